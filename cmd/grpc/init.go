@@ -2,6 +2,8 @@ package main
 
 import (
 	"github.com/vangxitrum/payment-host/config"
+	"github.com/vangxitrum/payment-host/internal/common/gasprice"
+	"github.com/vangxitrum/payment-host/internal/common/slack"
 	"github.com/vangxitrum/payment-host/internal/crons"
 	"github.com/vangxitrum/payment-host/internal/models"
 	"github.com/vangxitrum/payment-host/internal/services"
@@ -17,6 +19,10 @@ var (
 	paymentMarkRepo models.PaymentMarkRepository
 	walletRepo      models.WalletRepository
 	txRepo          models.TransactionRepository
+	idempotencyRepo models.IdempotencyRepository
+	apiKeyRepo      models.ApiKeyRepository
+	withdrawalRepo  models.WithdrawalRepository
+	invoiceRepo     models.InvoiceRepository
 
 	entityService services.EntityService
 )
@@ -29,17 +35,31 @@ func init() {
 	paymentMarkRepo = db.MustNewPaymentMarkRepository(db.DB, true)
 	walletRepo = db.MustNewWalletRepository(db.DB, true)
 	txRepo = db.MustNewTransactionRepository(db.DB, true)
+	idempotencyRepo = db.MustNewIdempotencyRepository(db.DB, true)
+	apiKeyRepo = db.MustNewApiKeyRepository(db.DB, true)
+	withdrawalRepo = db.MustNewWithdrawalRepository(db.DB, true)
+	invoiceRepo = db.MustNewInvoiceRepository(db.DB, true)
 
 	entityService = v1.MustNewEntityService(
 		appConfig.RpcUrl,
 		appConfig.EvmUrl,
 		appConfig.PassPhrase,
 		appConfig.BusinessAddr,
+		appConfig.TokenContracts,
+		gasprice.MustParseCaps(appConfig.MaxFeePerGas, appConfig.MaxPriorityFeePerGas),
+		appConfig.SweepThresholds,
+		appConfig.HotWalletPrivateKey,
+		appConfig.TreasuryColdAddr,
+		appConfig.TreasuryRanges,
+		slack.NewClient(appConfig.OathTokenBot, appConfig.ChannelId),
 
 		entityRepo,
 		paymentMarkRepo,
 		walletRepo,
 		txRepo,
+		apiKeyRepo,
+		withdrawalRepo,
+		invoiceRepo,
 	)
 
 	entityService = v1.NewEntityLogService(entityService)
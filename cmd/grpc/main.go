@@ -9,6 +9,32 @@ import (
 func main() {
 	fmt.Printf("Payment host server is running on port %s\n", appConfig.ServerPort)
 
+	authConfig := &server.AuthConfig{
+		Enabled:              appConfig.AuthEnabled,
+		AllowUnauthenticated: appConfig.AllowUnauthenticated,
+		AdminApiKeys:         appConfig.AdminApiKeys,
+		AdminCertNames:       appConfig.AdminCertNames,
+		TLSCertFile:          appConfig.TLSCertFile,
+		TLSKeyFile:           appConfig.TLSKeyFile,
+		TLSClientCAFile:      appConfig.TLSClientCAFile,
+		TLSServerName:        appConfig.TLSServerName,
+	}
+
+	if appConfig.GatewayPort != "" {
+		fmt.Printf("REST gateway is running on port %s\n", appConfig.GatewayPort)
+		go server.MustMakeGatewayServerAndRun(
+			fmt.Sprintf(":%s", appConfig.GatewayPort),
+			fmt.Sprintf("localhost:%s", appConfig.ServerPort),
+			authConfig,
+		)
+	}
+
 	cron.Start()
-	server.MustMakeGrpcPaymentHostServerAndRun(fmt.Sprintf(":%s", appConfig.ServerPort), entityService)
+	server.MustMakeGrpcPaymentHostServerAndRun(
+		fmt.Sprintf(":%s", appConfig.ServerPort),
+		entityService,
+		idempotencyRepo,
+		apiKeyRepo,
+		authConfig,
+	)
 }
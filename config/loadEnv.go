@@ -3,7 +3,8 @@ package config
 import "github.com/spf13/viper"
 
 type Config struct {
-	ServerPort string `mapstructure:"SERVER_PORT" validate:"required"`
+	ServerPort  string `mapstructure:"SERVER_PORT" validate:"required"`
+	GatewayPort string `mapstructure:"GATEWAY_PORT"`
 
 	PostgresHost     string `mapstructure:"POSTGRES_HOST" required:"true"`
 	PostgresPort     string `mapstructure:"POSTGRES_PORT" required:"true"`
@@ -16,6 +17,26 @@ type Config struct {
 	RpcUrl       string `mapstructure:"RPC_URL" required:"true"`
 	EvmUrl       string `mapstructure:"EVM_URL" required:"true"`
 
+	TokenContracts []string `mapstructure:"TOKEN_CONTRACTS"`
+
+	MaxFeePerGas         string `mapstructure:"MAX_FEE_PER_GAS"`
+	MaxPriorityFeePerGas string `mapstructure:"MAX_PRIORITY_FEE_PER_GAS"`
+
+	SweepThresholds     []string `mapstructure:"SWEEP_THRESHOLDS"`
+	HotWalletPrivateKey string   `mapstructure:"HOT_WALLET_PRIVATE_KEY"`
+
+	TreasuryColdAddr string   `mapstructure:"TREASURY_COLD_ADDR"`
+	TreasuryRanges   []string `mapstructure:"TREASURY_RANGES"`
+
+	AuthEnabled          bool     `mapstructure:"AUTH_ENABLED"`
+	AllowUnauthenticated bool     `mapstructure:"ALLOW_UNAUTHENTICATED"`
+	AdminApiKeys         []string `mapstructure:"ADMIN_API_KEYS"`
+	AdminCertNames       []string `mapstructure:"ADMIN_CERT_NAMES"`
+	TLSCertFile          string   `mapstructure:"TLS_CERT_FILE"`
+	TLSKeyFile           string   `mapstructure:"TLS_KEY_FILE"`
+	TLSClientCAFile      string   `mapstructure:"TLS_CLIENT_CA_FILE"`
+	TLSServerName        string   `mapstructure:"TLS_SERVER_NAME"`
+
 	OathTokenBot string `mapstructure:"OATH_TOKEN_BOT" required:"true"`
 	ChannelId    string `mapstructure:"CHANNEL_ID" required:"true"`
 }
@@ -25,6 +46,7 @@ func LoadEnv(path, filename string) *Config {
 	viper.SetConfigName(filename)
 	viper.AddConfigPath(path)
 	viper.SetConfigType("env")
+	viper.SetDefault("AUTH_ENABLED", true)
 	if err := viper.ReadInConfig(); err != nil {
 		panic(err)
 	}
@@ -0,0 +1,46 @@
+package blockchain
+
+import (
+	"fmt"
+
+	"github.com/shopspring/decimal"
+	"github.com/vangxitrum/payment-host/internal/common/aiozcoin"
+)
+
+// denomExponents maps every native denomination accepted by the API to the
+// power of ten it represents in the base denomination.
+var denomExponents = map[string]int32{
+	StandardDenom:         aiozcoin.BaseDenomUnit,
+	MilliDenom:            aiozcoin.BaseDenomUnit - 3,
+	MicroDenom:            aiozcoin.BaseDenomUnit - 6,
+	NanoDenom:             aiozcoin.BaseDenomUnit - 9,
+	aiozcoin.DefaultDenom: 0,
+}
+
+// IsNativeDenom reports whether denom is one of the AIOZ denominations.
+func IsNativeDenom(denom string) bool {
+	_, ok := denomExponents[denom]
+	return ok
+}
+
+// ToBaseAmount converts an amount expressed in a native denomination into
+// attoaioz.
+func ToBaseAmount(amount decimal.Decimal, denom string) (decimal.Decimal, error) {
+	exp, ok := denomExponents[denom]
+	if !ok {
+		return decimal.Zero, fmt.Errorf("unknown denom %q", denom)
+	}
+
+	return ScaleAmount(amount, exp)
+}
+
+// ScaleAmount multiplies amount by 10^decimals. The conversion is exact, so an
+// amount finer than the smallest unit is rejected instead of being rounded.
+func ScaleAmount(amount decimal.Decimal, decimals int32) (decimal.Decimal, error) {
+	scaled := amount.Shift(decimals)
+	if !scaled.Equal(scaled.Truncate(0)) {
+		return decimal.Zero, fmt.Errorf("amount %s has more than %d decimal places", amount.String(), decimals)
+	}
+
+	return scaled.Truncate(0), nil
+}
@@ -0,0 +1,44 @@
+package blockchain
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestToBaseAmount(t *testing.T) {
+	cases := []struct {
+		amount string
+		denom  string
+		want   string
+		err    bool
+	}{
+		{amount: "1", denom: StandardDenom, want: "1000000000000000000"},
+		{amount: "12345.678", denom: StandardDenom, want: "12345678000000000000000"},
+		{amount: "0.5", denom: MilliDenom, want: "500000000000000"},
+		{amount: "42", denom: "attoaioz", want: "42"},
+		{amount: "1.5", denom: "attoaioz", err: true},
+		{amount: "0.0000000000000000001", denom: StandardDenom, err: true},
+		{amount: "1", denom: "uatom", err: true},
+	}
+
+	for _, c := range cases {
+		got, err := ToBaseAmount(decimal.RequireFromString(c.amount), c.denom)
+		if c.err {
+			if err == nil {
+				t.Errorf("ToBaseAmount(%s, %s) expected error, got %s", c.amount, c.denom, got)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("ToBaseAmount(%s, %s) unexpected error: %v", c.amount, c.denom, err)
+			continue
+		}
+
+		if got.String() != c.want {
+			t.Errorf("ToBaseAmount(%s, %s) = %s, want %s", c.amount, c.denom, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,200 @@
+// Package cosmostx builds, signs and broadcasts bank transfers on the Cosmos
+// side of the chain for eth_secp256k1 accounts.
+package cosmostx
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+
+	codectypes "github.com/cosmos/cosmos-sdk/codec/types"
+	cryptocodec "github.com/cosmos/cosmos-sdk/crypto/codec"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	txtypes "github.com/cosmos/cosmos-sdk/types/tx"
+	"github.com/cosmos/cosmos-sdk/types/tx/signing"
+	authtypes "github.com/cosmos/cosmos-sdk/x/auth/types"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	ethcodec "github.com/evmos/ethermint/crypto/codec"
+	"github.com/evmos/ethermint/crypto/ethsecp256k1"
+	ethermint "github.com/evmos/ethermint/types"
+	"github.com/tendermint/tendermint/libs/bytes"
+	coretypes "github.com/tendermint/tendermint/rpc/core/types"
+	tmtypes "github.com/tendermint/tendermint/types"
+)
+
+// Client is the part of the Tendermint RPC client needed to send a transfer.
+type Client interface {
+	ABCIQuery(ctx context.Context, path string, data bytes.HexBytes) (*coretypes.ResultABCIQuery, error)
+	BroadcastTxSync(ctx context.Context, tx tmtypes.Tx) (*coretypes.ResultBroadcastTx, error)
+}
+
+var registry = newInterfaceRegistry()
+
+func newInterfaceRegistry() codectypes.InterfaceRegistry {
+	registry := codectypes.NewInterfaceRegistry()
+	cryptocodec.RegisterInterfaces(registry)
+	ethcodec.RegisterInterfaces(registry)
+	authtypes.RegisterInterfaces(registry)
+	ethermint.RegisterInterfaces(registry)
+	return registry
+}
+
+// AccountNumber returns the account number of addr. The account only exists
+// once it received funds.
+func AccountNumber(ctx context.Context, client Client, addr sdk.AccAddress) (uint64, error) {
+	req, err := (&authtypes.QueryAccountRequest{Address: addr.String()}).Marshal()
+	if err != nil {
+		return 0, err
+	}
+
+	var res authtypes.QueryAccountResponse
+	if err := query(ctx, client, "/cosmos.auth.v1beta1.Query/Account", req, &res); err != nil {
+		return 0, err
+	}
+
+	var account authtypes.AccountI
+	if err := registry.UnpackAny(res.Account, &account); err != nil {
+		return 0, err
+	}
+
+	return account.GetAccountNumber(), nil
+}
+
+// Simulate runs txBytes against the latest state and returns the gas it used.
+func Simulate(ctx context.Context, client Client, txBytes []byte) (uint64, error) {
+	req, err := (&txtypes.SimulateRequest{TxBytes: txBytes}).Marshal()
+	if err != nil {
+		return 0, err
+	}
+
+	var res txtypes.SimulateResponse
+	if err := query(ctx, client, "/cosmos.tx.v1beta1.Service/Simulate", req, &res); err != nil {
+		return 0, err
+	}
+
+	return res.GasInfo.GasUsed, nil
+}
+
+// Broadcast submits txBytes and waits for it to pass CheckTx.
+func Broadcast(ctx context.Context, client Client, txBytes []byte) error {
+	res, err := client.BroadcastTxSync(ctx, txBytes)
+	if err != nil {
+		return err
+	}
+
+	if res.Code != 0 {
+		return fmt.Errorf("transaction rejected with code %d: %s", res.Code, res.Log)
+	}
+
+	return nil
+}
+
+// Hash returns the hash txBytes is known by once broadcast, in the upper case
+// hex form used by the RPC.
+func Hash(txBytes []byte) string {
+	return bytes.HexBytes(tmtypes.Tx(txBytes).Hash()).String()
+}
+
+// Memo returns the memo of the encoded transaction txBytes.
+func Memo(txBytes []byte) (string, error) {
+	var raw txtypes.TxRaw
+	if err := raw.Unmarshal(txBytes); err != nil {
+		return "", err
+	}
+
+	var body txtypes.TxBody
+	if err := body.Unmarshal(raw.BodyBytes); err != nil {
+		return "", err
+	}
+
+	return body.Memo, nil
+}
+
+func query(ctx context.Context, client Client, path string, req []byte, res interface{ Unmarshal([]byte) error }) error {
+	rs, err := client.ABCIQuery(ctx, path, req)
+	if err != nil {
+		return err
+	}
+
+	if rs.Response.Code != 0 {
+		return fmt.Errorf("query %s failed with code %d: %s", path, rs.Response.Code, rs.Response.Log)
+	}
+
+	return res.Unmarshal(rs.Response.Value)
+}
+
+// Send is a bank MsgSend from the account of the signing key.
+type Send struct {
+	ChainId       string
+	AccountNumber uint64
+	Sequence      uint64
+	To            sdk.AccAddress
+	Amount        sdk.Coins
+	GasLimit      uint64
+	Fee           sdk.Coins
+}
+
+// Sign returns the transaction signed with key in direct mode, encoded for
+// broadcasting.
+func (s *Send) Sign(key *ecdsa.PrivateKey) ([]byte, error) {
+	privKey := &ethsecp256k1.PrivKey{Key: crypto.FromECDSA(key)}
+	pubKey := privKey.PubKey()
+
+	msg, err := codectypes.NewAnyWithValue(banktypes.NewMsgSend(sdk.AccAddress(pubKey.Address()), s.To, s.Amount))
+	if err != nil {
+		return nil, err
+	}
+
+	bodyBytes, err := (&txtypes.TxBody{Messages: []*codectypes.Any{msg}}).Marshal()
+	if err != nil {
+		return nil, err
+	}
+
+	pubKeyAny, err := codectypes.NewAnyWithValue(pubKey)
+	if err != nil {
+		return nil, err
+	}
+
+	authInfoBytes, err := (&txtypes.AuthInfo{
+		SignerInfos: []*txtypes.SignerInfo{{
+			PublicKey: pubKeyAny,
+			ModeInfo: &txtypes.ModeInfo{
+				Sum: &txtypes.ModeInfo_Single_{
+					Single: &txtypes.ModeInfo_Single{Mode: signing.SignMode_SIGN_MODE_DIRECT},
+				},
+			},
+			Sequence: s.Sequence,
+		}},
+		Fee: &txtypes.Fee{
+			Amount:   s.Fee,
+			GasLimit: s.GasLimit,
+		},
+	}).Marshal()
+	if err != nil {
+		return nil, err
+	}
+
+	signBytes, err := (&txtypes.SignDoc{
+		BodyBytes:     bodyBytes,
+		AuthInfoBytes: authInfoBytes,
+		ChainId:       s.ChainId,
+		AccountNumber: s.AccountNumber,
+	}).Marshal()
+	if err != nil {
+		return nil, err
+	}
+
+	// The key hashes signBytes with keccak256 before signing, as
+	// eth_secp256k1 verification expects.
+	signature, err := privKey.Sign(signBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	return (&txtypes.TxRaw{
+		BodyBytes:     bodyBytes,
+		AuthInfoBytes: authInfoBytes,
+		Signatures:    [][]byte{signature},
+	}).Marshal()
+}
@@ -0,0 +1,104 @@
+package cosmostx
+
+import (
+	"testing"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	txtypes "github.com/cosmos/cosmos-sdk/types/tx"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/evmos/ethermint/crypto/ethsecp256k1"
+)
+
+func TestSendSign(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	send := &Send{
+		ChainId:       "aioz_168-1",
+		AccountNumber: 7,
+		Sequence:      3,
+		To:            sdk.AccAddress(crypto.PubkeyToAddress(key.PublicKey).Bytes()),
+		Amount:        sdk.NewCoins(sdk.NewInt64Coin("attoaioz", 100)),
+		GasLimit:      100000,
+		Fee:           sdk.NewCoins(sdk.NewInt64Coin("attoaioz", 10)),
+	}
+	txBytes, err := send.Sign(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var raw txtypes.TxRaw
+	if err := raw.Unmarshal(txBytes); err != nil {
+		t.Fatal(err)
+	}
+
+	var authInfo txtypes.AuthInfo
+	if err := authInfo.Unmarshal(raw.AuthInfoBytes); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := authInfo.SignerInfos[0].Sequence; got != send.Sequence {
+		t.Errorf("sequence = %d, want %d", got, send.Sequence)
+	}
+
+	var body txtypes.TxBody
+	if err := body.Unmarshal(raw.BodyBytes); err != nil {
+		t.Fatal(err)
+	}
+
+	var msg banktypes.MsgSend
+	if err := msg.Unmarshal(body.Messages[0].Value); err != nil {
+		t.Fatal(err)
+	}
+
+	if msg.ToAddress != send.To.String() || !sdk.Coins(msg.Amount).IsEqual(send.Amount) {
+		t.Errorf("unexpected message %v", msg)
+	}
+
+	var pubKey ethsecp256k1.PubKey
+	if err := pubKey.Unmarshal(authInfo.SignerInfos[0].PublicKey.Value); err != nil {
+		t.Fatal(err)
+	}
+
+	signBytes, err := (&txtypes.SignDoc{
+		BodyBytes:     raw.BodyBytes,
+		AuthInfoBytes: raw.AuthInfoBytes,
+		ChainId:       send.ChainId,
+		AccountNumber: send.AccountNumber,
+	}).Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !pubKey.VerifySignature(signBytes, raw.Signatures[0]) {
+		t.Error("signature does not verify")
+	}
+}
+
+func TestMemo(t *testing.T) {
+	bodyBytes, err := (&txtypes.TxBody{Memo: "acme"}).Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	txBytes, err := (&txtypes.TxRaw{BodyBytes: bodyBytes}).Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	memo, err := Memo(txBytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if memo != "acme" {
+		t.Errorf("memo = %q, want %q", memo, "acme")
+	}
+
+	if _, err := Memo([]byte{0xff}); err == nil {
+		t.Error("expected an error for a malformed transaction")
+	}
+}
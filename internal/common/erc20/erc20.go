@@ -0,0 +1,85 @@
+package erc20
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// ABI is the subset of the ERC-20 interface used by the payment host.
+const ABI = `[
+	{"constant":true,"inputs":[{"name":"owner","type":"address"}],"name":"balanceOf","outputs":[{"name":"","type":"uint256"}],"type":"function"},
+	{"constant":true,"inputs":[],"name":"decimals","outputs":[{"name":"","type":"uint8"}],"type":"function"},
+	{"constant":false,"inputs":[{"name":"to","type":"address"},{"name":"value","type":"uint256"}],"name":"transfer","outputs":[{"name":"","type":"bool"}],"type":"function"}
+]`
+
+var parsedABI = mustParseABI()
+
+func mustParseABI() abi.ABI {
+	parsed, err := abi.JSON(strings.NewReader(ABI))
+	if err != nil {
+		panic(err)
+	}
+
+	return parsed
+}
+
+// BalanceOf returns the token balance of owner at the latest block.
+func BalanceOf(ctx context.Context, caller ethereum.ContractCaller, contract, owner common.Address) (*big.Int, error) {
+	data, err := parsedABI.Pack("balanceOf", owner)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := caller.CallContract(ctx, ethereum.CallMsg{To: &contract, Data: data}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	values, err := parsedABI.Unpack("balanceOf", out)
+	if err != nil {
+		return nil, err
+	}
+
+	balance, ok := values[0].(*big.Int)
+	if !ok {
+		return nil, fmt.Errorf("unexpected balanceOf result")
+	}
+
+	return balance, nil
+}
+
+// Decimals returns the number of decimals the token uses for display.
+func Decimals(ctx context.Context, caller ethereum.ContractCaller, contract common.Address) (uint8, error) {
+	data, err := parsedABI.Pack("decimals")
+	if err != nil {
+		return 0, err
+	}
+
+	out, err := caller.CallContract(ctx, ethereum.CallMsg{To: &contract, Data: data}, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	values, err := parsedABI.Unpack("decimals", out)
+	if err != nil {
+		return 0, err
+	}
+
+	decimals, ok := values[0].(uint8)
+	if !ok {
+		return 0, fmt.Errorf("unexpected decimals result")
+	}
+
+	return decimals, nil
+}
+
+// PackTransfer encodes the call data of transfer(to, amount).
+func PackTransfer(to common.Address, amount *big.Int) ([]byte, error) {
+	return parsedABI.Pack("transfer", to, amount)
+}
@@ -0,0 +1,247 @@
+package gasprice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// ErrFeeCapTooLow is returned when the fee cap left by the caps does not cover
+// the current base fee plus the tip, so the transaction could not be mined.
+var ErrFeeCapTooLow = errors.New("fee cap is below the base fee plus the tip")
+
+// Backend is the part of the EVM client needed to price a transaction.
+type Backend interface {
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+	SuggestGasPrice(ctx context.Context) (*big.Int, error)
+	SuggestGasTipCap(ctx context.Context) (*big.Int, error)
+}
+
+// Caps bound what a transaction is willing to pay per unit of gas, in
+// attoaioz. A nil cap is not enforced.
+type Caps struct {
+	MaxFeePerGas         *big.Int
+	MaxPriorityFeePerGas *big.Int
+}
+
+// MustParseCaps parses the caps from their decimal string form. Empty values
+// leave the matching cap unset.
+func MustParseCaps(maxFeePerGas, maxPriorityFeePerGas string) Caps {
+	return Caps{
+		MaxFeePerGas:         mustParseWei("max fee per gas", maxFeePerGas),
+		MaxPriorityFeePerGas: mustParseWei("max priority fee per gas", maxPriorityFeePerGas),
+	}
+}
+
+func mustParseWei(name, value string) *big.Int {
+	if value == "" {
+		return nil
+	}
+
+	wei, ok := new(big.Int).SetString(value, 10)
+	if !ok || wei.Sign() <= 0 {
+		panic(fmt.Sprintf("invalid %s %q", name, value))
+	}
+
+	return wei
+}
+
+// Pricing is the fee configuration of a single transaction. Dynamic pricing
+// produces an EIP-1559 transaction, otherwise a legacy one using GasPrice.
+type Pricing struct {
+	Dynamic   bool
+	GasPrice  *big.Int
+	GasTipCap *big.Int
+	GasFeeCap *big.Int
+}
+
+// Suggest prices a transaction from the node's suggestions, bounded by caps.
+// Dynamic fees are used when the latest header carries a base fee, legacy
+// pricing is used for nodes that have not activated London. It fails with
+// ErrFeeCapTooLow when the capped fee cap is below the base fee plus the tip.
+func Suggest(ctx context.Context, backend Backend, caps Caps) (*Pricing, error) {
+	pricing, baseFee, err := suggest(ctx, backend, caps)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := pricing.checkBaseFee(baseFee); err != nil {
+		return nil, err
+	}
+
+	return pricing, nil
+}
+
+// suggest prices a transaction like Suggest and returns the base fee it was
+// priced against, nil for legacy pricing.
+func suggest(ctx context.Context, backend Backend, caps Caps) (*Pricing, *big.Int, error) {
+	header, err := backend.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if header.BaseFee == nil {
+		gasPrice, err := backend.SuggestGasPrice(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return &Pricing{GasPrice: capped(gasPrice, caps.MaxFeePerGas)}, nil, nil
+	}
+
+	tip, err := backend.SuggestGasTipCap(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tip = capped(tip, caps.MaxPriorityFeePerGas)
+
+	// Twice the base fee leaves room for six consecutive full blocks before
+	// the transaction stops being includable, the same margin geth uses.
+	feeCap := new(big.Int).Add(new(big.Int).Mul(header.BaseFee, big.NewInt(2)), tip)
+	feeCap = capped(feeCap, caps.MaxFeePerGas)
+	tip = capped(tip, feeCap)
+
+	return &Pricing{
+		Dynamic:   true,
+		GasTipCap: tip,
+		GasFeeCap: feeCap,
+	}, header.BaseFee, nil
+}
+
+// checkBaseFee fails with ErrFeeCapTooLow when dynamic pricing can not pay
+// baseFee plus its tip.
+func (p *Pricing) checkBaseFee(baseFee *big.Int) error {
+	if !p.Dynamic || baseFee == nil {
+		return nil
+	}
+
+	if required := new(big.Int).Add(baseFee, p.GasTipCap); p.GasFeeCap.Cmp(required) < 0 {
+		return fmt.Errorf("%w: fee cap %s, base fee %s, tip %s", ErrFeeCapTooLow, p.GasFeeCap, baseFee, p.GasTipCap)
+	}
+
+	return nil
+}
+
+// Flat returns a single price per gas for transactions that pay their whole
+// fee up front, such as Cosmos transactions: the node's gas price suggestion
+// bounded by the fee cap.
+func Flat(ctx context.Context, backend Backend, caps Caps) (*big.Int, error) {
+	gasPrice, err := backend.SuggestGasPrice(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return capped(gasPrice, caps.MaxFeePerGas), nil
+}
+
+// Replace prices a transaction that replaces a pending one with the same
+// nonce. Nodes only accept a replacement paying more, so the suggestion is
+// raised to at least prevTipCap and prevFeeCap plus 12.5%. The result can
+// exceed caps, callers decide whether to go ahead. Like Suggest it fails with
+// ErrFeeCapTooLow when the raised fee cap still misses the base fee plus the
+// tip.
+func Replace(ctx context.Context, backend Backend, caps Caps, prevTipCap, prevFeeCap *big.Int) (*Pricing, error) {
+	pricing, baseFee, err := suggest(ctx, backend, caps)
+	if err != nil {
+		return nil, err
+	}
+
+	minFeeCap := bump(prevFeeCap)
+	if !pricing.Dynamic {
+		pricing.GasPrice = atLeast(pricing.GasPrice, minFeeCap)
+		return pricing, nil
+	}
+
+	pricing.GasTipCap = atLeast(pricing.GasTipCap, bump(prevTipCap))
+	pricing.GasFeeCap = atLeast(pricing.GasFeeCap, minFeeCap)
+	pricing.GasFeeCap = atLeast(pricing.GasFeeCap, pricing.GasTipCap)
+
+	if err := pricing.checkBaseFee(baseFee); err != nil {
+		return nil, err
+	}
+
+	return pricing, nil
+}
+
+// Exceeds reports the first cap pricing goes over, with the price asked for
+// and the cap.
+func (c Caps) Exceeds(pricing *Pricing) (string, *big.Int, *big.Int, bool) {
+	if c.MaxFeePerGas != nil && pricing.MaxFeePerGas().Cmp(c.MaxFeePerGas) > 0 {
+		return "max fee per gas", pricing.MaxFeePerGas(), c.MaxFeePerGas, true
+	}
+
+	if pricing.Dynamic && c.MaxPriorityFeePerGas != nil && pricing.GasTipCap.Cmp(c.MaxPriorityFeePerGas) > 0 {
+		return "max priority fee per gas", pricing.GasTipCap, c.MaxPriorityFeePerGas, true
+	}
+
+	return "", nil, nil, false
+}
+
+func bump(value *big.Int) *big.Int {
+	bumped := new(big.Int).Mul(value, big.NewInt(9))
+	bumped.Add(bumped, big.NewInt(7))
+
+	return bumped.Div(bumped, big.NewInt(8))
+}
+
+func atLeast(value, floor *big.Int) *big.Int {
+	if value.Cmp(floor) < 0 {
+		return new(big.Int).Set(floor)
+	}
+
+	return value
+}
+
+// MaxFeePerGas returns the most the transaction can pay per unit of gas.
+func (p *Pricing) MaxFeePerGas() *big.Int {
+	if p.Dynamic {
+		return p.GasFeeCap
+	}
+
+	return p.GasPrice
+}
+
+// NewTx builds an unsigned transaction with this pricing.
+func (p *Pricing) NewTx(
+	chainId *big.Int,
+	nonce uint64,
+	to common.Address,
+	value *big.Int,
+	gasLimit uint64,
+	data []byte,
+) *types.Transaction {
+	if !p.Dynamic {
+		return types.NewTx(&types.LegacyTx{
+			Nonce:    nonce,
+			To:       &to,
+			Value:    value,
+			Gas:      gasLimit,
+			GasPrice: p.GasPrice,
+			Data:     data,
+		})
+	}
+
+	return types.NewTx(&types.DynamicFeeTx{
+		ChainID:   chainId,
+		Nonce:     nonce,
+		To:        &to,
+		Value:     value,
+		Gas:       gasLimit,
+		GasTipCap: p.GasTipCap,
+		GasFeeCap: p.GasFeeCap,
+		Data:      data,
+	})
+}
+
+func capped(value, limit *big.Int) *big.Int {
+	if limit != nil && value.Cmp(limit) > 0 {
+		return new(big.Int).Set(limit)
+	}
+
+	return value
+}
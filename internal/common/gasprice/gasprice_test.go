@@ -0,0 +1,106 @@
+package gasprice
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+type fakeBackend struct {
+	baseFee  *big.Int
+	gasPrice *big.Int
+	tip      *big.Int
+}
+
+func (b *fakeBackend) HeaderByNumber(context.Context, *big.Int) (*types.Header, error) {
+	return &types.Header{BaseFee: b.baseFee}, nil
+}
+
+func (b *fakeBackend) SuggestGasPrice(context.Context) (*big.Int, error) {
+	return b.gasPrice, nil
+}
+
+func (b *fakeBackend) SuggestGasTipCap(context.Context) (*big.Int, error) {
+	return b.tip, nil
+}
+
+func TestSuggest(t *testing.T) {
+	cases := []struct {
+		name    string
+		backend *fakeBackend
+		caps    Caps
+		dynamic bool
+		maxFee  int64
+		tip     int64
+		err     error
+	}{
+		{
+			name:    "legacy",
+			backend: &fakeBackend{gasPrice: big.NewInt(30)},
+			maxFee:  30,
+		},
+		{
+			name:    "legacy capped",
+			backend: &fakeBackend{gasPrice: big.NewInt(30)},
+			caps:    Caps{MaxFeePerGas: big.NewInt(20)},
+			maxFee:  20,
+		},
+		{
+			name:    "dynamic",
+			backend: &fakeBackend{baseFee: big.NewInt(10), tip: big.NewInt(2)},
+			dynamic: true,
+			maxFee:  22,
+			tip:     2,
+		},
+		{
+			name:    "dynamic capped",
+			backend: &fakeBackend{baseFee: big.NewInt(10), tip: big.NewInt(5)},
+			caps:    Caps{MaxFeePerGas: big.NewInt(15), MaxPriorityFeePerGas: big.NewInt(3)},
+			dynamic: true,
+			maxFee:  15,
+			tip:     3,
+		},
+		{
+			name:    "tip above fee cap",
+			backend: &fakeBackend{baseFee: big.NewInt(10), tip: big.NewInt(5)},
+			caps:    Caps{MaxFeePerGas: big.NewInt(4)},
+			err:     ErrFeeCapTooLow,
+		},
+		{
+			name:    "fee cap below base fee plus tip",
+			backend: &fakeBackend{baseFee: big.NewInt(10), tip: big.NewInt(5)},
+			caps:    Caps{MaxFeePerGas: big.NewInt(12)},
+			err:     ErrFeeCapTooLow,
+		},
+	}
+
+	for _, c := range cases {
+		pricing, err := Suggest(context.Background(), c.backend, c.caps)
+		if c.err != nil {
+			if !errors.Is(err, c.err) {
+				t.Errorf("%s: error = %v, want %v", c.name, err, c.err)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			t.Fatalf("%s: unexpected error %v", c.name, err)
+		}
+
+		if pricing.Dynamic != c.dynamic {
+			t.Errorf("%s: dynamic = %v, want %v", c.name, pricing.Dynamic, c.dynamic)
+		}
+
+		if pricing.MaxFeePerGas().Int64() != c.maxFee {
+			t.Errorf("%s: max fee = %s, want %d", c.name, pricing.MaxFeePerGas(), c.maxFee)
+		}
+
+		if c.dynamic && pricing.GasTipCap.Int64() != c.tip {
+			t.Errorf("%s: tip = %s, want %d", c.name, pricing.GasTipCap, c.tip)
+		}
+	}
+}
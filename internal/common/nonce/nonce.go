@@ -0,0 +1,186 @@
+package nonce
+
+import (
+	"context"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Source is the node's view of an account nonce.
+type Source interface {
+	PendingNonceAt(ctx context.Context, account common.Address) (uint64, error)
+}
+
+// FillFunc occupies nonce of account with a transaction that has no effect,
+// typically a zero-value transfer to itself.
+type FillFunc func(ctx context.Context, account common.Address, nonce uint64) error
+
+// Manager hands out nonces for the wallets the payment host signs with. It
+// serializes signing per address, so concurrent transfers from one wallet
+// never share a nonce, and only asks the node again after a failure.
+type Manager struct {
+	source Source
+
+	mu      sync.Mutex
+	wallets map[common.Address]*wallet
+}
+
+type wallet struct {
+	// lock is held for the lifetime of a lease, a channel so that waiting
+	// can be abandoned with the context.
+	lock   chan struct{}
+	next   uint64
+	synced bool
+}
+
+func NewManager(source Source) *Manager {
+	return &Manager{
+		source:  source,
+		wallets: make(map[common.Address]*wallet),
+	}
+}
+
+// Lease is the exclusive right to sign the next transaction of a wallet with
+// Nonce. Exactly one of Commit or Release must be called.
+type Lease struct {
+	Nonce uint64
+
+	w    *wallet
+	done bool
+}
+
+// Acquire waits until no other lease is held for account and returns a lease
+// on its next nonce.
+func (m *Manager) Acquire(ctx context.Context, account common.Address) (*Lease, error) {
+	w, err := m.lock(ctx, account)
+	if err != nil {
+		return nil, err
+	}
+
+	if !w.synced {
+		next, err := m.source.PendingNonceAt(ctx, account)
+		if err != nil {
+			<-w.lock
+			return nil, err
+		}
+
+		w.next = next
+		w.synced = true
+	}
+
+	return &Lease{Nonce: w.next, w: w}, nil
+}
+
+// Commit records that a transaction using the leased nonce reached the node.
+func (l *Lease) Commit() {
+	if l.done {
+		return
+	}
+
+	l.w.next = l.Nonce + 1
+	l.release()
+}
+
+// Release gives the nonce back unused. A non-nil err means the node may or may
+// not have seen the transaction, so the next lease resynchronizes with it.
+func (l *Lease) Release(err error) {
+	if l.done {
+		return
+	}
+
+	if err != nil {
+		l.w.synced = false
+	}
+
+	l.release()
+}
+
+func (l *Lease) release() {
+	l.done = true
+	<-l.w.lock
+}
+
+// Reconcile compares every known wallet with the node. A pending nonce ahead
+// of the local one means transactions were sent from elsewhere and is adopted.
+// A pending nonce behind it means a handed out nonce never reached the node,
+// which blocks every later transaction, so the gap is passed to fill.
+func (m *Manager) Reconcile(ctx context.Context, fill FillFunc) error {
+	m.mu.Lock()
+	accounts := make([]common.Address, 0, len(m.wallets))
+	for account := range m.wallets {
+		accounts = append(accounts, account)
+	}
+	m.mu.Unlock()
+
+	var firstErr error
+	for _, account := range accounts {
+		if err := m.reconcile(ctx, account, fill); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return firstErr
+}
+
+func (m *Manager) reconcile(ctx context.Context, account common.Address, fill FillFunc) error {
+	w, err := m.lock(ctx, account)
+	if err != nil {
+		return err
+	}
+	defer func() { <-w.lock }()
+
+	pending, err := m.source.PendingNonceAt(ctx, account)
+	if err != nil {
+		return err
+	}
+
+	if !w.synced || pending >= w.next {
+		w.next = pending
+		w.synced = true
+		return nil
+	}
+
+	// The node reports the first nonce missing from its pool, so gaps are
+	// filled one at a time: queued transactions behind a filled gap become
+	// pending and move the reported nonce past them.
+	for pending < w.next {
+		if err := fill(ctx, account, pending); err != nil {
+			w.synced = false
+			return err
+		}
+
+		next, err := m.source.PendingNonceAt(ctx, account)
+		if err != nil {
+			w.synced = false
+			return err
+		}
+
+		if next <= pending {
+			w.synced = false
+			return nil
+		}
+
+		pending = next
+	}
+
+	w.next = pending
+	return nil
+}
+
+func (m *Manager) lock(ctx context.Context, account common.Address) (*wallet, error) {
+	m.mu.Lock()
+	w, ok := m.wallets[account]
+	if !ok {
+		w = &wallet{lock: make(chan struct{}, 1)}
+		m.wallets[account] = w
+	}
+	m.mu.Unlock()
+
+	select {
+	case w.lock <- struct{}{}:
+		return w, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
@@ -0,0 +1,113 @@
+package nonce
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+type fakeSource struct {
+	mu      sync.Mutex
+	pending uint64
+	calls   int
+}
+
+func (s *fakeSource) PendingNonceAt(context.Context, common.Address) (uint64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.calls++
+	return s.pending, nil
+}
+
+func TestAcquireHandsOutDistinctNonces(t *testing.T) {
+	source := &fakeSource{pending: 7}
+	manager := NewManager(source)
+	account := common.HexToAddress("0x1")
+
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		nonces = make(map[uint64]bool)
+	)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			lease, err := manager.Acquire(context.Background(), account)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+
+			mu.Lock()
+			nonces[lease.Nonce] = true
+			mu.Unlock()
+			lease.Commit()
+		}()
+	}
+	wg.Wait()
+
+	for n := uint64(7); n < 27; n++ {
+		if !nonces[n] {
+			t.Errorf("nonce %d was not handed out", n)
+		}
+	}
+
+	if source.calls != 1 {
+		t.Errorf("node queried %d times, want 1", source.calls)
+	}
+}
+
+func TestReleaseWithErrorResyncs(t *testing.T) {
+	source := &fakeSource{pending: 3}
+	manager := NewManager(source)
+	account := common.HexToAddress("0x1")
+
+	lease, _ := manager.Acquire(context.Background(), account)
+	lease.Release(errors.New("send failed"))
+
+	source.pending = 5
+	lease, _ = manager.Acquire(context.Background(), account)
+	if lease.Nonce != 5 {
+		t.Errorf("nonce = %d, want 5", lease.Nonce)
+	}
+	lease.Release(nil)
+}
+
+func TestReconcileFillsGaps(t *testing.T) {
+	source := &fakeSource{pending: 0}
+	manager := NewManager(source)
+	account := common.HexToAddress("0x1")
+
+	for i := 0; i < 3; i++ {
+		lease, _ := manager.Acquire(context.Background(), account)
+		lease.Commit()
+	}
+
+	// nonce 1 never reached the node, nonce 2 is queued behind it
+	source.pending = 1
+	var filled []uint64
+	err := manager.Reconcile(context.Background(), func(_ context.Context, _ common.Address, nonce uint64) error {
+		filled = append(filled, nonce)
+		source.pending = 3
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(filled) != 1 || filled[0] != 1 {
+		t.Errorf("filled %v, want [1]", filled)
+	}
+
+	lease, _ := manager.Acquire(context.Background(), account)
+	if lease.Nonce != 3 {
+		t.Errorf("nonce = %d, want 3", lease.Nonce)
+	}
+	lease.Release(nil)
+}
@@ -0,0 +1,84 @@
+// Package slack posts messages to a Slack channel through a bot token.
+package slack
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const postMessageUrl = "https://slack.com/api/chat.postMessage"
+
+// Client posts to a single channel. A nil Client posts nothing.
+type Client struct {
+	url        string
+	token      string
+	channelId  string
+	httpClient *http.Client
+}
+
+// NewClient returns a client posting to channelId with the bot token, or nil
+// when either is empty.
+func NewClient(token, channelId string) *Client {
+	if token == "" || channelId == "" {
+		return nil
+	}
+
+	return &Client{
+		url:        postMessageUrl,
+		token:      token,
+		channelId:  channelId,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// PostMessage posts text to the channel of the client.
+func (c *Client) PostMessage(ctx context.Context, text string) error {
+	if c == nil {
+		return nil
+	}
+
+	body, err := json.Marshal(map[string]string{
+		"channel": c.channelId,
+		"text":    text,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	req.Header.Set("Authorization", "Bearer "+c.token)
+
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("slack responded with status %d", res.StatusCode)
+	}
+
+	// Slack reports most failures with a 200 and ok set to false.
+	var rs struct {
+		Ok    bool   `json:"ok"`
+		Error string `json:"error"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&rs); err != nil {
+		return err
+	}
+
+	if !rs.Ok {
+		return fmt.Errorf("slack error: %s", rs.Error)
+	}
+
+	return nil
+}
@@ -0,0 +1,56 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPostMessage(t *testing.T) {
+	var got map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			t.Errorf("unexpected authorization %q", r.Header.Get("Authorization"))
+		}
+
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Error(err)
+		}
+
+		if got["text"] == "fail" {
+			w.Write([]byte(`{"ok":false,"error":"channel_not_found"}`))
+			return
+		}
+
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("token", "C123")
+	client.url = server.URL
+
+	if err := client.PostMessage(context.Background(), "hello"); err != nil {
+		t.Fatal(err)
+	}
+
+	if got["channel"] != "C123" || got["text"] != "hello" {
+		t.Fatalf("unexpected message %v", got)
+	}
+
+	if err := client.PostMessage(context.Background(), "fail"); err == nil {
+		t.Fatal("expected an error when slack reports one")
+	}
+}
+
+func TestNilClient(t *testing.T) {
+	client := NewClient("", "C123")
+	if client != nil {
+		t.Fatal("expected no client without a token")
+	}
+
+	if err := client.PostMessage(context.Background(), "hello"); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -25,5 +25,27 @@ func (c *Cron) Start() {
 		c.service.WatchTransaction(context.Background())
 	})
 
+	c.cron.AddFunc("@every 15s", func() {
+		c.service.TrackWithdrawals(context.Background())
+	})
+
+	c.cron.AddFunc("@every 1m", func() {
+		c.service.ReconcileNonces(context.Background())
+	})
+
+	c.cron.AddFunc("@every 5m", func() {
+		c.service.SweepDeposits(context.Background())
+	})
+
+	c.cron.AddFunc("@every 5m", func() {
+		c.service.RebalanceTreasury(context.Background())
+	})
+
+	// Picks up batches left behind by a restart, new batches start processing
+	// as soon as they are made.
+	c.cron.AddFunc("@every 30s", func() {
+		c.service.ProcessWithdrawalBatches(context.Background())
+	})
+
 	c.cron.Start()
 }
@@ -0,0 +1,59 @@
+package models
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const apiKeyPrefix = "phk_"
+
+type ApiKeyRepository interface {
+	Create(ctx context.Context, apiKey *ApiKey) error
+
+	GetApiKeyByHash(ctx context.Context, keyHash string) (*ApiKey, error)
+
+	RevokeApiKeyById(ctx context.Context, id uuid.UUID) error
+}
+
+// ApiKey authenticates calls made on behalf of a single entity. Only the hash
+// of the key is stored.
+type ApiKey struct {
+	Id        uuid.UUID `json:"id" gorm:"primaryKey;type:uuid"`
+	EntityId  uuid.UUID `json:"entity_id" gorm:"type:uuid;not null;index"`
+	KeyHash   string    `json:"-" gorm:"type:text;not null;uniqueIndex"`
+	CreatedAt int64     `json:"created_at" gorm:"not null"`
+	RevokedAt int64     `json:"revoked_at"`
+	Entity    *Entity   `json:"-" gorm:"foreignKey:EntityId"`
+}
+
+// NewApiKey generates a key for entityId and returns it along with the
+// plaintext key, which is not recoverable afterwards.
+func NewApiKey(entityId uuid.UUID) (*ApiKey, string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, "", err
+	}
+
+	key := apiKeyPrefix + base64.RawURLEncoding.EncodeToString(secret)
+	return &ApiKey{
+		Id:        uuid.New(),
+		EntityId:  entityId,
+		KeyHash:   HashApiKey(key),
+		CreatedAt: time.Now().UTC().Unix(),
+	}, key, nil
+}
+
+func HashApiKey(key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(hash[:])
+}
+
+func (k *ApiKey) IsRevoked() bool {
+	return k.RevokedAt != 0
+}
@@ -13,6 +13,9 @@ type EntityRepository interface {
 	GetEntityById(ctx context.Context, id uuid.UUID) (*Entity, error)
 	GetEntityByName(ctx context.Context, name string) (*Entity, error)
 	GetEntityByWalletAddress(ctx context.Context, walletAddress string) (*Entity, error)
+	// LockEntity locks the entity until the end of the database transaction
+	// it is called in.
+	LockEntity(ctx context.Context, id uuid.UUID) error
 
 	DeleteEntityById(ctx context.Context, id uuid.UUID) error
 }
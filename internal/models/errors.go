@@ -0,0 +1,161 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	ERROR_KIND_INVALID_ARGUMENT    = "invalid_argument"
+	ERROR_KIND_NOT_FOUND           = "not_found"
+	ERROR_KIND_FAILED_PRECONDITION = "failed_precondition"
+	ERROR_KIND_RESOURCE_EXHAUSTED  = "resource_exhausted"
+	ERROR_KIND_PERMISSION_DENIED   = "permission_denied"
+)
+
+// Reasons are stable, machine-readable identifiers clients can branch on.
+const (
+	REASON_INVALID_ARGUMENT      = "INVALID_ARGUMENT"
+	REASON_INVALID_ADDRESS       = "INVALID_ADDRESS"
+	REASON_INVALID_AMOUNT        = "INVALID_AMOUNT"
+	REASON_INVALID_CURSOR        = "INVALID_CURSOR"
+	REASON_UNSUPPORTED_DENOM     = "UNSUPPORTED_DENOM"
+	REASON_ENTITY_NOT_FOUND      = "ENTITY_NOT_FOUND"
+	REASON_API_KEY_NOT_FOUND     = "API_KEY_NOT_FOUND"
+	REASON_TX_NOT_FOUND          = "TRANSACTION_NOT_FOUND"
+	REASON_TX_NOT_PENDING        = "TRANSACTION_NOT_PENDING"
+	REASON_TX_ATTRIBUTED         = "TRANSACTION_ALREADY_ATTRIBUTED"
+	REASON_WITHDRAWAL_NOT_FOUND  = "WITHDRAWAL_NOT_FOUND"
+	REASON_WITHDRAWAL_DECIDED    = "WITHDRAWAL_ALREADY_DECIDED"
+	REASON_WITHDRAWAL_CHANGED    = "WITHDRAWAL_AMOUNT_CHANGED"
+	REASON_BATCH_NOT_FOUND       = "WITHDRAWAL_BATCH_NOT_FOUND"
+	REASON_QUOTE_NOT_FOUND       = "QUOTE_NOT_FOUND"
+	REASON_INVOICE_NOT_FOUND     = "INVOICE_NOT_FOUND"
+	REASON_INVOICE_NOT_OPEN      = "INVOICE_NOT_OPEN"
+	REASON_QUOTE_EXPIRED         = "QUOTE_EXPIRED"
+	REASON_QUOTE_USED            = "QUOTE_ALREADY_USED"
+	REASON_QUOTE_FEE_EXCEEDED    = "QUOTE_FEE_EXCEEDED"
+	REASON_SELF_APPROVAL         = "SELF_APPROVAL"
+	REASON_REQUESTER_UNKNOWN     = "REQUESTER_UNKNOWN"
+	REASON_DESTINATION_DENIED    = "DESTINATION_NOT_ALLOWED"
+	REASON_DESTINATION_COOLDOWN  = "DESTINATION_COOLING_DOWN"
+	REASON_DESTINATION_NOT_FOUND = "DESTINATION_NOT_FOUND"
+	REASON_INSUFFICIENT_BALANCE  = "INSUFFICIENT_BALANCE"
+	REASON_LIMIT_EXCEEDED        = "LIMIT_EXCEEDED"
+	REASON_FEE_CAP_TOO_LOW       = "FEE_CAP_TOO_LOW"
+	REASON_PAYOUT_UNAVAILABLE    = "PAYOUT_UNAVAILABLE"
+	REASON_MEMO_DISABLED         = "MEMO_PAYMENTS_DISABLED"
+	REASON_SPEED_UP_UNFUNDED     = "SPEED_UP_UNFUNDED"
+)
+
+// Error is a domain error. The API layer turns it into a gRPC status whose
+// code follows Kind and whose details carry Reason, Subject and Metadata.
+type Error struct {
+	Kind   string
+	Reason string
+	// Subject is the request field, resource or limit the error is about.
+	Subject  string
+	Message  string
+	Metadata map[string]string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func NewInvalidArgumentError(reason, field, message string) *Error {
+	return &Error{
+		Kind:    ERROR_KIND_INVALID_ARGUMENT,
+		Reason:  reason,
+		Subject: field,
+		Message: message,
+	}
+}
+
+func NewNotFoundError(reason, resourceType, resourceName string) *Error {
+	return &Error{
+		Kind:    ERROR_KIND_NOT_FOUND,
+		Reason:  reason,
+		Subject: resourceType,
+		Message: fmt.Sprintf("%s not found", resourceType),
+		Metadata: map[string]string{
+			"resource_type": resourceType,
+			"resource_name": resourceName,
+		},
+	}
+}
+
+func NewEntityNotFoundError(name string) *Error {
+	return NewNotFoundError(REASON_ENTITY_NOT_FOUND, "entity", name)
+}
+
+func NewFailedPreconditionError(reason, subject, message string) *Error {
+	return &Error{
+		Kind:    ERROR_KIND_FAILED_PRECONDITION,
+		Reason:  reason,
+		Subject: subject,
+		Message: message,
+	}
+}
+
+// NewDestinationNotAllowedError reports a withdrawal to an address missing
+// from the allowlist of the entity.
+func NewDestinationNotAllowedError(address string) *Error {
+	return &Error{
+		Kind:    ERROR_KIND_PERMISSION_DENIED,
+		Reason:  REASON_DESTINATION_DENIED,
+		Subject: "ReceiverWalletAddress",
+		Message: "destination is not on the allowlist",
+		Metadata: map[string]string{
+			"address": address,
+		},
+	}
+}
+
+// NewDestinationCoolingDownError reports a withdrawal to an allowed address
+// that can not be used before usableAt.
+func NewDestinationCoolingDownError(address string, usableAt int64) *Error {
+	return &Error{
+		Kind:    ERROR_KIND_FAILED_PRECONDITION,
+		Reason:  REASON_DESTINATION_COOLDOWN,
+		Subject: "ReceiverWalletAddress",
+		Message: "destination was added to the allowlist too recently",
+		Metadata: map[string]string{
+			"address":   address,
+			"usable_at": strconv.FormatInt(usableAt, 10),
+		},
+	}
+}
+
+// NewInsufficientBalanceError reports that a wallet holds less of asset than
+// an operation needs, amounts are in base units.
+func NewInsufficientBalanceError(asset string, required, available decimal.Decimal) *Error {
+	return &Error{
+		Kind:    ERROR_KIND_FAILED_PRECONDITION,
+		Reason:  REASON_INSUFFICIENT_BALANCE,
+		Subject: asset,
+		Message: "not enough balance",
+		Metadata: map[string]string{
+			"asset":     asset,
+			"required":  required.String(),
+			"available": available.String(),
+		},
+	}
+}
+
+// NewLimitExceededError reports that an operation would go over limit.
+func NewLimitExceededError(limit string, requested, max decimal.Decimal) *Error {
+	return &Error{
+		Kind:    ERROR_KIND_RESOURCE_EXHAUSTED,
+		Reason:  REASON_LIMIT_EXCEEDED,
+		Subject: limit,
+		Message: fmt.Sprintf("%s exceeded", limit),
+		Metadata: map[string]string{
+			"limit":     limit,
+			"requested": requested.String(),
+			"max":       max.String(),
+		},
+	}
+}
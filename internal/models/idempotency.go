@@ -0,0 +1,54 @@
+package models
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	IDEMPOTENCY_STATUS_PENDING   = "pending"
+	IDEMPOTENCY_STATUS_COMPLETED = "completed"
+	IDEMPOTENCY_STATUS_FAILED    = "failed"
+)
+
+type IdempotencyRepository interface {
+	// CreateIfNotExists stores record unless a record with the same scope and
+	// key already exists, it reports whether the record was created.
+	CreateIfNotExists(ctx context.Context, record *IdempotencyRecord) (bool, error)
+
+	GetIdempotencyRecord(ctx context.Context, scope, key string) (*IdempotencyRecord, error)
+
+	CompleteIdempotencyRecord(ctx context.Context, scope, key string, response []byte) error
+
+	// FailIdempotencyRecord marks the record failed with status, the encoded
+	// gRPC status the request ended with.
+	FailIdempotencyRecord(ctx context.Context, scope, key string, status []byte) error
+
+	DeleteIdempotencyRecord(ctx context.Context, scope, key string) error
+}
+
+// IdempotencyRecord remembers the outcome of a mutating request so that a
+// retry carrying the same idempotency key replays it instead of running twice.
+// Response holds the response of a completed request, or the gRPC status of a
+// failed one.
+type IdempotencyRecord struct {
+	Scope       string `json:"scope" gorm:"primaryKey;type:text"`
+	Key         string `json:"key" gorm:"primaryKey;type:text"`
+	Fingerprint string `json:"fingerprint" gorm:"type:text;not null"`
+	Status      string `json:"status" gorm:"type:text;not null"`
+	Response    []byte `json:"response"`
+	CreatedAt   int64  `json:"created_at" gorm:"not null"`
+	UpdatedAt   int64  `json:"updated_at" gorm:"not null"`
+}
+
+func NewIdempotencyRecord(scope, key, fingerprint string) *IdempotencyRecord {
+	now := time.Now().UTC().Unix()
+	return &IdempotencyRecord{
+		Scope:       scope,
+		Key:         key,
+		Fingerprint: fingerprint,
+		Status:      IDEMPOTENCY_STATUS_PENDING,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+}
@@ -0,0 +1,99 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	INVOICE_STATUS_OPEN           = "open"
+	INVOICE_STATUS_PARTIALLY_PAID = "partially_paid"
+	INVOICE_STATUS_PAID           = "paid"
+	INVOICE_STATUS_OVERPAID       = "overpaid"
+	INVOICE_STATUS_EXPIRED        = "expired"
+	INVOICE_STATUS_CANCELED       = "canceled"
+)
+
+type InvoiceRepository interface {
+	Create(ctx context.Context, invoice *Invoice) error
+	GetInvoiceById(ctx context.Context, id uuid.UUID) (*Invoice, error)
+	// FindOpenInvoice returns the oldest open or partially paid invoice paid
+	// to address in contractAddress without a memo.
+	FindOpenInvoice(ctx context.Context, address, contractAddress string) (*Invoice, error)
+	// ApplyInvoicePayment adds amount, paid at paidAt, to an invoice that is
+	// neither canceled nor expired at paidAt, moves it to the status its paid
+	// amount gives and reports whether it did.
+	ApplyInvoicePayment(ctx context.Context, id uuid.UUID, amount decimal.Decimal, paidAt int64) (bool, error)
+	// CancelInvoice cancels an invoice that is still open and reports
+	// whether it did.
+	CancelInvoice(ctx context.Context, id uuid.UUID) (bool, error)
+	// ExpireInvoices expires the open and partially paid invoices that
+	// expired at now and returns how many it expired.
+	ExpireInvoices(ctx context.Context, now int64) (int64, error)
+}
+
+// InvoiceParams describes an invoice as requested by an API client. Amount is
+// expressed in Denom, which is either an AIOZ denomination or the address of a
+// tracked token contract. With PayByMemo set the invoice is paid to the shared
+// business wallet with its memo, otherwise to the entity wallet.
+type InvoiceParams struct {
+	EntityName string
+	Amount     decimal.Decimal
+	Denom      string
+	ExpiresAt  int64
+	PayByMemo  bool
+}
+
+// Invoice requests Amount of ContractAddress, in base units, to be paid to
+// Address before ExpiresAt. Deposits found by the scanner are added to
+// PaidAmount. Invoices paid to the business wallet are matched by Memo, those
+// paid to an entity wallet by the Memo or, when the transfer carries none, in
+// the order they were made.
+type Invoice struct {
+	Id              uuid.UUID       `json:"id" gorm:"primaryKey;type:uuid"`
+	EntityId        uuid.UUID       `json:"entity_id" gorm:"type:uuid;not null;index"`
+	EntityName      string          `json:"entity_name" gorm:"type:text;not null"`
+	RequestedAmount decimal.Decimal `json:"requested_amount" gorm:"type:numeric"`
+	Denom           string          `json:"denom" gorm:"type:text;not null"`
+	ContractAddress string          `json:"contract_address" gorm:"type:text;not null"`
+	Amount          decimal.Decimal `json:"amount" gorm:"type:numeric"`
+	Address         string          `json:"address" gorm:"type:text;not null;index"`
+	Memo            string          `json:"memo" gorm:"type:text;not null"`
+	PayByMemo       bool            `json:"pay_by_memo"`
+	PaidAmount      decimal.Decimal `json:"paid_amount" gorm:"type:numeric;not null;default:0"`
+	Status          string          `json:"status" gorm:"type:text;not null;index"`
+	ExpiresAt       int64           `json:"expires_at" gorm:"not null"`
+	CreatedAt       int64           `json:"created_at" gorm:"not null"`
+	UpdatedAt       int64           `json:"updated_at" gorm:"not null"`
+}
+
+func NewInvoice(
+	entity *Entity,
+	params *InvoiceParams,
+	contractAddress string,
+	amount decimal.Decimal,
+	address string,
+) *Invoice {
+	now := time.Now().UTC().Unix()
+	id := uuid.New()
+	return &Invoice{
+		Id:              id,
+		EntityId:        entity.Id,
+		EntityName:      entity.Name,
+		RequestedAmount: params.Amount,
+		Denom:           params.Denom,
+		ContractAddress: contractAddress,
+		Amount:          amount,
+		Address:         address,
+		Memo:            id.String(),
+		PayByMemo:       params.PayByMemo,
+		PaidAmount:      decimal.Zero,
+		Status:          INVOICE_STATUS_OPEN,
+		ExpiresAt:       params.ExpiresAt,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+}
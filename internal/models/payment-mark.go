@@ -8,6 +8,7 @@ type PaymentMarkRepository interface {
 	GetPaymentMarkByChainId(ctx context.Context, chainId int64) (*PaymentMark, error)
 
 	UpdatePaymentMarkByChainId(ctx context.Context, chainId int64, blockNumber int64) error
+	UpdatePaymentMarkPausedByChainId(ctx context.Context, chainId int64, paused bool) error
 
 	DeletePaymentMarkByChainId(ctx context.Context, chainId int64) error
 }
@@ -15,4 +16,14 @@ type PaymentMarkRepository interface {
 type PaymentMark struct {
 	ChainId     int64 `json:"chain_id" gorm:"primaryKey,int,not null"`
 	BlockNumber int64 `json:"block_number" gorm:"int8,not null"`
+	Paused      bool  `json:"paused" gorm:"not null;default:false"`
+}
+
+// ScannerStatus reports how far the block scanner is behind the chain.
+type ScannerStatus struct {
+	ChainId           int64
+	BlockNumber       int64
+	LatestBlockNumber int64
+	Lag               int64
+	Paused            bool
 }
@@ -2,8 +2,10 @@ package models
 
 import (
 	"context"
+	"encoding/base64"
 	"fmt"
 	"regexp"
+	"strconv"
 
 	"github.com/google/uuid"
 	"github.com/shopspring/decimal"
@@ -12,21 +14,83 @@ import (
 const (
 	CONTRACT_IN_TYPE  = "in"
 	CONTRACT_OUT_TYPE = "out"
+	// A sweep moves deposits from an entity wallet to the business wallet.
+	CONTRACT_SWEEP_TYPE = "sweep"
+	// A gas top-up sends an entity wallet the AIOZ it needs to pay for a
+	// token transfer, from the hot wallet.
+	CONTRACT_GAS_TOPUP_TYPE = "gas_topup"
+	// A rebalance moves the excess of the hot wallet to the cold wallet, it
+	// belongs to no entity.
+	CONTRACT_REBALANCE_TYPE = "rebalance"
 
 	AIOZ_CONTRACT_ADDRESS = "aioz"
 
 	TX_STATUS_NEW     = "new"
 	TX_STATUS_HANDLED = "handled"
+	TX_STATUS_PENDING = "pending"
+	TX_STATUS_FAILED  = "failed"
+
+	// Outbound transactions leave pending once mined, either confirmed or
+	// failed when reverted, or replaced when another transaction with the
+	// same nonce was mined instead.
+	TX_STATUS_CONFIRMED = "confirmed"
+	TX_STATUS_REPLACED  = "replaced"
 )
 
 type TransactionRepository interface {
 	Create(ctx context.Context, transaction *Transaction) error
 
+	GetTransactionById(ctx context.Context, id uuid.UUID) (*Transaction, error)
 	GetTransactionByHashIndexAndReceiverAddr(ctx context.Context, hash string, index int, recvAddr string) (*Transaction, error)
+	GetTransactionByEvmHashAndType(ctx context.Context, hash string, txType string) (*Transaction, error)
+
+	ListTransactions(ctx context.Context, filter TransactionFilter, cursor int64, limit int) ([]*Transaction, error)
+	ListTransactionsAfter(ctx context.Context, filter TransactionFilter, cursor int64, limit int) ([]*Transaction, error)
+	GetLatestTransactionSeq(ctx context.Context) (int64, error)
+	// LockDepositSequence serializes the writers of deposits until the end of
+	// the database transaction it is called in, so that deposits are
+	// committed in the order of their Seq.
+	LockDepositSequence(ctx context.Context) error
+	// ListUnsweptDeposits returns the deposits to entity wallets that no
+	// pending or confirmed sweep consolidated, oldest first.
+	ListUnsweptDeposits(ctx context.Context, cursor int64, limit int) ([]*Transaction, error)
+	// GetWithdrawalTotals sums the withdrawals of contractAddress made by
+	// entityId since the given time that were not dropped, and counts them.
+	GetWithdrawalTotals(ctx context.Context, entityId uuid.UUID, contractAddress string, since int64) (decimal.Decimal, int64, error)
+	// GetLedgerBalance returns what entityId holds of contractAddress: its
+	// deposits less its withdrawals that were not dropped, and less their
+	// fees for AIOZ. AIOZ withdrawals that failed once mined are charged
+	// their fee.
+	GetLedgerBalance(ctx context.Context, entityId uuid.UUID, contractAddress string) (decimal.Decimal, error)
+
+	UpdateTransactionStatus(ctx context.Context, id uuid.UUID, status string) error
+	UpdateTransactionReceipt(ctx context.Context, id uuid.UUID, status string, blockNumber uint64, fee decimal.Decimal) error
+	SetTransactionsSweepId(ctx context.Context, ids []uuid.UUID, sweepId uuid.UUID) error
+	// AssignTransaction attributes an unattributed deposit to entityId and
+	// reports whether it did. The deposit is given a new Seq, so that it is
+	// delivered again to the subscribers of deposits.
+	AssignTransaction(ctx context.Context, id uuid.UUID, entityId uuid.UUID) (bool, error)
+}
+
+// TransactionFilter narrows ListTransactions, zero values are ignored.
+type TransactionFilter struct {
+	EntityId        uuid.UUID
+	Type            string
+	Status          string
+	Denom           string
+	ContractAddress string
+	FromBlock       uint64
+	ToBlock         uint64
+	FromTime        int64
+	ToTime          int64
+	// Unattributed keeps the transactions that belong to no entity, such as
+	// the deposits to the business wallet waiting in the suspense queue.
+	Unattributed bool
 }
 
 type Transaction struct {
 	Id              uuid.UUID       `json:"id" gorm:"primary_key,type:uuid"`
+	Seq             int64           `json:"seq" gorm:"autoIncrement;uniqueIndex"`
 	EntityId        uuid.UUID       `json:"entity_id" gorm:"type:uuid"`
 	CosmosHash      string          `json:"cosmos_hash" gorm:"text"`
 	EvmHash         string          `json:"evm_hash" gorm:"text"`
@@ -39,9 +103,27 @@ type Transaction struct {
 	Denom           string          `json:"denom" gorm:"varchar(255)"`
 	Amount          decimal.Decimal `json:"amount" gorm:"type:numeric"`
 	Credit          decimal.Decimal `json:"credit" gorm:"type:numeric"`
+	Fee             decimal.Decimal `json:"fee" gorm:"type:numeric"`
 	Status          string          `json:"status" gorm:"text"`
-	CreatedAt       int64           `json:"created_at" gorm:"int8,not null"`
-	UpdatedAt       int64           `json:"updated_at" gorm:"int8,not null"`
+	// Nonce and gas settings of outbound EVM transactions, kept to track and
+	// replace them. GasTipCap equals GasFeeCap for legacy transactions.
+	// Cosmos transfers only set Nonce, their account sequence, and GasLimit.
+	Nonce     uint64          `json:"nonce" gorm:"int8"`
+	GasLimit  uint64          `json:"gas_limit" gorm:"int8"`
+	GasFeeCap decimal.Decimal `json:"gas_fee_cap" gorm:"type:numeric"`
+	GasTipCap decimal.Decimal `json:"gas_tip_cap" gorm:"type:numeric"`
+	// ReplacesId links a speed-up or cancel transaction to the one it replaces.
+	ReplacesId uuid.UUID `json:"replaces_id" gorm:"type:uuid"`
+	// SweepId links a deposit to the sweep that moved it to the business
+	// wallet.
+	SweepId uuid.UUID `json:"sweep_id" gorm:"type:uuid"`
+	// Memo of the Cosmos transaction of a deposit, it names the entity a
+	// deposit to the business wallet is for.
+	Memo string `json:"memo" gorm:"text"`
+	// InvoiceId links a deposit to the invoice it paid.
+	InvoiceId uuid.UUID `json:"invoice_id" gorm:"type:uuid"`
+	CreatedAt int64     `json:"created_at" gorm:"int8,not null"`
+	UpdatedAt int64     `json:"updated_at" gorm:"int8,not null"`
 }
 
 func ParseCoinAmount(amountValue string) (decimal.Decimal, string, error) {
@@ -56,3 +138,27 @@ func ParseCoinAmount(amountValue string) (decimal.Decimal, string, error) {
 	}
 	return amount, match[2], nil
 }
+
+// EncodeTransactionCursor turns a transaction sequence number into the opaque
+// cursor handed out to API clients.
+func EncodeTransactionCursor(seq int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(seq, 10)))
+}
+
+func DecodeTransactionCursor(cursor string) (int64, error) {
+	if cursor == "" {
+		return 0, nil
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, fmt.Errorf("invalid cursor")
+	}
+
+	seq, err := strconv.ParseInt(string(raw), 10, 64)
+	if err != nil || seq < 0 {
+		return 0, fmt.Errorf("invalid cursor")
+	}
+
+	return seq, nil
+}
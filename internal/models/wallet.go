@@ -7,6 +7,7 @@ import (
 	"crypto/ecdsa"
 	"crypto/rand"
 	"crypto/sha256"
+	"errors"
 	"io"
 	"time"
 
@@ -33,6 +34,26 @@ type Wallet struct {
 	UpdatedAt   time.Time       `gorm:"not null" json:"updated_at,omitempty"`
 }
 
+// WalletBalance combines the on-chain holdings of an entity wallet with the
+// ledger values kept in the wallet row. AvailableNative and AvailableTokens
+// are what the entity can withdraw, its deposits less its withdrawals, swept
+// deposits included.
+type WalletBalance struct {
+	Address         string
+	Native          decimal.Decimal
+	Tokens          []*TokenBalance
+	Balance         decimal.Decimal
+	Debt            decimal.Decimal
+	FreeBalance     decimal.Decimal
+	AvailableNative decimal.Decimal
+	AvailableTokens []*TokenBalance
+}
+
+type TokenBalance struct {
+	ContractAddress string
+	Amount          decimal.Decimal
+}
+
 func NewWallet(passphrase string) (*Wallet, error) {
 	privateKey, err := crypto.GenerateKey()
 	if err != nil {
@@ -99,7 +120,7 @@ func Decrypt(data []byte, passphrase string) ([]byte, error) {
 
 	nonceSize := gcm.NonceSize()
 	if len(data) < nonceSize {
-		return nil, err
+		return nil, errors.New("ciphertext too short")
 	}
 
 	nonce, ciphertext := data[:nonceSize], data[nonceSize:]
@@ -0,0 +1,25 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// WithdrawParams describes a withdrawal as requested by an API client. Amount
+// is expressed in Denom, which is either an AIOZ denomination or the address of
+// a tracked token contract. With Max set, Amount is ignored and the whole
+// balance is sent. RequestedBy names the caller, it can not approve the
+// withdrawal itself, and a withdrawal without it can not be approved at all.
+// A non-nil QuoteId executes a quote, the transfer is then taken from the
+// quote and the other fields are left empty. A positive BaseAmount is the
+// exact amount to send in base units, it overrides Amount and Max.
+type WithdrawParams struct {
+	EntityName      string
+	Amount          decimal.Decimal
+	Denom           string
+	ReceiverAddress string
+	Max             bool
+	RequestedBy     string
+	QuoteId         uuid.UUID
+	BaseAmount      decimal.Decimal
+}
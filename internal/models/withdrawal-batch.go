@@ -0,0 +1,100 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	BATCH_STATUS_PROCESSING = "processing"
+	BATCH_STATUS_COMPLETED  = "completed"
+
+	BATCH_ITEM_STATUS_QUEUED = "queued"
+	// An item is marked sending right before its withdrawal is made. Finding
+	// one sending later means processing was interrupted and it is unknown
+	// whether the withdrawal went out, so it is failed instead of retried.
+	BATCH_ITEM_STATUS_SENDING   = "sending"
+	BATCH_ITEM_STATUS_SUBMITTED = "submitted"
+	BATCH_ITEM_STATUS_FAILED    = "failed"
+)
+
+// BatchWithdrawParams describes a batch payout as requested by an API client.
+type BatchWithdrawParams struct {
+	EntityName  string
+	RequestedBy string
+	Items       []*BatchWithdrawItem
+}
+
+type BatchWithdrawItem struct {
+	ReceiverAddress string
+	Amount          decimal.Decimal
+	Denom           string
+}
+
+// WithdrawalBatch is a list of withdrawals of one entity, made one after the
+// other in the background. Every item becomes a withdrawal request that
+// follows the approval rules and limits of a single withdrawal.
+type WithdrawalBatch struct {
+	Id          uuid.UUID              `json:"id" gorm:"primaryKey;type:uuid"`
+	EntityId    uuid.UUID              `json:"entity_id" gorm:"type:uuid;not null;index"`
+	EntityName  string                 `json:"entity_name" gorm:"type:text;not null"`
+	RequestedBy string                 `json:"requested_by" gorm:"type:text"`
+	Status      string                 `json:"status" gorm:"type:text;not null;index"`
+	CreatedAt   int64                  `json:"created_at" gorm:"not null"`
+	UpdatedAt   int64                  `json:"updated_at" gorm:"not null"`
+	Items       []*WithdrawalBatchItem `json:"items" gorm:"foreignKey:BatchId"`
+}
+
+type WithdrawalBatchItem struct {
+	Id                  uuid.UUID          `json:"id" gorm:"primaryKey;type:uuid"`
+	BatchId             uuid.UUID          `json:"batch_id" gorm:"type:uuid;not null;index"`
+	Position            int                `json:"position" gorm:"not null"`
+	ReceiverAddress     string             `json:"receiver_address" gorm:"type:text;not null"`
+	Amount              decimal.Decimal    `json:"amount" gorm:"type:numeric"`
+	Denom               string             `json:"denom" gorm:"type:text;not null"`
+	Status              string             `json:"status" gorm:"type:text;not null"`
+	Error               string             `json:"error" gorm:"type:text"`
+	WithdrawalRequestId *uuid.UUID         `json:"withdrawal_request_id" gorm:"type:uuid"`
+	UpdatedAt           int64              `json:"updated_at" gorm:"not null"`
+	WithdrawalRequest   *WithdrawalRequest `json:"withdrawal_request" gorm:"foreignKey:WithdrawalRequestId"`
+}
+
+func NewWithdrawalBatch(entity *Entity, params *BatchWithdrawParams) *WithdrawalBatch {
+	batch := &WithdrawalBatch{
+		Id:          uuid.New(),
+		EntityId:    entity.Id,
+		EntityName:  entity.Name,
+		RequestedBy: params.RequestedBy,
+		Status:      BATCH_STATUS_PROCESSING,
+		CreatedAt:   time.Now().UTC().Unix(),
+		UpdatedAt:   time.Now().UTC().Unix(),
+	}
+
+	for i, item := range params.Items {
+		batch.Items = append(batch.Items, &WithdrawalBatchItem{
+			Id:              uuid.New(),
+			BatchId:         batch.Id,
+			Position:        i,
+			ReceiverAddress: item.ReceiverAddress,
+			Amount:          item.Amount,
+			Denom:           item.Denom,
+			Status:          BATCH_ITEM_STATUS_QUEUED,
+			UpdatedAt:       time.Now().UTC().Unix(),
+		})
+	}
+
+	return batch
+}
+
+// WithdrawParams returns the parameters of the withdrawal made for item.
+func (b *WithdrawalBatch) WithdrawParams(item *WithdrawalBatchItem) *WithdrawParams {
+	return &WithdrawParams{
+		EntityName:      b.EntityName,
+		Amount:          item.Amount,
+		Denom:           item.Denom,
+		ReceiverAddress: item.ReceiverAddress,
+		RequestedBy:     b.RequestedBy,
+	}
+}
@@ -0,0 +1,79 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// WithdrawalPolicy holds the rules for withdrawing one asset, identified by
+// its contract address. A policy with a nil EntityId applies to every entity
+// without a policy of its own.
+type WithdrawalPolicy struct {
+	Id              uuid.UUID `json:"id" gorm:"primaryKey;type:uuid"`
+	EntityId        uuid.UUID `json:"entity_id" gorm:"type:uuid;not null;uniqueIndex:idx_withdrawal_policy"`
+	ContractAddress string    `json:"contract_address" gorm:"type:text;not null;uniqueIndex:idx_withdrawal_policy"`
+	// Withdrawals of more than ApprovalThreshold, in base units, need
+	// RequiredApprovals distinct approvers.
+	ApprovalThreshold decimal.Decimal `json:"approval_threshold" gorm:"type:numeric"`
+	RequiredApprovals int             `json:"required_approvals"`
+	// Limits in base units, zero means unlimited. Caps and counts cover
+	// the withdrawals of the last 24 hours and 7 days.
+	MaxPerTx      decimal.Decimal `json:"max_per_tx" gorm:"type:numeric"`
+	DailyCap      decimal.Decimal `json:"daily_cap" gorm:"type:numeric"`
+	WeeklyCap     decimal.Decimal `json:"weekly_cap" gorm:"type:numeric"`
+	MaxDailyCount int             `json:"max_daily_count"`
+	// With the allowlist enabled, withdrawals only go to the allowed
+	// destinations of the entity, once they were added AllowlistCooldown
+	// seconds ago.
+	AllowlistEnabled  bool  `json:"allowlist_enabled"`
+	AllowlistCooldown int64 `json:"allowlist_cooldown"`
+	CreatedAt         int64 `json:"created_at" gorm:"not null"`
+	UpdatedAt         int64 `json:"updated_at" gorm:"not null"`
+}
+
+// WithdrawalPolicyParams sets a policy as requested by an API client. Amounts
+// are expressed in Denom.
+type WithdrawalPolicyParams struct {
+	EntityName        string
+	Denom             string
+	ApprovalThreshold decimal.Decimal
+	RequiredApprovals int
+	MaxPerTx          decimal.Decimal
+	DailyCap          decimal.Decimal
+	WeeklyCap         decimal.Decimal
+	MaxDailyCount     int
+	AllowlistEnabled  bool
+	AllowlistCooldown int64
+}
+
+// AllowedDestination is an address an entity may withdraw to while its
+// allowlist is enabled.
+type AllowedDestination struct {
+	Id        uuid.UUID `json:"id" gorm:"primaryKey;type:uuid"`
+	EntityId  uuid.UUID `json:"entity_id" gorm:"type:uuid;not null;uniqueIndex:idx_allowed_destination"`
+	Address   string    `json:"address" gorm:"type:text;not null;uniqueIndex:idx_allowed_destination"`
+	Label     string    `json:"label" gorm:"type:text"`
+	CreatedAt int64     `json:"created_at" gorm:"not null"`
+}
+
+func NewWithdrawalPolicy(entityId uuid.UUID, contractAddress string) *WithdrawalPolicy {
+	return &WithdrawalPolicy{
+		Id:              uuid.New(),
+		EntityId:        entityId,
+		ContractAddress: contractAddress,
+		CreatedAt:       time.Now().UTC().Unix(),
+		UpdatedAt:       time.Now().UTC().Unix(),
+	}
+}
+
+func NewAllowedDestination(entityId uuid.UUID, address, label string) *AllowedDestination {
+	return &AllowedDestination{
+		Id:        uuid.New(),
+		EntityId:  entityId,
+		Address:   address,
+		Label:     label,
+		CreatedAt: time.Now().UTC().Unix(),
+	}
+}
@@ -0,0 +1,81 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// WithdrawalQuoteTTL is how long a quote can be executed after it was made.
+const WithdrawalQuoteTTL = 2 * time.Minute
+
+// WithdrawalQuote is a withdrawal that passed every check of Withdraw without
+// being sent. Executing it replays the quoted parameters with the gas price
+// held to MaxFeePerGas, a quote can be executed once.
+type WithdrawalQuote struct {
+	Id              uuid.UUID       `json:"id" gorm:"primaryKey;type:uuid"`
+	EntityId        uuid.UUID       `json:"entity_id" gorm:"type:uuid;not null;index"`
+	EntityName      string          `json:"entity_name" gorm:"type:text;not null"`
+	ReceiverAddress string          `json:"receiver_address" gorm:"type:text;not null"`
+	RequestedAmount decimal.Decimal `json:"requested_amount" gorm:"type:numeric"`
+	Denom           string          `json:"denom" gorm:"type:text;not null"`
+	Max             bool            `json:"max"`
+	ContractAddress string          `json:"contract_address" gorm:"type:text;not null"`
+	// Amount is the net amount sent in base units of ContractAddress, Fee is
+	// the most the transfer can be charged in attoaioz.
+	Amount            decimal.Decimal `json:"amount" gorm:"type:numeric"`
+	Fee               decimal.Decimal `json:"fee" gorm:"type:numeric"`
+	GasLimit          uint64          `json:"gas_limit"`
+	MaxFeePerGas      decimal.Decimal `json:"max_fee_per_gas" gorm:"type:numeric"`
+	RequiredApprovals int             `json:"required_approvals"`
+	ExpiresAt         int64           `json:"expires_at" gorm:"not null"`
+	// UsedAt is zero until the quote is executed.
+	UsedAt    int64 `json:"used_at" gorm:"not null;default:0"`
+	CreatedAt int64 `json:"created_at" gorm:"not null"`
+}
+
+func NewWithdrawalQuote(
+	entity *Entity,
+	params *WithdrawParams,
+	contractAddress string,
+	amount decimal.Decimal,
+	fee decimal.Decimal,
+	gasLimit uint64,
+	requiredApprovals int,
+) *WithdrawalQuote {
+	now := time.Now().UTC()
+	return &WithdrawalQuote{
+		Id:                uuid.New(),
+		EntityId:          entity.Id,
+		EntityName:        entity.Name,
+		ReceiverAddress:   params.ReceiverAddress,
+		RequestedAmount:   params.Amount,
+		Denom:             params.Denom,
+		Max:               params.Max,
+		ContractAddress:   contractAddress,
+		Amount:            amount,
+		Fee:               fee,
+		GasLimit:          gasLimit,
+		MaxFeePerGas:      fee.Div(decimal.NewFromInt(int64(gasLimit))).Floor(),
+		RequiredApprovals: requiredApprovals,
+		ExpiresAt:         now.Add(WithdrawalQuoteTTL).Unix(),
+		CreatedAt:         now.Unix(),
+	}
+}
+
+// Params rebuilds the parameters the quote was made with, on behalf of
+// requestedBy. The quoted Amount is sent as is, a Max quote is not resized to
+// the balance at execution time.
+func (q *WithdrawalQuote) Params(requestedBy string) *WithdrawParams {
+	return &WithdrawParams{
+		EntityName:      q.EntityName,
+		Amount:          q.RequestedAmount,
+		Denom:           q.Denom,
+		ReceiverAddress: q.ReceiverAddress,
+		Max:             q.Max,
+		RequestedBy:     requestedBy,
+		QuoteId:         q.Id,
+		BaseAmount:      q.Amount,
+	}
+}
@@ -0,0 +1,165 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	WITHDRAWAL_STATUS_REQUESTED = "requested"
+	WITHDRAWAL_STATUS_APPROVED  = "approved"
+	WITHDRAWAL_STATUS_EXECUTED  = "executed"
+	WITHDRAWAL_STATUS_REJECTED  = "rejected"
+	// An approved request whose transfer could not be sent. It is not
+	// retried, the entity has to request the withdrawal again.
+	WITHDRAWAL_STATUS_FAILED = "failed"
+
+	WITHDRAWAL_DECISION_APPROVED = "approved"
+	WITHDRAWAL_DECISION_REJECTED = "rejected"
+)
+
+type WithdrawalRepository interface {
+	CreateWithdrawalRequest(ctx context.Context, request *WithdrawalRequest) error
+	GetWithdrawalRequestById(ctx context.Context, id uuid.UUID) (*WithdrawalRequest, error)
+	ListWithdrawalRequests(ctx context.Context, status string, limit int) ([]*WithdrawalRequest, error)
+	// UpdateWithdrawalRequestStatus only moves a request that is still in
+	// fromStatus and reports whether it did.
+	UpdateWithdrawalRequestStatus(ctx context.Context, id uuid.UUID, fromStatus, toStatus string) (bool, error)
+	CompleteWithdrawalRequest(ctx context.Context, id uuid.UUID, transactionId *uuid.UUID, status, failureReason string) error
+	// GetPendingWithdrawalTotals sums the amounts of the requests that are
+	// not executed yet by contract address.
+	GetPendingWithdrawalTotals(ctx context.Context) (map[string]decimal.Decimal, error)
+
+	// CreateWithdrawalApproval reports false when the approver already
+	// decided on the request.
+	CreateWithdrawalApproval(ctx context.Context, approval *WithdrawalApproval) (bool, error)
+
+	GetWithdrawalPolicy(ctx context.Context, entityId uuid.UUID, contractAddress string) (*WithdrawalPolicy, error)
+	UpsertWithdrawalPolicy(ctx context.Context, policy *WithdrawalPolicy) error
+
+	// CreateAllowedDestination reports false when the address is already
+	// allowed for the entity.
+	CreateAllowedDestination(ctx context.Context, destination *AllowedDestination) (bool, error)
+	GetAllowedDestination(ctx context.Context, entityId uuid.UUID, address string) (*AllowedDestination, error)
+	ListAllowedDestinations(ctx context.Context, entityId uuid.UUID) ([]*AllowedDestination, error)
+	DeleteAllowedDestination(ctx context.Context, entityId uuid.UUID, address string) error
+
+	CreateWithdrawalQuote(ctx context.Context, quote *WithdrawalQuote) error
+	GetWithdrawalQuoteById(ctx context.Context, id uuid.UUID) (*WithdrawalQuote, error)
+	// UseWithdrawalQuote marks a quote that is neither used nor expired at
+	// now as used, and reports whether it did.
+	UseWithdrawalQuote(ctx context.Context, id uuid.UUID, now int64) (bool, error)
+
+	CreateWithdrawalBatch(ctx context.Context, batch *WithdrawalBatch) error
+	GetWithdrawalBatchById(ctx context.Context, id uuid.UUID) (*WithdrawalBatch, error)
+	ListWithdrawalBatches(ctx context.Context, status string, limit int) ([]*WithdrawalBatch, error)
+	UpdateWithdrawalBatchStatus(ctx context.Context, id uuid.UUID, status string) error
+	UpdateWithdrawalBatchItem(ctx context.Context, item *WithdrawalBatchItem) error
+	// ClaimWithdrawalBatchItem only moves an item that is still queued to
+	// sending and reports whether it did.
+	ClaimWithdrawalBatchItem(ctx context.Context, item *WithdrawalBatchItem) (bool, error)
+}
+
+// WithdrawalRequest is a withdrawal waiting for, or done with, its approvals.
+// The parameters are kept as requested so the transfer is priced when it is
+// executed, Amount is the base amount at request time.
+type WithdrawalRequest struct {
+	Id                uuid.UUID             `json:"id" gorm:"primaryKey;type:uuid"`
+	EntityId          uuid.UUID             `json:"entity_id" gorm:"type:uuid;not null;index"`
+	EntityName        string                `json:"entity_name" gorm:"type:text;not null"`
+	ReceiverAddress   string                `json:"receiver_address" gorm:"type:text;not null"`
+	RequestedAmount   decimal.Decimal       `json:"requested_amount" gorm:"type:numeric"`
+	Denom             string                `json:"denom" gorm:"type:text;not null"`
+	Max               bool                  `json:"max"`
+	ContractAddress   string                `json:"contract_address" gorm:"type:text;not null"`
+	Amount            decimal.Decimal       `json:"amount" gorm:"type:numeric"`
+	RequiredApprovals int                   `json:"required_approvals"`
+	RequestedBy       string                `json:"requested_by" gorm:"type:text"`
+	Status            string                `json:"status" gorm:"type:text;not null;index"`
+	FailureReason     string                `json:"failure_reason" gorm:"type:text"`
+	TransactionId     *uuid.UUID            `json:"transaction_id" gorm:"type:uuid"`
+	CreatedAt         int64                 `json:"created_at" gorm:"not null"`
+	UpdatedAt         int64                 `json:"updated_at" gorm:"not null"`
+	Approvals         []*WithdrawalApproval `json:"approvals" gorm:"foreignKey:RequestId"`
+	Transaction       *Transaction          `json:"transaction" gorm:"foreignKey:TransactionId"`
+}
+
+// WithdrawalApproval is the decision of one approver on a request.
+type WithdrawalApproval struct {
+	Id        uuid.UUID `json:"id" gorm:"primaryKey;type:uuid"`
+	RequestId uuid.UUID `json:"request_id" gorm:"type:uuid;not null;uniqueIndex:idx_withdrawal_approver"`
+	Approver  string    `json:"approver" gorm:"type:text;not null;uniqueIndex:idx_withdrawal_approver"`
+	Decision  string    `json:"decision" gorm:"type:text;not null"`
+	Reason    string    `json:"reason" gorm:"type:text"`
+	CreatedAt int64     `json:"created_at" gorm:"not null"`
+}
+
+func NewWithdrawalRequest(
+	entity *Entity,
+	params *WithdrawParams,
+	contractAddress string,
+	amount decimal.Decimal,
+	requiredApprovals int,
+) *WithdrawalRequest {
+	status := WITHDRAWAL_STATUS_APPROVED
+	if requiredApprovals > 0 {
+		status = WITHDRAWAL_STATUS_REQUESTED
+	}
+
+	return &WithdrawalRequest{
+		Id:                uuid.New(),
+		EntityId:          entity.Id,
+		EntityName:        entity.Name,
+		ReceiverAddress:   params.ReceiverAddress,
+		RequestedAmount:   params.Amount,
+		Denom:             params.Denom,
+		Max:               params.Max,
+		ContractAddress:   contractAddress,
+		Amount:            amount,
+		RequiredApprovals: requiredApprovals,
+		RequestedBy:       params.RequestedBy,
+		Status:            status,
+		CreatedAt:         time.Now().UTC().Unix(),
+		UpdatedAt:         time.Now().UTC().Unix(),
+	}
+}
+
+func NewWithdrawalApproval(requestId uuid.UUID, approver, decision, reason string) *WithdrawalApproval {
+	return &WithdrawalApproval{
+		Id:        uuid.New(),
+		RequestId: requestId,
+		Approver:  approver,
+		Decision:  decision,
+		Reason:    reason,
+		CreatedAt: time.Now().UTC().Unix(),
+	}
+}
+
+// Params rebuilds the parameters the request was made with. The amount is
+// the one that was approved, a Max request does not grow with later deposits.
+func (r *WithdrawalRequest) Params() *WithdrawParams {
+	return &WithdrawParams{
+		EntityName:      r.EntityName,
+		Amount:          r.RequestedAmount,
+		Denom:           r.Denom,
+		ReceiverAddress: r.ReceiverAddress,
+		Max:             r.Max,
+		RequestedBy:     r.RequestedBy,
+		BaseAmount:      r.Amount,
+	}
+}
+
+// ApprovalCount returns the number of approvers who approved the request.
+func (r *WithdrawalRequest) ApprovalCount() int {
+	count := 0
+	for _, approval := range r.Approvals {
+		if approval.Decision == WITHDRAWAL_DECISION_APPROVED {
+			count++
+		}
+	}
+
+	return count
+}
@@ -0,0 +1,9 @@
+package payment_host
+
+import _ "embed"
+
+// OpenAPI is the OpenAPI (Swagger 2.0) document of the REST gateway, generated
+// from payment.proto by `make proto`.
+//
+//go:embed payment.swagger.json
+var OpenAPI []byte
@@ -19,8 +19,23 @@ import (
 const _ = grpc.SupportPackageIsVersion7
 
 const (
-	PaymentHostService_Register_FullMethodName = "/PaymentHostService/Register"
-	PaymentHostService_Withdraw_FullMethodName = "/PaymentHostService/Withdraw"
+	PaymentHostService_Register_FullMethodName                 = "/PaymentHostService/Register"
+	PaymentHostService_CreateApiKey_FullMethodName             = "/PaymentHostService/CreateApiKey"
+	PaymentHostService_RevokeApiKey_FullMethodName             = "/PaymentHostService/RevokeApiKey"
+	PaymentHostService_Withdraw_FullMethodName                 = "/PaymentHostService/Withdraw"
+	PaymentHostService_QuoteWithdraw_FullMethodName            = "/PaymentHostService/QuoteWithdraw"
+	PaymentHostService_GetWithdrawalRequest_FullMethodName     = "/PaymentHostService/GetWithdrawalRequest"
+	PaymentHostService_BatchWithdraw_FullMethodName            = "/PaymentHostService/BatchWithdraw"
+	PaymentHostService_GetWithdrawalBatch_FullMethodName       = "/PaymentHostService/GetWithdrawalBatch"
+	PaymentHostService_AddAllowedDestination_FullMethodName    = "/PaymentHostService/AddAllowedDestination"
+	PaymentHostService_RemoveAllowedDestination_FullMethodName = "/PaymentHostService/RemoveAllowedDestination"
+	PaymentHostService_ListAllowedDestinations_FullMethodName  = "/PaymentHostService/ListAllowedDestinations"
+	PaymentHostService_CreateInvoice_FullMethodName            = "/PaymentHostService/CreateInvoice"
+	PaymentHostService_GetInvoice_FullMethodName               = "/PaymentHostService/GetInvoice"
+	PaymentHostService_CancelInvoice_FullMethodName            = "/PaymentHostService/CancelInvoice"
+	PaymentHostService_GetBalance_FullMethodName               = "/PaymentHostService/GetBalance"
+	PaymentHostService_ListTransactions_FullMethodName         = "/PaymentHostService/ListTransactions"
+	PaymentHostService_SubscribeDeposits_FullMethodName        = "/PaymentHostService/SubscribeDeposits"
 )
 
 // PaymentHostServiceClient is the client API for PaymentHostService service.
@@ -28,7 +43,22 @@ const (
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type PaymentHostServiceClient interface {
 	Register(ctx context.Context, in *RegisterRequest, opts ...grpc.CallOption) (*RegisterResponse, error)
+	CreateApiKey(ctx context.Context, in *CreateApiKeyRequest, opts ...grpc.CallOption) (*CreateApiKeyResponse, error)
+	RevokeApiKey(ctx context.Context, in *RevokeApiKeyRequest, opts ...grpc.CallOption) (*RevokeApiKeyResponse, error)
 	Withdraw(ctx context.Context, in *WithdrawRequest, opts ...grpc.CallOption) (*WithdrawResponse, error)
+	QuoteWithdraw(ctx context.Context, in *QuoteWithdrawRequest, opts ...grpc.CallOption) (*WithdrawalQuote, error)
+	GetWithdrawalRequest(ctx context.Context, in *GetWithdrawalRequestRequest, opts ...grpc.CallOption) (*WithdrawalRequest, error)
+	BatchWithdraw(ctx context.Context, in *BatchWithdrawRequest, opts ...grpc.CallOption) (*WithdrawalBatch, error)
+	GetWithdrawalBatch(ctx context.Context, in *GetWithdrawalBatchRequest, opts ...grpc.CallOption) (*WithdrawalBatch, error)
+	AddAllowedDestination(ctx context.Context, in *AddAllowedDestinationRequest, opts ...grpc.CallOption) (*AllowedDestination, error)
+	RemoveAllowedDestination(ctx context.Context, in *RemoveAllowedDestinationRequest, opts ...grpc.CallOption) (*RemoveAllowedDestinationResponse, error)
+	ListAllowedDestinations(ctx context.Context, in *ListAllowedDestinationsRequest, opts ...grpc.CallOption) (*ListAllowedDestinationsResponse, error)
+	CreateInvoice(ctx context.Context, in *CreateInvoiceRequest, opts ...grpc.CallOption) (*Invoice, error)
+	GetInvoice(ctx context.Context, in *GetInvoiceRequest, opts ...grpc.CallOption) (*Invoice, error)
+	CancelInvoice(ctx context.Context, in *CancelInvoiceRequest, opts ...grpc.CallOption) (*Invoice, error)
+	GetBalance(ctx context.Context, in *GetBalanceRequest, opts ...grpc.CallOption) (*GetBalanceResponse, error)
+	ListTransactions(ctx context.Context, in *ListTransactionsRequest, opts ...grpc.CallOption) (*ListTransactionsResponse, error)
+	SubscribeDeposits(ctx context.Context, in *SubscribeDepositsRequest, opts ...grpc.CallOption) (PaymentHostService_SubscribeDepositsClient, error)
 }
 
 type paymentHostServiceClient struct {
@@ -48,6 +78,24 @@ func (c *paymentHostServiceClient) Register(ctx context.Context, in *RegisterReq
 	return out, nil
 }
 
+func (c *paymentHostServiceClient) CreateApiKey(ctx context.Context, in *CreateApiKeyRequest, opts ...grpc.CallOption) (*CreateApiKeyResponse, error) {
+	out := new(CreateApiKeyResponse)
+	err := c.cc.Invoke(ctx, PaymentHostService_CreateApiKey_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *paymentHostServiceClient) RevokeApiKey(ctx context.Context, in *RevokeApiKeyRequest, opts ...grpc.CallOption) (*RevokeApiKeyResponse, error) {
+	out := new(RevokeApiKeyResponse)
+	err := c.cc.Invoke(ctx, PaymentHostService_RevokeApiKey_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *paymentHostServiceClient) Withdraw(ctx context.Context, in *WithdrawRequest, opts ...grpc.CallOption) (*WithdrawResponse, error) {
 	out := new(WithdrawResponse)
 	err := c.cc.Invoke(ctx, PaymentHostService_Withdraw_FullMethodName, in, out, opts...)
@@ -57,12 +105,167 @@ func (c *paymentHostServiceClient) Withdraw(ctx context.Context, in *WithdrawReq
 	return out, nil
 }
 
+func (c *paymentHostServiceClient) QuoteWithdraw(ctx context.Context, in *QuoteWithdrawRequest, opts ...grpc.CallOption) (*WithdrawalQuote, error) {
+	out := new(WithdrawalQuote)
+	err := c.cc.Invoke(ctx, PaymentHostService_QuoteWithdraw_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *paymentHostServiceClient) GetWithdrawalRequest(ctx context.Context, in *GetWithdrawalRequestRequest, opts ...grpc.CallOption) (*WithdrawalRequest, error) {
+	out := new(WithdrawalRequest)
+	err := c.cc.Invoke(ctx, PaymentHostService_GetWithdrawalRequest_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *paymentHostServiceClient) BatchWithdraw(ctx context.Context, in *BatchWithdrawRequest, opts ...grpc.CallOption) (*WithdrawalBatch, error) {
+	out := new(WithdrawalBatch)
+	err := c.cc.Invoke(ctx, PaymentHostService_BatchWithdraw_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *paymentHostServiceClient) GetWithdrawalBatch(ctx context.Context, in *GetWithdrawalBatchRequest, opts ...grpc.CallOption) (*WithdrawalBatch, error) {
+	out := new(WithdrawalBatch)
+	err := c.cc.Invoke(ctx, PaymentHostService_GetWithdrawalBatch_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *paymentHostServiceClient) AddAllowedDestination(ctx context.Context, in *AddAllowedDestinationRequest, opts ...grpc.CallOption) (*AllowedDestination, error) {
+	out := new(AllowedDestination)
+	err := c.cc.Invoke(ctx, PaymentHostService_AddAllowedDestination_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *paymentHostServiceClient) RemoveAllowedDestination(ctx context.Context, in *RemoveAllowedDestinationRequest, opts ...grpc.CallOption) (*RemoveAllowedDestinationResponse, error) {
+	out := new(RemoveAllowedDestinationResponse)
+	err := c.cc.Invoke(ctx, PaymentHostService_RemoveAllowedDestination_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *paymentHostServiceClient) ListAllowedDestinations(ctx context.Context, in *ListAllowedDestinationsRequest, opts ...grpc.CallOption) (*ListAllowedDestinationsResponse, error) {
+	out := new(ListAllowedDestinationsResponse)
+	err := c.cc.Invoke(ctx, PaymentHostService_ListAllowedDestinations_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *paymentHostServiceClient) CreateInvoice(ctx context.Context, in *CreateInvoiceRequest, opts ...grpc.CallOption) (*Invoice, error) {
+	out := new(Invoice)
+	err := c.cc.Invoke(ctx, PaymentHostService_CreateInvoice_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *paymentHostServiceClient) GetInvoice(ctx context.Context, in *GetInvoiceRequest, opts ...grpc.CallOption) (*Invoice, error) {
+	out := new(Invoice)
+	err := c.cc.Invoke(ctx, PaymentHostService_GetInvoice_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *paymentHostServiceClient) CancelInvoice(ctx context.Context, in *CancelInvoiceRequest, opts ...grpc.CallOption) (*Invoice, error) {
+	out := new(Invoice)
+	err := c.cc.Invoke(ctx, PaymentHostService_CancelInvoice_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *paymentHostServiceClient) GetBalance(ctx context.Context, in *GetBalanceRequest, opts ...grpc.CallOption) (*GetBalanceResponse, error) {
+	out := new(GetBalanceResponse)
+	err := c.cc.Invoke(ctx, PaymentHostService_GetBalance_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *paymentHostServiceClient) ListTransactions(ctx context.Context, in *ListTransactionsRequest, opts ...grpc.CallOption) (*ListTransactionsResponse, error) {
+	out := new(ListTransactionsResponse)
+	err := c.cc.Invoke(ctx, PaymentHostService_ListTransactions_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *paymentHostServiceClient) SubscribeDeposits(ctx context.Context, in *SubscribeDepositsRequest, opts ...grpc.CallOption) (PaymentHostService_SubscribeDepositsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &PaymentHostService_ServiceDesc.Streams[0], PaymentHostService_SubscribeDeposits_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &paymentHostServiceSubscribeDepositsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type PaymentHostService_SubscribeDepositsClient interface {
+	Recv() (*DepositEvent, error)
+	grpc.ClientStream
+}
+
+type paymentHostServiceSubscribeDepositsClient struct {
+	grpc.ClientStream
+}
+
+func (x *paymentHostServiceSubscribeDepositsClient) Recv() (*DepositEvent, error) {
+	m := new(DepositEvent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // PaymentHostServiceServer is the server API for PaymentHostService service.
 // All implementations must embed UnimplementedPaymentHostServiceServer
 // for forward compatibility
 type PaymentHostServiceServer interface {
 	Register(context.Context, *RegisterRequest) (*RegisterResponse, error)
+	CreateApiKey(context.Context, *CreateApiKeyRequest) (*CreateApiKeyResponse, error)
+	RevokeApiKey(context.Context, *RevokeApiKeyRequest) (*RevokeApiKeyResponse, error)
 	Withdraw(context.Context, *WithdrawRequest) (*WithdrawResponse, error)
+	QuoteWithdraw(context.Context, *QuoteWithdrawRequest) (*WithdrawalQuote, error)
+	GetWithdrawalRequest(context.Context, *GetWithdrawalRequestRequest) (*WithdrawalRequest, error)
+	BatchWithdraw(context.Context, *BatchWithdrawRequest) (*WithdrawalBatch, error)
+	GetWithdrawalBatch(context.Context, *GetWithdrawalBatchRequest) (*WithdrawalBatch, error)
+	AddAllowedDestination(context.Context, *AddAllowedDestinationRequest) (*AllowedDestination, error)
+	RemoveAllowedDestination(context.Context, *RemoveAllowedDestinationRequest) (*RemoveAllowedDestinationResponse, error)
+	ListAllowedDestinations(context.Context, *ListAllowedDestinationsRequest) (*ListAllowedDestinationsResponse, error)
+	CreateInvoice(context.Context, *CreateInvoiceRequest) (*Invoice, error)
+	GetInvoice(context.Context, *GetInvoiceRequest) (*Invoice, error)
+	CancelInvoice(context.Context, *CancelInvoiceRequest) (*Invoice, error)
+	GetBalance(context.Context, *GetBalanceRequest) (*GetBalanceResponse, error)
+	ListTransactions(context.Context, *ListTransactionsRequest) (*ListTransactionsResponse, error)
+	SubscribeDeposits(*SubscribeDepositsRequest, PaymentHostService_SubscribeDepositsServer) error
 	mustEmbedUnimplementedPaymentHostServiceServer()
 }
 
@@ -73,9 +276,54 @@ type UnimplementedPaymentHostServiceServer struct {
 func (UnimplementedPaymentHostServiceServer) Register(context.Context, *RegisterRequest) (*RegisterResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Register not implemented")
 }
+func (UnimplementedPaymentHostServiceServer) CreateApiKey(context.Context, *CreateApiKeyRequest) (*CreateApiKeyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateApiKey not implemented")
+}
+func (UnimplementedPaymentHostServiceServer) RevokeApiKey(context.Context, *RevokeApiKeyRequest) (*RevokeApiKeyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RevokeApiKey not implemented")
+}
 func (UnimplementedPaymentHostServiceServer) Withdraw(context.Context, *WithdrawRequest) (*WithdrawResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Withdraw not implemented")
 }
+func (UnimplementedPaymentHostServiceServer) QuoteWithdraw(context.Context, *QuoteWithdrawRequest) (*WithdrawalQuote, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method QuoteWithdraw not implemented")
+}
+func (UnimplementedPaymentHostServiceServer) GetWithdrawalRequest(context.Context, *GetWithdrawalRequestRequest) (*WithdrawalRequest, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetWithdrawalRequest not implemented")
+}
+func (UnimplementedPaymentHostServiceServer) BatchWithdraw(context.Context, *BatchWithdrawRequest) (*WithdrawalBatch, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BatchWithdraw not implemented")
+}
+func (UnimplementedPaymentHostServiceServer) GetWithdrawalBatch(context.Context, *GetWithdrawalBatchRequest) (*WithdrawalBatch, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetWithdrawalBatch not implemented")
+}
+func (UnimplementedPaymentHostServiceServer) AddAllowedDestination(context.Context, *AddAllowedDestinationRequest) (*AllowedDestination, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddAllowedDestination not implemented")
+}
+func (UnimplementedPaymentHostServiceServer) RemoveAllowedDestination(context.Context, *RemoveAllowedDestinationRequest) (*RemoveAllowedDestinationResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RemoveAllowedDestination not implemented")
+}
+func (UnimplementedPaymentHostServiceServer) ListAllowedDestinations(context.Context, *ListAllowedDestinationsRequest) (*ListAllowedDestinationsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListAllowedDestinations not implemented")
+}
+func (UnimplementedPaymentHostServiceServer) CreateInvoice(context.Context, *CreateInvoiceRequest) (*Invoice, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateInvoice not implemented")
+}
+func (UnimplementedPaymentHostServiceServer) GetInvoice(context.Context, *GetInvoiceRequest) (*Invoice, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetInvoice not implemented")
+}
+func (UnimplementedPaymentHostServiceServer) CancelInvoice(context.Context, *CancelInvoiceRequest) (*Invoice, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CancelInvoice not implemented")
+}
+func (UnimplementedPaymentHostServiceServer) GetBalance(context.Context, *GetBalanceRequest) (*GetBalanceResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetBalance not implemented")
+}
+func (UnimplementedPaymentHostServiceServer) ListTransactions(context.Context, *ListTransactionsRequest) (*ListTransactionsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListTransactions not implemented")
+}
+func (UnimplementedPaymentHostServiceServer) SubscribeDeposits(*SubscribeDepositsRequest, PaymentHostService_SubscribeDepositsServer) error {
+	return status.Errorf(codes.Unimplemented, "method SubscribeDeposits not implemented")
+}
 func (UnimplementedPaymentHostServiceServer) mustEmbedUnimplementedPaymentHostServiceServer() {}
 
 // UnsafePaymentHostServiceServer may be embedded to opt out of forward compatibility for this service.
@@ -107,6 +355,42 @@ func _PaymentHostService_Register_Handler(srv interface{}, ctx context.Context,
 	return interceptor(ctx, in, info, handler)
 }
 
+func _PaymentHostService_CreateApiKey_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateApiKeyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaymentHostServiceServer).CreateApiKey(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PaymentHostService_CreateApiKey_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaymentHostServiceServer).CreateApiKey(ctx, req.(*CreateApiKeyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PaymentHostService_RevokeApiKey_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RevokeApiKeyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaymentHostServiceServer).RevokeApiKey(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PaymentHostService_RevokeApiKey_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaymentHostServiceServer).RevokeApiKey(ctx, req.(*RevokeApiKeyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _PaymentHostService_Withdraw_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(WithdrawRequest)
 	if err := dec(in); err != nil {
@@ -125,20 +409,854 @@ func _PaymentHostService_Withdraw_Handler(srv interface{}, ctx context.Context,
 	return interceptor(ctx, in, info, handler)
 }
 
-// PaymentHostService_ServiceDesc is the grpc.ServiceDesc for PaymentHostService service.
-// It's only intended for direct use with grpc.RegisterService,
-// and not to be introspected or modified (even as a copy)
-var PaymentHostService_ServiceDesc = grpc.ServiceDesc{
-	ServiceName: "PaymentHostService",
-	HandlerType: (*PaymentHostServiceServer)(nil),
-	Methods: []grpc.MethodDesc{
-		{
-			MethodName: "Register",
-			Handler:    _PaymentHostService_Register_Handler,
-		},
-		{
-			MethodName: "Withdraw",
-			Handler:    _PaymentHostService_Withdraw_Handler,
+func _PaymentHostService_QuoteWithdraw_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(QuoteWithdrawRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaymentHostServiceServer).QuoteWithdraw(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PaymentHostService_QuoteWithdraw_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaymentHostServiceServer).QuoteWithdraw(ctx, req.(*QuoteWithdrawRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PaymentHostService_GetWithdrawalRequest_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetWithdrawalRequestRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaymentHostServiceServer).GetWithdrawalRequest(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PaymentHostService_GetWithdrawalRequest_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaymentHostServiceServer).GetWithdrawalRequest(ctx, req.(*GetWithdrawalRequestRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PaymentHostService_BatchWithdraw_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchWithdrawRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaymentHostServiceServer).BatchWithdraw(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PaymentHostService_BatchWithdraw_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaymentHostServiceServer).BatchWithdraw(ctx, req.(*BatchWithdrawRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PaymentHostService_GetWithdrawalBatch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetWithdrawalBatchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaymentHostServiceServer).GetWithdrawalBatch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PaymentHostService_GetWithdrawalBatch_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaymentHostServiceServer).GetWithdrawalBatch(ctx, req.(*GetWithdrawalBatchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PaymentHostService_AddAllowedDestination_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddAllowedDestinationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaymentHostServiceServer).AddAllowedDestination(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PaymentHostService_AddAllowedDestination_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaymentHostServiceServer).AddAllowedDestination(ctx, req.(*AddAllowedDestinationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PaymentHostService_RemoveAllowedDestination_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RemoveAllowedDestinationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaymentHostServiceServer).RemoveAllowedDestination(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PaymentHostService_RemoveAllowedDestination_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaymentHostServiceServer).RemoveAllowedDestination(ctx, req.(*RemoveAllowedDestinationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PaymentHostService_ListAllowedDestinations_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListAllowedDestinationsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaymentHostServiceServer).ListAllowedDestinations(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PaymentHostService_ListAllowedDestinations_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaymentHostServiceServer).ListAllowedDestinations(ctx, req.(*ListAllowedDestinationsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PaymentHostService_CreateInvoice_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateInvoiceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaymentHostServiceServer).CreateInvoice(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PaymentHostService_CreateInvoice_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaymentHostServiceServer).CreateInvoice(ctx, req.(*CreateInvoiceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PaymentHostService_GetInvoice_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetInvoiceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaymentHostServiceServer).GetInvoice(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PaymentHostService_GetInvoice_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaymentHostServiceServer).GetInvoice(ctx, req.(*GetInvoiceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PaymentHostService_CancelInvoice_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CancelInvoiceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaymentHostServiceServer).CancelInvoice(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PaymentHostService_CancelInvoice_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaymentHostServiceServer).CancelInvoice(ctx, req.(*CancelInvoiceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PaymentHostService_GetBalance_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetBalanceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaymentHostServiceServer).GetBalance(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PaymentHostService_GetBalance_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaymentHostServiceServer).GetBalance(ctx, req.(*GetBalanceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PaymentHostService_ListTransactions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListTransactionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaymentHostServiceServer).ListTransactions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PaymentHostService_ListTransactions_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaymentHostServiceServer).ListTransactions(ctx, req.(*ListTransactionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PaymentHostService_SubscribeDeposits_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SubscribeDepositsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(PaymentHostServiceServer).SubscribeDeposits(m, &paymentHostServiceSubscribeDepositsServer{stream})
+}
+
+type PaymentHostService_SubscribeDepositsServer interface {
+	Send(*DepositEvent) error
+	grpc.ServerStream
+}
+
+type paymentHostServiceSubscribeDepositsServer struct {
+	grpc.ServerStream
+}
+
+func (x *paymentHostServiceSubscribeDepositsServer) Send(m *DepositEvent) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// PaymentHostService_ServiceDesc is the grpc.ServiceDesc for PaymentHostService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var PaymentHostService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "PaymentHostService",
+	HandlerType: (*PaymentHostServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Register",
+			Handler:    _PaymentHostService_Register_Handler,
+		},
+		{
+			MethodName: "CreateApiKey",
+			Handler:    _PaymentHostService_CreateApiKey_Handler,
+		},
+		{
+			MethodName: "RevokeApiKey",
+			Handler:    _PaymentHostService_RevokeApiKey_Handler,
+		},
+		{
+			MethodName: "Withdraw",
+			Handler:    _PaymentHostService_Withdraw_Handler,
+		},
+		{
+			MethodName: "QuoteWithdraw",
+			Handler:    _PaymentHostService_QuoteWithdraw_Handler,
+		},
+		{
+			MethodName: "GetWithdrawalRequest",
+			Handler:    _PaymentHostService_GetWithdrawalRequest_Handler,
+		},
+		{
+			MethodName: "BatchWithdraw",
+			Handler:    _PaymentHostService_BatchWithdraw_Handler,
+		},
+		{
+			MethodName: "GetWithdrawalBatch",
+			Handler:    _PaymentHostService_GetWithdrawalBatch_Handler,
+		},
+		{
+			MethodName: "AddAllowedDestination",
+			Handler:    _PaymentHostService_AddAllowedDestination_Handler,
+		},
+		{
+			MethodName: "RemoveAllowedDestination",
+			Handler:    _PaymentHostService_RemoveAllowedDestination_Handler,
+		},
+		{
+			MethodName: "ListAllowedDestinations",
+			Handler:    _PaymentHostService_ListAllowedDestinations_Handler,
+		},
+		{
+			MethodName: "CreateInvoice",
+			Handler:    _PaymentHostService_CreateInvoice_Handler,
+		},
+		{
+			MethodName: "GetInvoice",
+			Handler:    _PaymentHostService_GetInvoice_Handler,
+		},
+		{
+			MethodName: "CancelInvoice",
+			Handler:    _PaymentHostService_CancelInvoice_Handler,
+		},
+		{
+			MethodName: "GetBalance",
+			Handler:    _PaymentHostService_GetBalance_Handler,
+		},
+		{
+			MethodName: "ListTransactions",
+			Handler:    _PaymentHostService_ListTransactions_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "SubscribeDeposits",
+			Handler:       _PaymentHostService_SubscribeDeposits_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "payment.proto",
+}
+
+const (
+	PaymentHostAdminService_GetScannerStatus_FullMethodName          = "/PaymentHostAdminService/GetScannerStatus"
+	PaymentHostAdminService_SetPaymentMark_FullMethodName            = "/PaymentHostAdminService/SetPaymentMark"
+	PaymentHostAdminService_RescanBlocks_FullMethodName              = "/PaymentHostAdminService/RescanBlocks"
+	PaymentHostAdminService_PauseScanner_FullMethodName              = "/PaymentHostAdminService/PauseScanner"
+	PaymentHostAdminService_ResumeScanner_FullMethodName             = "/PaymentHostAdminService/ResumeScanner"
+	PaymentHostAdminService_SpeedUpTransaction_FullMethodName        = "/PaymentHostAdminService/SpeedUpTransaction"
+	PaymentHostAdminService_CancelTransaction_FullMethodName         = "/PaymentHostAdminService/CancelTransaction"
+	PaymentHostAdminService_ListWithdrawalRequests_FullMethodName    = "/PaymentHostAdminService/ListWithdrawalRequests"
+	PaymentHostAdminService_ApproveWithdrawal_FullMethodName         = "/PaymentHostAdminService/ApproveWithdrawal"
+	PaymentHostAdminService_RejectWithdrawal_FullMethodName          = "/PaymentHostAdminService/RejectWithdrawal"
+	PaymentHostAdminService_SetWithdrawalPolicy_FullMethodName       = "/PaymentHostAdminService/SetWithdrawalPolicy"
+	PaymentHostAdminService_ListSuspenseTransactions_FullMethodName  = "/PaymentHostAdminService/ListSuspenseTransactions"
+	PaymentHostAdminService_AssignSuspenseTransaction_FullMethodName = "/PaymentHostAdminService/AssignSuspenseTransaction"
+)
+
+// PaymentHostAdminServiceClient is the client API for PaymentHostAdminService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type PaymentHostAdminServiceClient interface {
+	GetScannerStatus(ctx context.Context, in *GetScannerStatusRequest, opts ...grpc.CallOption) (*ScannerStatus, error)
+	SetPaymentMark(ctx context.Context, in *SetPaymentMarkRequest, opts ...grpc.CallOption) (*ScannerStatus, error)
+	RescanBlocks(ctx context.Context, in *RescanBlocksRequest, opts ...grpc.CallOption) (*RescanBlocksResponse, error)
+	PauseScanner(ctx context.Context, in *PauseScannerRequest, opts ...grpc.CallOption) (*ScannerStatus, error)
+	ResumeScanner(ctx context.Context, in *ResumeScannerRequest, opts ...grpc.CallOption) (*ScannerStatus, error)
+	SpeedUpTransaction(ctx context.Context, in *SpeedUpTransactionRequest, opts ...grpc.CallOption) (*Transaction, error)
+	CancelTransaction(ctx context.Context, in *CancelTransactionRequest, opts ...grpc.CallOption) (*Transaction, error)
+	ListWithdrawalRequests(ctx context.Context, in *ListWithdrawalRequestsRequest, opts ...grpc.CallOption) (*ListWithdrawalRequestsResponse, error)
+	ApproveWithdrawal(ctx context.Context, in *ApproveWithdrawalRequest, opts ...grpc.CallOption) (*WithdrawalRequest, error)
+	RejectWithdrawal(ctx context.Context, in *RejectWithdrawalRequest, opts ...grpc.CallOption) (*WithdrawalRequest, error)
+	SetWithdrawalPolicy(ctx context.Context, in *SetWithdrawalPolicyRequest, opts ...grpc.CallOption) (*WithdrawalPolicy, error)
+	ListSuspenseTransactions(ctx context.Context, in *ListSuspenseTransactionsRequest, opts ...grpc.CallOption) (*ListTransactionsResponse, error)
+	AssignSuspenseTransaction(ctx context.Context, in *AssignSuspenseTransactionRequest, opts ...grpc.CallOption) (*Transaction, error)
+}
+
+type paymentHostAdminServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewPaymentHostAdminServiceClient(cc grpc.ClientConnInterface) PaymentHostAdminServiceClient {
+	return &paymentHostAdminServiceClient{cc}
+}
+
+func (c *paymentHostAdminServiceClient) GetScannerStatus(ctx context.Context, in *GetScannerStatusRequest, opts ...grpc.CallOption) (*ScannerStatus, error) {
+	out := new(ScannerStatus)
+	err := c.cc.Invoke(ctx, PaymentHostAdminService_GetScannerStatus_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *paymentHostAdminServiceClient) SetPaymentMark(ctx context.Context, in *SetPaymentMarkRequest, opts ...grpc.CallOption) (*ScannerStatus, error) {
+	out := new(ScannerStatus)
+	err := c.cc.Invoke(ctx, PaymentHostAdminService_SetPaymentMark_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *paymentHostAdminServiceClient) RescanBlocks(ctx context.Context, in *RescanBlocksRequest, opts ...grpc.CallOption) (*RescanBlocksResponse, error) {
+	out := new(RescanBlocksResponse)
+	err := c.cc.Invoke(ctx, PaymentHostAdminService_RescanBlocks_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *paymentHostAdminServiceClient) PauseScanner(ctx context.Context, in *PauseScannerRequest, opts ...grpc.CallOption) (*ScannerStatus, error) {
+	out := new(ScannerStatus)
+	err := c.cc.Invoke(ctx, PaymentHostAdminService_PauseScanner_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *paymentHostAdminServiceClient) ResumeScanner(ctx context.Context, in *ResumeScannerRequest, opts ...grpc.CallOption) (*ScannerStatus, error) {
+	out := new(ScannerStatus)
+	err := c.cc.Invoke(ctx, PaymentHostAdminService_ResumeScanner_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *paymentHostAdminServiceClient) SpeedUpTransaction(ctx context.Context, in *SpeedUpTransactionRequest, opts ...grpc.CallOption) (*Transaction, error) {
+	out := new(Transaction)
+	err := c.cc.Invoke(ctx, PaymentHostAdminService_SpeedUpTransaction_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *paymentHostAdminServiceClient) CancelTransaction(ctx context.Context, in *CancelTransactionRequest, opts ...grpc.CallOption) (*Transaction, error) {
+	out := new(Transaction)
+	err := c.cc.Invoke(ctx, PaymentHostAdminService_CancelTransaction_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *paymentHostAdminServiceClient) ListWithdrawalRequests(ctx context.Context, in *ListWithdrawalRequestsRequest, opts ...grpc.CallOption) (*ListWithdrawalRequestsResponse, error) {
+	out := new(ListWithdrawalRequestsResponse)
+	err := c.cc.Invoke(ctx, PaymentHostAdminService_ListWithdrawalRequests_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *paymentHostAdminServiceClient) ApproveWithdrawal(ctx context.Context, in *ApproveWithdrawalRequest, opts ...grpc.CallOption) (*WithdrawalRequest, error) {
+	out := new(WithdrawalRequest)
+	err := c.cc.Invoke(ctx, PaymentHostAdminService_ApproveWithdrawal_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *paymentHostAdminServiceClient) RejectWithdrawal(ctx context.Context, in *RejectWithdrawalRequest, opts ...grpc.CallOption) (*WithdrawalRequest, error) {
+	out := new(WithdrawalRequest)
+	err := c.cc.Invoke(ctx, PaymentHostAdminService_RejectWithdrawal_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *paymentHostAdminServiceClient) SetWithdrawalPolicy(ctx context.Context, in *SetWithdrawalPolicyRequest, opts ...grpc.CallOption) (*WithdrawalPolicy, error) {
+	out := new(WithdrawalPolicy)
+	err := c.cc.Invoke(ctx, PaymentHostAdminService_SetWithdrawalPolicy_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *paymentHostAdminServiceClient) ListSuspenseTransactions(ctx context.Context, in *ListSuspenseTransactionsRequest, opts ...grpc.CallOption) (*ListTransactionsResponse, error) {
+	out := new(ListTransactionsResponse)
+	err := c.cc.Invoke(ctx, PaymentHostAdminService_ListSuspenseTransactions_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *paymentHostAdminServiceClient) AssignSuspenseTransaction(ctx context.Context, in *AssignSuspenseTransactionRequest, opts ...grpc.CallOption) (*Transaction, error) {
+	out := new(Transaction)
+	err := c.cc.Invoke(ctx, PaymentHostAdminService_AssignSuspenseTransaction_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// PaymentHostAdminServiceServer is the server API for PaymentHostAdminService service.
+// All implementations must embed UnimplementedPaymentHostAdminServiceServer
+// for forward compatibility
+type PaymentHostAdminServiceServer interface {
+	GetScannerStatus(context.Context, *GetScannerStatusRequest) (*ScannerStatus, error)
+	SetPaymentMark(context.Context, *SetPaymentMarkRequest) (*ScannerStatus, error)
+	RescanBlocks(context.Context, *RescanBlocksRequest) (*RescanBlocksResponse, error)
+	PauseScanner(context.Context, *PauseScannerRequest) (*ScannerStatus, error)
+	ResumeScanner(context.Context, *ResumeScannerRequest) (*ScannerStatus, error)
+	SpeedUpTransaction(context.Context, *SpeedUpTransactionRequest) (*Transaction, error)
+	CancelTransaction(context.Context, *CancelTransactionRequest) (*Transaction, error)
+	ListWithdrawalRequests(context.Context, *ListWithdrawalRequestsRequest) (*ListWithdrawalRequestsResponse, error)
+	ApproveWithdrawal(context.Context, *ApproveWithdrawalRequest) (*WithdrawalRequest, error)
+	RejectWithdrawal(context.Context, *RejectWithdrawalRequest) (*WithdrawalRequest, error)
+	SetWithdrawalPolicy(context.Context, *SetWithdrawalPolicyRequest) (*WithdrawalPolicy, error)
+	ListSuspenseTransactions(context.Context, *ListSuspenseTransactionsRequest) (*ListTransactionsResponse, error)
+	AssignSuspenseTransaction(context.Context, *AssignSuspenseTransactionRequest) (*Transaction, error)
+	mustEmbedUnimplementedPaymentHostAdminServiceServer()
+}
+
+// UnimplementedPaymentHostAdminServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedPaymentHostAdminServiceServer struct {
+}
+
+func (UnimplementedPaymentHostAdminServiceServer) GetScannerStatus(context.Context, *GetScannerStatusRequest) (*ScannerStatus, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetScannerStatus not implemented")
+}
+func (UnimplementedPaymentHostAdminServiceServer) SetPaymentMark(context.Context, *SetPaymentMarkRequest) (*ScannerStatus, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetPaymentMark not implemented")
+}
+func (UnimplementedPaymentHostAdminServiceServer) RescanBlocks(context.Context, *RescanBlocksRequest) (*RescanBlocksResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RescanBlocks not implemented")
+}
+func (UnimplementedPaymentHostAdminServiceServer) PauseScanner(context.Context, *PauseScannerRequest) (*ScannerStatus, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PauseScanner not implemented")
+}
+func (UnimplementedPaymentHostAdminServiceServer) ResumeScanner(context.Context, *ResumeScannerRequest) (*ScannerStatus, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ResumeScanner not implemented")
+}
+func (UnimplementedPaymentHostAdminServiceServer) SpeedUpTransaction(context.Context, *SpeedUpTransactionRequest) (*Transaction, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SpeedUpTransaction not implemented")
+}
+func (UnimplementedPaymentHostAdminServiceServer) CancelTransaction(context.Context, *CancelTransactionRequest) (*Transaction, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CancelTransaction not implemented")
+}
+func (UnimplementedPaymentHostAdminServiceServer) ListWithdrawalRequests(context.Context, *ListWithdrawalRequestsRequest) (*ListWithdrawalRequestsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListWithdrawalRequests not implemented")
+}
+func (UnimplementedPaymentHostAdminServiceServer) ApproveWithdrawal(context.Context, *ApproveWithdrawalRequest) (*WithdrawalRequest, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ApproveWithdrawal not implemented")
+}
+func (UnimplementedPaymentHostAdminServiceServer) RejectWithdrawal(context.Context, *RejectWithdrawalRequest) (*WithdrawalRequest, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RejectWithdrawal not implemented")
+}
+func (UnimplementedPaymentHostAdminServiceServer) SetWithdrawalPolicy(context.Context, *SetWithdrawalPolicyRequest) (*WithdrawalPolicy, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetWithdrawalPolicy not implemented")
+}
+func (UnimplementedPaymentHostAdminServiceServer) ListSuspenseTransactions(context.Context, *ListSuspenseTransactionsRequest) (*ListTransactionsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListSuspenseTransactions not implemented")
+}
+func (UnimplementedPaymentHostAdminServiceServer) AssignSuspenseTransaction(context.Context, *AssignSuspenseTransactionRequest) (*Transaction, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AssignSuspenseTransaction not implemented")
+}
+func (UnimplementedPaymentHostAdminServiceServer) mustEmbedUnimplementedPaymentHostAdminServiceServer() {
+}
+
+// UnsafePaymentHostAdminServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to PaymentHostAdminServiceServer will
+// result in compilation errors.
+type UnsafePaymentHostAdminServiceServer interface {
+	mustEmbedUnimplementedPaymentHostAdminServiceServer()
+}
+
+func RegisterPaymentHostAdminServiceServer(s grpc.ServiceRegistrar, srv PaymentHostAdminServiceServer) {
+	s.RegisterService(&PaymentHostAdminService_ServiceDesc, srv)
+}
+
+func _PaymentHostAdminService_GetScannerStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetScannerStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaymentHostAdminServiceServer).GetScannerStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PaymentHostAdminService_GetScannerStatus_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaymentHostAdminServiceServer).GetScannerStatus(ctx, req.(*GetScannerStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PaymentHostAdminService_SetPaymentMark_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetPaymentMarkRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaymentHostAdminServiceServer).SetPaymentMark(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PaymentHostAdminService_SetPaymentMark_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaymentHostAdminServiceServer).SetPaymentMark(ctx, req.(*SetPaymentMarkRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PaymentHostAdminService_RescanBlocks_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RescanBlocksRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaymentHostAdminServiceServer).RescanBlocks(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PaymentHostAdminService_RescanBlocks_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaymentHostAdminServiceServer).RescanBlocks(ctx, req.(*RescanBlocksRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PaymentHostAdminService_PauseScanner_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PauseScannerRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaymentHostAdminServiceServer).PauseScanner(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PaymentHostAdminService_PauseScanner_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaymentHostAdminServiceServer).PauseScanner(ctx, req.(*PauseScannerRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PaymentHostAdminService_ResumeScanner_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ResumeScannerRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaymentHostAdminServiceServer).ResumeScanner(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PaymentHostAdminService_ResumeScanner_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaymentHostAdminServiceServer).ResumeScanner(ctx, req.(*ResumeScannerRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PaymentHostAdminService_SpeedUpTransaction_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SpeedUpTransactionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaymentHostAdminServiceServer).SpeedUpTransaction(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PaymentHostAdminService_SpeedUpTransaction_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaymentHostAdminServiceServer).SpeedUpTransaction(ctx, req.(*SpeedUpTransactionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PaymentHostAdminService_CancelTransaction_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CancelTransactionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaymentHostAdminServiceServer).CancelTransaction(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PaymentHostAdminService_CancelTransaction_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaymentHostAdminServiceServer).CancelTransaction(ctx, req.(*CancelTransactionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PaymentHostAdminService_ListWithdrawalRequests_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListWithdrawalRequestsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaymentHostAdminServiceServer).ListWithdrawalRequests(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PaymentHostAdminService_ListWithdrawalRequests_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaymentHostAdminServiceServer).ListWithdrawalRequests(ctx, req.(*ListWithdrawalRequestsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PaymentHostAdminService_ApproveWithdrawal_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ApproveWithdrawalRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaymentHostAdminServiceServer).ApproveWithdrawal(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PaymentHostAdminService_ApproveWithdrawal_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaymentHostAdminServiceServer).ApproveWithdrawal(ctx, req.(*ApproveWithdrawalRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PaymentHostAdminService_RejectWithdrawal_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RejectWithdrawalRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaymentHostAdminServiceServer).RejectWithdrawal(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PaymentHostAdminService_RejectWithdrawal_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaymentHostAdminServiceServer).RejectWithdrawal(ctx, req.(*RejectWithdrawalRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PaymentHostAdminService_SetWithdrawalPolicy_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetWithdrawalPolicyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaymentHostAdminServiceServer).SetWithdrawalPolicy(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PaymentHostAdminService_SetWithdrawalPolicy_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaymentHostAdminServiceServer).SetWithdrawalPolicy(ctx, req.(*SetWithdrawalPolicyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PaymentHostAdminService_ListSuspenseTransactions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListSuspenseTransactionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaymentHostAdminServiceServer).ListSuspenseTransactions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PaymentHostAdminService_ListSuspenseTransactions_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaymentHostAdminServiceServer).ListSuspenseTransactions(ctx, req.(*ListSuspenseTransactionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PaymentHostAdminService_AssignSuspenseTransaction_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AssignSuspenseTransactionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaymentHostAdminServiceServer).AssignSuspenseTransaction(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PaymentHostAdminService_AssignSuspenseTransaction_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaymentHostAdminServiceServer).AssignSuspenseTransaction(ctx, req.(*AssignSuspenseTransactionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// PaymentHostAdminService_ServiceDesc is the grpc.ServiceDesc for PaymentHostAdminService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var PaymentHostAdminService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "PaymentHostAdminService",
+	HandlerType: (*PaymentHostAdminServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetScannerStatus",
+			Handler:    _PaymentHostAdminService_GetScannerStatus_Handler,
+		},
+		{
+			MethodName: "SetPaymentMark",
+			Handler:    _PaymentHostAdminService_SetPaymentMark_Handler,
+		},
+		{
+			MethodName: "RescanBlocks",
+			Handler:    _PaymentHostAdminService_RescanBlocks_Handler,
+		},
+		{
+			MethodName: "PauseScanner",
+			Handler:    _PaymentHostAdminService_PauseScanner_Handler,
+		},
+		{
+			MethodName: "ResumeScanner",
+			Handler:    _PaymentHostAdminService_ResumeScanner_Handler,
+		},
+		{
+			MethodName: "SpeedUpTransaction",
+			Handler:    _PaymentHostAdminService_SpeedUpTransaction_Handler,
+		},
+		{
+			MethodName: "CancelTransaction",
+			Handler:    _PaymentHostAdminService_CancelTransaction_Handler,
+		},
+		{
+			MethodName: "ListWithdrawalRequests",
+			Handler:    _PaymentHostAdminService_ListWithdrawalRequests_Handler,
+		},
+		{
+			MethodName: "ApproveWithdrawal",
+			Handler:    _PaymentHostAdminService_ApproveWithdrawal_Handler,
+		},
+		{
+			MethodName: "RejectWithdrawal",
+			Handler:    _PaymentHostAdminService_RejectWithdrawal_Handler,
+		},
+		{
+			MethodName: "SetWithdrawalPolicy",
+			Handler:    _PaymentHostAdminService_SetWithdrawalPolicy_Handler,
+		},
+		{
+			MethodName: "ListSuspenseTransactions",
+			Handler:    _PaymentHostAdminService_ListSuspenseTransactions_Handler,
+		},
+		{
+			MethodName: "AssignSuspenseTransaction",
+			Handler:    _PaymentHostAdminService_AssignSuspenseTransaction_Handler,
 		},
 	},
 	Streams:  []grpc.StreamDesc{},
@@ -0,0 +1,334 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	protobuf "google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"gorm.io/gorm"
+
+	"github.com/vangxitrum/payment-host/internal/models"
+	proto "github.com/vangxitrum/payment-host/internal/proto/payment_host"
+)
+
+const (
+	entityNameField = "EntityName"
+	apiKeyHeader    = "x-api-key"
+)
+
+// adminOnlyMethods can not be called with entity credentials, whether or not
+// their requests name an entity. It holds every method of the admin service.
+var adminOnlyMethods = map[string]bool{
+	proto.PaymentHostService_CreateApiKey_FullMethodName:                   true,
+	proto.PaymentHostAdminService_GetScannerStatus_FullMethodName:          true,
+	proto.PaymentHostAdminService_SetPaymentMark_FullMethodName:            true,
+	proto.PaymentHostAdminService_RescanBlocks_FullMethodName:              true,
+	proto.PaymentHostAdminService_PauseScanner_FullMethodName:              true,
+	proto.PaymentHostAdminService_ResumeScanner_FullMethodName:             true,
+	proto.PaymentHostAdminService_SpeedUpTransaction_FullMethodName:        true,
+	proto.PaymentHostAdminService_CancelTransaction_FullMethodName:         true,
+	proto.PaymentHostAdminService_ListWithdrawalRequests_FullMethodName:    true,
+	proto.PaymentHostAdminService_ApproveWithdrawal_FullMethodName:         true,
+	proto.PaymentHostAdminService_RejectWithdrawal_FullMethodName:          true,
+	proto.PaymentHostAdminService_SetWithdrawalPolicy_FullMethodName:       true,
+	proto.PaymentHostAdminService_ListSuspenseTransactions_FullMethodName:  true,
+	proto.PaymentHostAdminService_AssignSuspenseTransaction_FullMethodName: true,
+}
+
+type AuthConfig struct {
+	Enabled bool
+	// AllowUnauthenticated lets the server run with Enabled off, it refuses
+	// to start otherwise.
+	AllowUnauthenticated bool
+
+	// AdminApiKeys holds name:key pairs.
+	AdminApiKeys []string
+	// AdminCertNames holds the client certificate common names with admin
+	// rights.
+	AdminCertNames []string
+
+	TLSCertFile     string
+	TLSKeyFile      string
+	TLSClientCAFile string
+	// TLSServerName is the name the gateway expects in the certificate of
+	// the server.
+	TLSServerName string
+}
+
+// Principal is the caller a request was authenticated as. An admin principal
+// may act on any entity, any other principal only on its own entity.
+type Principal struct {
+	Name       string
+	Admin      bool
+	EntityName string
+}
+
+type principalKey struct{}
+
+func withPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+func principalFromContext(ctx context.Context) (*Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(*Principal)
+	return principal, ok
+}
+
+type authenticator struct {
+	apiKeyRepo     models.ApiKeyRepository
+	adminApiKeys   map[string]string
+	adminCertNames map[string]bool
+}
+
+func newAuthenticator(config *AuthConfig, apiKeyRepo models.ApiKeyRepository) (*authenticator, error) {
+	adminApiKeys := make(map[string]string, len(config.AdminApiKeys))
+	for _, pair := range config.AdminApiKeys {
+		name, key, ok := strings.Cut(pair, ":")
+		if !ok || name == "" || key == "" {
+			return nil, fmt.Errorf("admin api key must be in name:key form")
+		}
+
+		adminApiKeys[models.HashApiKey(key)] = name
+	}
+
+	adminCertNames := make(map[string]bool, len(config.AdminCertNames))
+	for _, name := range config.AdminCertNames {
+		adminCertNames[name] = true
+	}
+
+	return &authenticator{
+		apiKeyRepo:     apiKeyRepo,
+		adminApiKeys:   adminApiKeys,
+		adminCertNames: adminCertNames,
+	}, nil
+}
+
+// authenticate resolves the caller from its API key, or from its verified
+// client certificate when no key is sent.
+func (a *authenticator) authenticate(ctx context.Context) (*Principal, error) {
+	if key := apiKeyFromContext(ctx); key != "" {
+		keyHash := models.HashApiKey(key)
+		if name, ok := a.adminApiKeys[keyHash]; ok {
+			return &Principal{Name: "admin:" + name, Admin: true}, nil
+		}
+
+		apiKey, err := a.apiKeyRepo.GetApiKeyByHash(ctx, keyHash)
+		if err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return nil, status.Newf(codes.Unauthenticated, "invalid api key").Err()
+			}
+
+			return nil, status.Newf(codes.Internal, "failed to get api key").Err()
+		}
+
+		if apiKey.IsRevoked() || apiKey.Entity == nil {
+			return nil, status.Newf(codes.Unauthenticated, "invalid api key").Err()
+		}
+
+		return &Principal{
+			Name:       "entity:" + apiKey.EntityId.String(),
+			EntityName: apiKey.Entity.Name,
+		}, nil
+	}
+
+	if commonName := clientCertName(ctx); commonName != "" {
+		if a.adminCertNames[commonName] {
+			return &Principal{Name: "admin:" + commonName, Admin: true}, nil
+		}
+
+		return &Principal{Name: "cert:" + commonName, EntityName: commonName}, nil
+	}
+
+	return nil, status.Newf(codes.Unauthenticated, "missing credentials").Err()
+}
+
+// authorize checks that principal may send req to method. Requests of entity
+// principals that leave the entity name empty are scoped to their own entity.
+func (a *authenticator) authorize(principal *Principal, method string, req interface{}) error {
+	if principal.Admin {
+		return nil
+	}
+
+	if adminOnlyMethods[method] {
+		return status.Newf(codes.PermissionDenied, "admin credentials required").Err()
+	}
+
+	msg, ok := req.(protobuf.Message)
+	if !ok {
+		return status.Newf(codes.PermissionDenied, "admin credentials required").Err()
+	}
+
+	reflectMsg := msg.ProtoReflect()
+	field := reflectMsg.Descriptor().Fields().ByName(entityNameField)
+	if field == nil || field.Kind() != protoreflect.StringKind {
+		return status.Newf(codes.PermissionDenied, "admin credentials required").Err()
+	}
+
+	entityName := reflectMsg.Get(field).String()
+	if entityName == "" {
+		reflectMsg.Set(field, protoreflect.ValueOfString(principal.EntityName))
+		return nil
+	}
+
+	if entityName != principal.EntityName {
+		return status.Newf(codes.PermissionDenied, "credentials do not grant access to entity %q", entityName).Err()
+	}
+
+	return nil
+}
+
+func (a *authenticator) unaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		if isPublicMethod(info.FullMethod) {
+			return handler(ctx, req)
+		}
+
+		principal, err := a.authenticate(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := a.authorize(principal, info.FullMethod, req); err != nil {
+			return nil, err
+		}
+
+		return handler(withPrincipal(ctx, principal), req)
+	}
+}
+
+func (a *authenticator) streamInterceptor() grpc.StreamServerInterceptor {
+	return func(
+		srv interface{},
+		stream grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		if isPublicMethod(info.FullMethod) {
+			return handler(srv, stream)
+		}
+
+		principal, err := a.authenticate(stream.Context())
+		if err != nil {
+			return err
+		}
+
+		return handler(srv, &authorizedStream{
+			ServerStream: stream,
+			ctx:          withPrincipal(stream.Context(), principal),
+			auth:         a,
+			principal:    principal,
+			method:       info.FullMethod,
+		})
+	}
+}
+
+// authorizedStream authorizes every message received from the client before
+// the handler sees it.
+type authorizedStream struct {
+	grpc.ServerStream
+	ctx       context.Context
+	auth      *authenticator
+	principal *Principal
+	method    string
+}
+
+func (s *authorizedStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *authorizedStream) RecvMsg(m interface{}) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+
+	return s.auth.authorize(s.principal, s.method, m)
+}
+
+func isPublicMethod(method string) bool {
+	return strings.HasPrefix(method, "/grpc.health.v1.Health/")
+}
+
+func apiKeyFromContext(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+
+	if values := md.Get(apiKeyHeader); len(values) > 0 {
+		return values[0]
+	}
+
+	if values := md.Get("authorization"); len(values) > 0 {
+		if key, ok := strings.CutPrefix(values[0], "Bearer "); ok {
+			return key
+		}
+	}
+
+	return ""
+}
+
+func clientCertName(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return ""
+	}
+
+	tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(tlsInfo.State.VerifiedChains) == 0 || len(tlsInfo.State.VerifiedChains[0]) == 0 {
+		return ""
+	}
+
+	return tlsInfo.State.VerifiedChains[0][0].Subject.CommonName
+}
+
+// serverTLSConfig returns nil when no server certificate is configured. A
+// configured client CA enables mTLS, client certificates stay optional so that
+// API key callers can still connect.
+func serverTLSConfig(config *AuthConfig) (*tls.Config, error) {
+	if config.TLSCertFile == "" {
+		return nil, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(config.TLSCertFile, config.TLSKeyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	if config.TLSClientCAFile != "" {
+		caPem, err := os.ReadFile(config.TLSClientCAFile)
+		if err != nil {
+			return nil, err
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPem) {
+			return nil, fmt.Errorf("no certificates found in %s", config.TLSClientCAFile)
+		}
+
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+
+	return tlsConfig, nil
+}
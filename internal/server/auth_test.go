@@ -0,0 +1,82 @@
+package server
+
+import (
+	"fmt"
+	"testing"
+
+	proto "github.com/vangxitrum/payment-host/internal/proto/payment_host"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+func TestAuthorize(t *testing.T) {
+	auth := &authenticator{}
+	entity := &Principal{Name: "entity:1", EntityName: "alice"}
+	admin := &Principal{Name: "admin:ops", Admin: true}
+
+	cases := []struct {
+		name      string
+		principal *Principal
+		method    string
+		req       interface{}
+		code      codes.Code
+	}{
+		{"own entity", entity, proto.PaymentHostService_Withdraw_FullMethodName, &proto.WithdrawRequest{EntityName: "alice"}, codes.OK},
+		{"other entity", entity, proto.PaymentHostService_Withdraw_FullMethodName, &proto.WithdrawRequest{EntityName: "bob"}, codes.PermissionDenied},
+		{"no entity field", entity, proto.PaymentHostService_Register_FullMethodName, &proto.RegisterRequest{Name: "carol"}, codes.PermissionDenied},
+		{"admin only", entity, proto.PaymentHostService_CreateApiKey_FullMethodName, &proto.CreateApiKeyRequest{EntityName: "alice"}, codes.PermissionDenied},
+		{"admin service", entity, proto.PaymentHostAdminService_SetWithdrawalPolicy_FullMethodName, &proto.SetWithdrawalPolicyRequest{EntityName: "alice"}, codes.PermissionDenied},
+		{"admin", admin, proto.PaymentHostService_Withdraw_FullMethodName, &proto.WithdrawRequest{EntityName: "bob"}, codes.OK},
+	}
+
+	for _, c := range cases {
+		err := auth.authorize(c.principal, c.method, c.req)
+		if status.Code(err) != c.code {
+			t.Errorf("%s: got %v, want %v", c.name, status.Code(err), c.code)
+		}
+	}
+
+	req := &proto.ListTransactionsRequest{}
+	if err := auth.authorize(entity, proto.PaymentHostService_ListTransactions_FullMethodName, req); err != nil {
+		t.Fatal(err)
+	}
+
+	if req.EntityName != "alice" {
+		t.Errorf("entity name not scoped to principal, got %q", req.EntityName)
+	}
+}
+
+// TestAuthorizeAdminService calls every admin method with entity credentials,
+// naming their own entity where the request has an entity field. Methods must
+// be listed as admin only rather than rely on their requests having no entity
+// field.
+func TestAuthorizeAdminService(t *testing.T) {
+	auth := &authenticator{}
+	entity := &Principal{Name: "entity:1", EntityName: "alice"}
+
+	service := proto.File_payment_proto.Services().ByName("PaymentHostAdminService")
+	methods := service.Methods()
+	for i := 0; i < methods.Len(); i++ {
+		method := methods.Get(i)
+		fullMethod := fmt.Sprintf("/%s/%s", service.FullName(), method.Name())
+		if !adminOnlyMethods[fullMethod] {
+			t.Errorf("%s is missing from adminOnlyMethods", fullMethod)
+		}
+
+		msgType, err := protoregistry.GlobalTypes.FindMessageByName(method.Input().FullName())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req := msgType.New()
+		if field := req.Descriptor().Fields().ByName(entityNameField); field != nil {
+			req.Set(field, protoreflect.ValueOfString(entity.EntityName))
+		}
+
+		if err := auth.authorize(entity, fullMethod, req.Interface()); status.Code(err) != codes.PermissionDenied {
+			t.Errorf("%s: got %v, want %v", fullMethod, status.Code(err), codes.PermissionDenied)
+		}
+	}
+}
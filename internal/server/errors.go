@@ -0,0 +1,119 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/vangxitrum/payment-host/internal/models"
+)
+
+const errorDomain = "payment-host"
+
+var errorKindCodes = map[string]codes.Code{
+	models.ERROR_KIND_INVALID_ARGUMENT:    codes.InvalidArgument,
+	models.ERROR_KIND_NOT_FOUND:           codes.NotFound,
+	models.ERROR_KIND_FAILED_PRECONDITION: codes.FailedPrecondition,
+	models.ERROR_KIND_RESOURCE_EXHAUSTED:  codes.ResourceExhausted,
+	models.ERROR_KIND_PERMISSION_DENIED:   codes.PermissionDenied,
+}
+
+// toStatusError converts domain errors into gRPC statuses carrying an
+// ErrorInfo plus the detail type that matches the code. Other errors are
+// returned unchanged.
+func toStatusError(err error) error {
+	var domainErr *models.Error
+	if !errors.As(err, &domainErr) {
+		return err
+	}
+
+	code, ok := errorKindCodes[domainErr.Kind]
+	if !ok {
+		code = codes.Internal
+	}
+
+	st := status.New(code, domainErr.Message)
+	errorInfo := &errdetails.ErrorInfo{
+		Reason:   domainErr.Reason,
+		Domain:   errorDomain,
+		Metadata: domainErr.Metadata,
+	}
+
+	var detailed *status.Status
+	switch code {
+	case codes.InvalidArgument:
+		detailed, err = st.WithDetails(errorInfo, &errdetails.BadRequest{
+			FieldViolations: []*errdetails.BadRequest_FieldViolation{
+				{Field: domainErr.Subject, Description: domainErr.Message},
+			},
+		})
+	case codes.NotFound:
+		detailed, err = st.WithDetails(errorInfo, &errdetails.ResourceInfo{
+			ResourceType: domainErr.Metadata["resource_type"],
+			ResourceName: domainErr.Metadata["resource_name"],
+			Description:  domainErr.Message,
+		})
+	case codes.FailedPrecondition:
+		detailed, err = st.WithDetails(errorInfo, &errdetails.PreconditionFailure{
+			Violations: []*errdetails.PreconditionFailure_Violation{
+				{Type: domainErr.Reason, Subject: domainErr.Subject, Description: domainErr.Message},
+			},
+		})
+	case codes.ResourceExhausted:
+		detailed, err = st.WithDetails(errorInfo, &errdetails.QuotaFailure{
+			Violations: []*errdetails.QuotaFailure_Violation{
+				{Subject: domainErr.Subject, Description: domainErr.Message},
+			},
+		})
+	default:
+		detailed, err = st.WithDetails(errorInfo)
+	}
+
+	if err != nil {
+		return st.Err()
+	}
+
+	return detailed.Err()
+}
+
+// invalidArgument builds the status for a request field that failed
+// validation in the API layer.
+func invalidArgument(field, format string, args ...interface{}) error {
+	return toStatusError(models.NewInvalidArgumentError(
+		models.REASON_INVALID_ARGUMENT,
+		field,
+		fmt.Sprintf(format, args...),
+	))
+}
+
+func errorUnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		resp, err := handler(ctx, req)
+		if err != nil {
+			return nil, toStatusError(err)
+		}
+
+		return resp, nil
+	}
+}
+
+func errorStreamInterceptor() grpc.StreamServerInterceptor {
+	return func(
+		srv interface{},
+		stream grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		return toStatusError(handler(srv, stream))
+	}
+}
@@ -0,0 +1,122 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/grpc-ecosystem/grpc-gateway/runtime"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+
+	proto "github.com/vangxitrum/payment-host/internal/proto/payment_host"
+)
+
+// MustMakeGatewayServerAndRun serves a REST/JSON transcoding of the payment
+// host service on listenAddr. Every call is forwarded to the gRPC server at
+// grpcAddr, so authentication and idempotency behave exactly as over gRPC.
+func MustMakeGatewayServerAndRun(
+	listenAddr string,
+	grpcAddr string,
+	authConfig *AuthConfig,
+) {
+	dialCreds, err := gatewayDialCredentials(authConfig)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	gatewayMux := runtime.NewServeMux(runtime.WithIncomingHeaderMatcher(gatewayHeaderMatcher))
+	dialOptions := []grpc.DialOption{grpc.WithTransportCredentials(dialCreds)}
+	if err := proto.RegisterPaymentHostServiceHandlerFromEndpoint(
+		context.Background(),
+		gatewayMux,
+		grpcAddr,
+		dialOptions,
+	); err != nil {
+		log.Fatal(err)
+	}
+
+	if err := proto.RegisterPaymentHostAdminServiceHandlerFromEndpoint(
+		context.Background(),
+		gatewayMux,
+		grpcAddr,
+		dialOptions,
+	); err != nil {
+		log.Fatal(err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/openapi.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(proto.OpenAPI)
+	})
+	mux.Handle("/", gatewayMux)
+
+	if err := http.ListenAndServe(listenAddr, mux); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// gatewayHeaderMatcher forwards the API key header on top of the headers the
+// gateway forwards by default, such as Authorization.
+func gatewayHeaderMatcher(key string) (string, bool) {
+	if strings.EqualFold(key, apiKeyHeader) {
+		return apiKeyHeader, true
+	}
+
+	return runtime.DefaultHeaderMatcher(key)
+}
+
+// gatewayDialCredentials trusts the system roots and the configured server
+// certificate, which covers self-signed deployments. The gateway dials the
+// server over loopback, so the certificate is checked against the configured
+// server name, or against the first DNS name it lists, rather than localhost.
+func gatewayDialCredentials(authConfig *AuthConfig) (credentials.TransportCredentials, error) {
+	if authConfig.TLSCertFile == "" {
+		return insecure.NewCredentials(), nil
+	}
+
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+
+	certPem, err := os.ReadFile(authConfig.TLSCertFile)
+	if err != nil {
+		return nil, err
+	}
+
+	pool.AppendCertsFromPEM(certPem)
+	serverName := authConfig.TLSServerName
+	if serverName == "" {
+		serverName = certServerName(certPem)
+	}
+
+	return credentials.NewTLS(&tls.Config{
+		RootCAs:    pool,
+		ServerName: serverName,
+		MinVersion: tls.VersionTLS12,
+	}), nil
+}
+
+// certServerName returns the first DNS name of the leaf certificate in
+// certPem, or an empty name to check against the dialed host.
+func certServerName(certPem []byte) string {
+	block, _ := pem.Decode(certPem)
+	if block == nil {
+		return ""
+	}
+
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil || len(cert.DNSNames) == 0 {
+		return ""
+	}
+
+	return cert.DNSNames[0]
+}
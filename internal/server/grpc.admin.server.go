@@ -0,0 +1,310 @@
+package server
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/vangxitrum/payment-host/internal/models"
+	proto "github.com/vangxitrum/payment-host/internal/proto/payment_host"
+	"github.com/vangxitrum/payment-host/internal/services"
+)
+
+// PaymentHostAdminServer exposes operational controls. Its methods are listed
+// in adminOnlyMethods, so only admin credentials can call them.
+type PaymentHostAdminServer struct {
+	entityService services.EntityService
+	proto.UnimplementedPaymentHostAdminServiceServer
+}
+
+func newPaymentHostAdminServer(entityService services.EntityService) *PaymentHostAdminServer {
+	return &PaymentHostAdminServer{
+		entityService: entityService,
+	}
+}
+
+func (s *PaymentHostAdminServer) GetScannerStatus(ctx context.Context, req *proto.GetScannerStatusRequest) (*proto.ScannerStatus, error) {
+	scannerStatus, err := s.entityService.GetScannerStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return toProtoScannerStatus(scannerStatus), nil
+}
+
+func (s *PaymentHostAdminServer) SetPaymentMark(ctx context.Context, req *proto.SetPaymentMarkRequest) (*proto.ScannerStatus, error) {
+	scannerStatus, err := s.entityService.SetPaymentMark(ctx, req.BlockNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	return toProtoScannerStatus(scannerStatus), nil
+}
+
+func (s *PaymentHostAdminServer) RescanBlocks(ctx context.Context, req *proto.RescanBlocksRequest) (*proto.RescanBlocksResponse, error) {
+	recorded, err := s.entityService.RescanBlocks(ctx, req.FromBlock, req.ToBlock)
+	if err != nil {
+		return nil, err
+	}
+
+	return &proto.RescanBlocksResponse{
+		RecordedDeposits: int32(recorded),
+	}, nil
+}
+
+func (s *PaymentHostAdminServer) PauseScanner(ctx context.Context, req *proto.PauseScannerRequest) (*proto.ScannerStatus, error) {
+	return s.setScannerPaused(ctx, true)
+}
+
+func (s *PaymentHostAdminServer) ResumeScanner(ctx context.Context, req *proto.ResumeScannerRequest) (*proto.ScannerStatus, error) {
+	return s.setScannerPaused(ctx, false)
+}
+
+func (s *PaymentHostAdminServer) setScannerPaused(ctx context.Context, paused bool) (*proto.ScannerStatus, error) {
+	scannerStatus, err := s.entityService.SetScannerPaused(ctx, paused)
+	if err != nil {
+		return nil, err
+	}
+
+	return toProtoScannerStatus(scannerStatus), nil
+}
+
+func (s *PaymentHostAdminServer) SpeedUpTransaction(ctx context.Context, req *proto.SpeedUpTransactionRequest) (*proto.Transaction, error) {
+	id, err := uuid.Parse(req.Id)
+	if err != nil {
+		return nil, invalidArgument("Id", "invalid transaction id")
+	}
+
+	tx, err := s.entityService.SpeedUpTransaction(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return toProtoTransaction(tx), nil
+}
+
+func (s *PaymentHostAdminServer) CancelTransaction(ctx context.Context, req *proto.CancelTransactionRequest) (*proto.Transaction, error) {
+	id, err := uuid.Parse(req.Id)
+	if err != nil {
+		return nil, invalidArgument("Id", "invalid transaction id")
+	}
+
+	tx, err := s.entityService.CancelTransaction(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return toProtoTransaction(tx), nil
+}
+
+func (s *PaymentHostAdminServer) ListSuspenseTransactions(ctx context.Context, req *proto.ListSuspenseTransactionsRequest) (*proto.ListTransactionsResponse, error) {
+	if req.PageSize < 0 {
+		return nil, invalidArgument("PageSize", "page size must not be negative")
+	}
+
+	txs, nextCursor, err := s.entityService.ListSuspenseTransactions(ctx, req.Cursor, int(req.PageSize))
+	if err != nil {
+		return nil, err
+	}
+
+	rs := make([]*proto.Transaction, 0, len(txs))
+	for _, tx := range txs {
+		rs = append(rs, toProtoTransaction(tx))
+	}
+
+	return &proto.ListTransactionsResponse{
+		Transactions: rs,
+		NextCursor:   nextCursor,
+	}, nil
+}
+
+func (s *PaymentHostAdminServer) AssignSuspenseTransaction(ctx context.Context, req *proto.AssignSuspenseTransactionRequest) (*proto.Transaction, error) {
+	id, err := uuid.Parse(req.Id)
+	if err != nil {
+		return nil, invalidArgument("Id", "invalid transaction id")
+	}
+
+	if req.EntityName == "" {
+		return nil, invalidArgument("EntityName", "entity name is required")
+	}
+
+	tx, err := s.entityService.AssignSuspenseTransaction(ctx, id, req.EntityName)
+	if err != nil {
+		return nil, err
+	}
+
+	return toProtoTransaction(tx), nil
+}
+
+func (s *PaymentHostAdminServer) ListWithdrawalRequests(ctx context.Context, req *proto.ListWithdrawalRequestsRequest) (*proto.ListWithdrawalRequestsResponse, error) {
+	if req.PageSize < 0 {
+		return nil, invalidArgument("PageSize", "page size must not be negative")
+	}
+
+	requests, err := s.entityService.ListWithdrawalRequests(ctx, req.Status, int(req.PageSize))
+	if err != nil {
+		return nil, err
+	}
+
+	rs := make([]*proto.WithdrawalRequest, 0, len(requests))
+	for _, request := range requests {
+		rs = append(rs, toProtoWithdrawalRequest(request))
+	}
+
+	return &proto.ListWithdrawalRequestsResponse{
+		WithdrawalRequests: rs,
+	}, nil
+}
+
+func (s *PaymentHostAdminServer) ApproveWithdrawal(ctx context.Context, req *proto.ApproveWithdrawalRequest) (*proto.WithdrawalRequest, error) {
+	id, err := uuid.Parse(req.Id)
+	if err != nil {
+		return nil, invalidArgument("Id", "invalid withdrawal request id")
+	}
+
+	approver, err := approverFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	request, err := s.entityService.ApproveWithdrawal(ctx, id, approver)
+	if err != nil {
+		return nil, err
+	}
+
+	return toProtoWithdrawalRequest(request), nil
+}
+
+func (s *PaymentHostAdminServer) RejectWithdrawal(ctx context.Context, req *proto.RejectWithdrawalRequest) (*proto.WithdrawalRequest, error) {
+	id, err := uuid.Parse(req.Id)
+	if err != nil {
+		return nil, invalidArgument("Id", "invalid withdrawal request id")
+	}
+
+	approver, err := approverFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	request, err := s.entityService.RejectWithdrawal(ctx, id, approver, req.Reason)
+	if err != nil {
+		return nil, err
+	}
+
+	return toProtoWithdrawalRequest(request), nil
+}
+
+func (s *PaymentHostAdminServer) SetWithdrawalPolicy(ctx context.Context, req *proto.SetWithdrawalPolicyRequest) (*proto.WithdrawalPolicy, error) {
+	if req.Denom == "" {
+		return nil, invalidArgument("Denom", "denom is required")
+	}
+
+	params := &models.WithdrawalPolicyParams{
+		EntityName:        req.EntityName,
+		Denom:             req.Denom,
+		RequiredApprovals: int(req.RequiredApprovals),
+		MaxDailyCount:     int(req.MaxDailyCount),
+		AllowlistEnabled:  req.AllowlistEnabled,
+		AllowlistCooldown: req.AllowlistCooldownSeconds,
+	}
+	for _, field := range []struct {
+		name   string
+		value  string
+		amount *decimal.Decimal
+	}{
+		{"ApprovalThreshold", req.ApprovalThreshold, &params.ApprovalThreshold},
+		{"MaxPerTx", req.MaxPerTx, &params.MaxPerTx},
+		{"DailyCap", req.DailyCap, &params.DailyCap},
+		{"WeeklyCap", req.WeeklyCap, &params.WeeklyCap},
+	} {
+		amount, err := parsePolicyAmount(field.value)
+		if err != nil {
+			return nil, invalidArgument(field.name, "invalid amount")
+		}
+
+		*field.amount = amount
+	}
+
+	if req.RequiredApprovals < 0 {
+		return nil, invalidArgument("RequiredApprovals", "required approvals must not be negative")
+	}
+
+	if req.MaxDailyCount < 0 {
+		return nil, invalidArgument("MaxDailyCount", "max daily count must not be negative")
+	}
+
+	if req.AllowlistCooldownSeconds < 0 {
+		return nil, invalidArgument("AllowlistCooldownSeconds", "cool-down must not be negative")
+	}
+
+	policy, err := s.entityService.SetWithdrawalPolicy(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	return toProtoWithdrawalPolicy(policy), nil
+}
+
+// parsePolicyAmount parses an optional, non-negative policy amount.
+func parsePolicyAmount(value string) (decimal.Decimal, error) {
+	if value == "" {
+		return decimal.Zero, nil
+	}
+
+	amount, err := decimal.NewFromString(value)
+	if err != nil {
+		return decimal.Zero, err
+	}
+
+	if amount.IsNegative() {
+		return decimal.Zero, fmt.Errorf("negative amount")
+	}
+
+	return amount, nil
+}
+
+// approverFromContext names the caller deciding on a withdrawal. Decisions
+// are only meaningful with authentication, which tells approvers apart.
+func approverFromContext(ctx context.Context) (string, error) {
+	principal, ok := principalFromContext(ctx)
+	if !ok {
+		return "", status.Newf(codes.FailedPrecondition, "withdrawal approvals require authentication").Err()
+	}
+
+	return principal.Name, nil
+}
+
+func toProtoWithdrawalPolicy(policy *models.WithdrawalPolicy) *proto.WithdrawalPolicy {
+	var entityId string
+	if policy.EntityId != uuid.Nil {
+		entityId = policy.EntityId.String()
+	}
+
+	return &proto.WithdrawalPolicy{
+		EntityId:                 entityId,
+		ContractAddress:          policy.ContractAddress,
+		ApprovalThreshold:        policy.ApprovalThreshold.String(),
+		RequiredApprovals:        int32(policy.RequiredApprovals),
+		UpdatedAt:                policy.UpdatedAt,
+		MaxPerTx:                 policy.MaxPerTx.String(),
+		DailyCap:                 policy.DailyCap.String(),
+		WeeklyCap:                policy.WeeklyCap.String(),
+		MaxDailyCount:            int32(policy.MaxDailyCount),
+		AllowlistEnabled:         policy.AllowlistEnabled,
+		AllowlistCooldownSeconds: policy.AllowlistCooldown,
+	}
+}
+
+func toProtoScannerStatus(scannerStatus *models.ScannerStatus) *proto.ScannerStatus {
+	return &proto.ScannerStatus{
+		ChainId:           scannerStatus.ChainId,
+		BlockNumber:       scannerStatus.BlockNumber,
+		LatestBlockNumber: scannerStatus.LatestBlockNumber,
+		Lag:               scannerStatus.Lag,
+		Paused:            scannerStatus.Paused,
+	}
+}
@@ -2,35 +2,70 @@ package server
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"net"
 
-	"github.com/ethereum/go-ethereum/common"
+	"github.com/google/uuid"
 	"github.com/shopspring/decimal"
+	"github.com/vangxitrum/payment-host/internal/models"
 	proto "github.com/vangxitrum/payment-host/internal/proto/payment_host"
 	"github.com/vangxitrum/payment-host/internal/services"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/health"
 	healthpb "google.golang.org/grpc/health/grpc_health_v1"
-	"google.golang.org/grpc/status"
 )
 
 func MustMakeGrpcPaymentHostServerAndRun(
 	listenAddr string,
 	entityService services.EntityService,
+	idempotencyRepo models.IdempotencyRepository,
+	apiKeyRepo models.ApiKeyRepository,
+	authConfig *AuthConfig,
 ) {
+	if !authConfig.Enabled && !authConfig.AllowUnauthenticated {
+		log.Fatal("authentication is disabled and unauthenticated access is not allowed")
+	}
+
 	grpcSourceControlServer := newPaymentHostServer(entityService)
 	listener, err := net.Listen("tcp", listenAddr)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	option := []grpc.ServerOption{}
+	unaryInterceptors := []grpc.UnaryServerInterceptor{errorUnaryInterceptor()}
+	streamInterceptors := []grpc.StreamServerInterceptor{errorStreamInterceptor()}
+	if authConfig.Enabled {
+		auth, err := newAuthenticator(authConfig, apiKeyRepo)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		unaryInterceptors = append(unaryInterceptors, auth.unaryInterceptor())
+		streamInterceptors = append(streamInterceptors, auth.streamInterceptor())
+	}
+
+	unaryInterceptors = append(unaryInterceptors, idempotencyUnaryInterceptor(idempotencyRepo))
+	option := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(unaryInterceptors...),
+		grpc.ChainStreamInterceptor(streamInterceptors...),
+	}
+
+	tlsConfig, err := serverTLSConfig(authConfig)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if tlsConfig != nil {
+		option = append(option, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+
 	grpcServer := grpc.NewServer(option...)
 	healthServer := health.NewServer()
 	healthServer.SetServingStatus(proto.PaymentHostService_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
 	proto.RegisterPaymentHostServiceServer(grpcServer, grpcSourceControlServer)
+	proto.RegisterPaymentHostAdminServiceServer(grpcServer, newPaymentHostAdminServer(entityService))
 	healthpb.RegisterHealthServer(grpcServer, healthServer)
 	if err := grpcServer.Serve(listener); err != nil {
 		log.Fatal(err)
@@ -50,7 +85,7 @@ func newPaymentHostServer(entityService services.EntityService) *PaymentHostServ
 
 func (s *PaymentHostServer) Register(ctx context.Context, req *proto.RegisterRequest) (*proto.RegisterResponse, error) {
 	if req.Name == "" {
-		return nil, status.Newf(codes.InvalidArgument, "name is required").Err()
+		return nil, invalidArgument("Name", "name is required")
 	}
 
 	entity, err := s.entityService.Register(ctx, req.Name)
@@ -63,23 +98,457 @@ func (s *PaymentHostServer) Register(ctx context.Context, req *proto.RegisterReq
 	}, nil
 }
 
-func (s *PaymentHostServer) Deposit(ctx context.Context, req *proto.WithdrawRequest) (*proto.WithdrawResponse, error) {
-	if req.ReceiverWalletAddress == "" {
-		return nil, status.Newf(codes.InvalidArgument, "wallet address is required").Err()
+func (s *PaymentHostServer) CreateApiKey(ctx context.Context, req *proto.CreateApiKeyRequest) (*proto.CreateApiKeyResponse, error) {
+	if req.EntityName == "" {
+		return nil, invalidArgument("EntityName", "entity name is required")
+	}
+
+	apiKey, key, err := s.entityService.CreateApiKey(ctx, req.EntityName)
+	if err != nil {
+		return nil, err
+	}
+
+	return &proto.CreateApiKeyResponse{
+		Id:     apiKey.Id.String(),
+		ApiKey: key,
+	}, nil
+}
+
+func (s *PaymentHostServer) RevokeApiKey(ctx context.Context, req *proto.RevokeApiKeyRequest) (*proto.RevokeApiKeyResponse, error) {
+	id, err := uuid.Parse(req.Id)
+	if err != nil {
+		return nil, invalidArgument("Id", "invalid api key id")
+	}
+
+	if err := s.entityService.RevokeApiKey(ctx, id); err != nil {
+		return nil, err
+	}
+
+	return &proto.RevokeApiKeyResponse{}, nil
+}
+
+func (s *PaymentHostServer) Withdraw(ctx context.Context, req *proto.WithdrawRequest) (*proto.WithdrawResponse, error) {
+	var (
+		params *models.WithdrawParams
+		err    error
+	)
+	if req.QuoteId != "" {
+		params, err = quotedWithdrawParams(req)
+	} else {
+		params, err = withdrawParams(req.EntityName, req.ReceiverWalletAddress, req.Amount, req.Denom, req.Max)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if principal, ok := principalFromContext(ctx); ok {
+		params.RequestedBy = principal.Name
+	}
+
+	request, err := s.entityService.Withdraw(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	rs := &proto.WithdrawResponse{
+		WithdrawalRequestId: request.Id.String(),
+		Status:              request.Status,
+	}
+	if tx := request.Transaction; tx != nil {
+		rs.TransactionHash = tx.EvmHash
+		if rs.TransactionHash == "" {
+			rs.TransactionHash = tx.CosmosHash
+		}
+		rs.Amount = tx.Amount.String()
+		rs.Denom = tx.Denom
+		rs.Fee = tx.Fee.String()
+	}
+
+	return rs, nil
+}
+
+func (s *PaymentHostServer) QuoteWithdraw(ctx context.Context, req *proto.QuoteWithdrawRequest) (*proto.WithdrawalQuote, error) {
+	params, err := withdrawParams(req.EntityName, req.ReceiverWalletAddress, req.Amount, req.Denom, req.Max)
+	if err != nil {
+		return nil, err
+	}
+
+	quote, err := s.entityService.QuoteWithdraw(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	return toProtoWithdrawalQuote(quote), nil
+}
+
+func withdrawParams(entityName, receiverAddress, amountValue, denom string, max bool) (*models.WithdrawParams, error) {
+	if entityName == "" {
+		return nil, invalidArgument("EntityName", "entity name is required")
+	}
+
+	if receiverAddress == "" {
+		return nil, invalidArgument("ReceiverWalletAddress", "wallet address is required")
+	}
+
+	amount := decimal.Zero
+	if max {
+		if amountValue != "" {
+			return nil, invalidArgument("Amount", "amount must be empty when withdrawing the maximum")
+		}
+	} else {
+		var err error
+		amount, err = decimal.NewFromString(amountValue)
+		if err != nil {
+			return nil, invalidArgument("Amount", "invalid amount")
+		}
+
+		if !amount.IsPositive() {
+			return nil, invalidArgument("Amount", "amount must be greater than 0")
+		}
+	}
+
+	if denom == "" {
+		return nil, invalidArgument("Denom", "denom is required")
+	}
+
+	return &models.WithdrawParams{
+		EntityName:      entityName,
+		Amount:          amount,
+		Denom:           denom,
+		ReceiverAddress: receiverAddress,
+		Max:             max,
+	}, nil
+}
+
+func quotedWithdrawParams(req *proto.WithdrawRequest) (*models.WithdrawParams, error) {
+	if req.EntityName == "" {
+		return nil, invalidArgument("EntityName", "entity name is required")
+	}
+
+	quoteId, err := uuid.Parse(req.QuoteId)
+	if err != nil {
+		return nil, invalidArgument("QuoteId", "invalid quote id")
+	}
+
+	if req.ReceiverWalletAddress != "" || req.Amount != "" || req.Denom != "" || req.Max {
+		return nil, invalidArgument("QuoteId", "the transfer is taken from the quote, leave its fields empty")
+	}
+
+	return &models.WithdrawParams{
+		EntityName: req.EntityName,
+		QuoteId:    quoteId,
+	}, nil
+}
+
+func (s *PaymentHostServer) GetWithdrawalRequest(ctx context.Context, req *proto.GetWithdrawalRequestRequest) (*proto.WithdrawalRequest, error) {
+	if req.EntityName == "" {
+		return nil, invalidArgument("EntityName", "entity name is required")
+	}
+
+	id, err := uuid.Parse(req.Id)
+	if err != nil {
+		return nil, invalidArgument("Id", "invalid withdrawal request id")
+	}
+
+	request, err := s.entityService.GetWithdrawalRequest(ctx, req.EntityName, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return toProtoWithdrawalRequest(request), nil
+}
+
+func (s *PaymentHostServer) BatchWithdraw(ctx context.Context, req *proto.BatchWithdrawRequest) (*proto.WithdrawalBatch, error) {
+	if req.EntityName == "" {
+		return nil, invalidArgument("EntityName", "entity name is required")
+	}
+
+	items := make([]*models.BatchWithdrawItem, 0, len(req.Items))
+	for i, item := range req.Items {
+		if item.ReceiverWalletAddress == "" {
+			return nil, invalidArgument(fmt.Sprintf("Items[%d].ReceiverWalletAddress", i), "wallet address is required")
+		}
+
+		amount, err := decimal.NewFromString(item.Amount)
+		if err != nil {
+			return nil, invalidArgument(fmt.Sprintf("Items[%d].Amount", i), "invalid amount")
+		}
+
+		if !amount.IsPositive() {
+			return nil, invalidArgument(fmt.Sprintf("Items[%d].Amount", i), "amount must be greater than 0")
+		}
+
+		if item.Denom == "" {
+			return nil, invalidArgument(fmt.Sprintf("Items[%d].Denom", i), "denom is required")
+		}
+
+		items = append(items, &models.BatchWithdrawItem{
+			ReceiverAddress: item.ReceiverWalletAddress,
+			Amount:          amount,
+			Denom:           item.Denom,
+		})
+	}
+
+	var requestedBy string
+	if principal, ok := principalFromContext(ctx); ok {
+		requestedBy = principal.Name
+	}
+
+	batch, err := s.entityService.BatchWithdraw(ctx, &models.BatchWithdrawParams{
+		EntityName:  req.EntityName,
+		RequestedBy: requestedBy,
+		Items:       items,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return toProtoWithdrawalBatch(batch), nil
+}
+
+func (s *PaymentHostServer) GetWithdrawalBatch(ctx context.Context, req *proto.GetWithdrawalBatchRequest) (*proto.WithdrawalBatch, error) {
+	if req.EntityName == "" {
+		return nil, invalidArgument("EntityName", "entity name is required")
+	}
+
+	id, err := uuid.Parse(req.Id)
+	if err != nil {
+		return nil, invalidArgument("Id", "invalid withdrawal batch id")
+	}
+
+	batch, err := s.entityService.GetWithdrawalBatch(ctx, req.EntityName, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return toProtoWithdrawalBatch(batch), nil
+}
+
+func (s *PaymentHostServer) AddAllowedDestination(ctx context.Context, req *proto.AddAllowedDestinationRequest) (*proto.AllowedDestination, error) {
+	if req.EntityName == "" {
+		return nil, invalidArgument("EntityName", "entity name is required")
+	}
+
+	if req.Address == "" {
+		return nil, invalidArgument("Address", "address is required")
+	}
+
+	destination, err := s.entityService.AddAllowedDestination(ctx, req.EntityName, req.Address, req.Label)
+	if err != nil {
+		return nil, err
+	}
+
+	return toProtoAllowedDestination(destination), nil
+}
+
+func (s *PaymentHostServer) RemoveAllowedDestination(ctx context.Context, req *proto.RemoveAllowedDestinationRequest) (*proto.RemoveAllowedDestinationResponse, error) {
+	if req.EntityName == "" {
+		return nil, invalidArgument("EntityName", "entity name is required")
+	}
+
+	if err := s.entityService.RemoveAllowedDestination(ctx, req.EntityName, req.Address); err != nil {
+		return nil, err
+	}
+
+	return &proto.RemoveAllowedDestinationResponse{}, nil
+}
+
+func (s *PaymentHostServer) ListAllowedDestinations(ctx context.Context, req *proto.ListAllowedDestinationsRequest) (*proto.ListAllowedDestinationsResponse, error) {
+	if req.EntityName == "" {
+		return nil, invalidArgument("EntityName", "entity name is required")
+	}
+
+	destinations, err := s.entityService.ListAllowedDestinations(ctx, req.EntityName)
+	if err != nil {
+		return nil, err
+	}
+
+	rs := make([]*proto.AllowedDestination, 0, len(destinations))
+	for _, destination := range destinations {
+		rs = append(rs, toProtoAllowedDestination(destination))
+	}
+
+	return &proto.ListAllowedDestinationsResponse{
+		AllowedDestinations: rs,
+	}, nil
+}
+
+func (s *PaymentHostServer) CreateInvoice(ctx context.Context, req *proto.CreateInvoiceRequest) (*proto.Invoice, error) {
+	if req.EntityName == "" {
+		return nil, invalidArgument("EntityName", "entity name is required")
+	}
+
+	amount, err := decimal.NewFromString(req.Amount)
+	if err != nil {
+		return nil, invalidArgument("Amount", "invalid amount")
+	}
+
+	if !amount.IsPositive() {
+		return nil, invalidArgument("Amount", "amount must be greater than 0")
+	}
+
+	if req.Denom == "" {
+		return nil, invalidArgument("Denom", "denom is required")
+	}
+
+	invoice, err := s.entityService.CreateInvoice(ctx, &models.InvoiceParams{
+		EntityName: req.EntityName,
+		Amount:     amount,
+		Denom:      req.Denom,
+		ExpiresAt:  req.ExpiresAt,
+		PayByMemo:  req.PayByMemo,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return toProtoInvoice(invoice), nil
+}
+
+func (s *PaymentHostServer) GetInvoice(ctx context.Context, req *proto.GetInvoiceRequest) (*proto.Invoice, error) {
+	if req.EntityName == "" {
+		return nil, invalidArgument("EntityName", "entity name is required")
+	}
+
+	id, err := uuid.Parse(req.Id)
+	if err != nil {
+		return nil, invalidArgument("Id", "invalid invoice id")
+	}
+
+	invoice, err := s.entityService.GetInvoice(ctx, req.EntityName, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return toProtoInvoice(invoice), nil
+}
+
+func (s *PaymentHostServer) CancelInvoice(ctx context.Context, req *proto.CancelInvoiceRequest) (*proto.Invoice, error) {
+	if req.EntityName == "" {
+		return nil, invalidArgument("EntityName", "entity name is required")
+	}
+
+	id, err := uuid.Parse(req.Id)
+	if err != nil {
+		return nil, invalidArgument("Id", "invalid invoice id")
+	}
+
+	invoice, err := s.entityService.CancelInvoice(ctx, req.EntityName, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return toProtoInvoice(invoice), nil
+}
+
+func (s *PaymentHostServer) GetBalance(ctx context.Context, req *proto.GetBalanceRequest) (*proto.GetBalanceResponse, error) {
+	if req.EntityName == "" {
+		return nil, invalidArgument("EntityName", "entity name is required")
+	}
+
+	balance, err := s.entityService.GetBalance(ctx, req.EntityName)
+	if err != nil {
+		return nil, err
+	}
+
+	tokenBalances := make([]*proto.TokenBalance, 0, len(balance.Tokens))
+	for _, token := range balance.Tokens {
+		tokenBalances = append(tokenBalances, &proto.TokenBalance{
+			ContractAddress: token.ContractAddress,
+			Balance:         token.Amount.String(),
+		})
+	}
+
+	availableTokenBalances := make([]*proto.TokenBalance, 0, len(balance.AvailableTokens))
+	for _, token := range balance.AvailableTokens {
+		availableTokenBalances = append(availableTokenBalances, &proto.TokenBalance{
+			ContractAddress: token.ContractAddress,
+			Balance:         token.Amount.String(),
+		})
+	}
+
+	return &proto.GetBalanceResponse{
+		WalletAddress:          balance.Address,
+		NativeBalance:          balance.Native.String(),
+		TokenBalances:          tokenBalances,
+		Balance:                balance.Balance.String(),
+		Debt:                   balance.Debt.String(),
+		FreeBalance:            balance.FreeBalance.String(),
+		AvailableNativeBalance: balance.AvailableNative.String(),
+		AvailableTokenBalances: availableTokenBalances,
+	}, nil
+}
+
+func (s *PaymentHostServer) ListTransactions(ctx context.Context, req *proto.ListTransactionsRequest) (*proto.ListTransactionsResponse, error) {
+	switch req.Type {
+	case "",
+		models.CONTRACT_IN_TYPE,
+		models.CONTRACT_OUT_TYPE,
+		models.CONTRACT_SWEEP_TYPE,
+		models.CONTRACT_GAS_TOPUP_TYPE,
+		models.CONTRACT_REBALANCE_TYPE:
+	default:
+		return nil, invalidArgument(
+			"Type",
+			"type must be %q, %q, %q, %q or %q",
+			models.CONTRACT_IN_TYPE,
+			models.CONTRACT_OUT_TYPE,
+			models.CONTRACT_SWEEP_TYPE,
+			models.CONTRACT_GAS_TOPUP_TYPE,
+			models.CONTRACT_REBALANCE_TYPE,
+		)
+	}
+
+	if req.ToBlock > 0 && req.FromBlock > req.ToBlock {
+		return nil, invalidArgument("FromBlock", "from block must not be greater than to block")
+	}
+
+	if req.ToTime > 0 && req.FromTime > req.ToTime {
+		return nil, invalidArgument("FromTime", "from time must not be greater than to time")
 	}
 
-	if req.Amount <= 0 {
-		return nil, status.Newf(codes.InvalidArgument, "amount must be greater than 0").Err()
+	if req.PageSize < 0 {
+		return nil, invalidArgument("PageSize", "page size must not be negative")
 	}
 
-	amount := decimal.NewFromInt(req.Amount)
-	receiverAddr := common.HexToAddress(req.ReceiverWalletAddress)
-	txHash, err := s.entityService.Withdraw(ctx, req.EntityName, amount, receiverAddr)
+	filter := models.TransactionFilter{
+		Type:            req.Type,
+		Status:          req.Status,
+		Denom:           req.Denom,
+		ContractAddress: req.ContractAddress,
+		FromBlock:       req.FromBlock,
+		ToBlock:         req.ToBlock,
+		FromTime:        req.FromTime,
+		ToTime:          req.ToTime,
+	}
+
+	txs, nextCursor, err := s.entityService.ListTransactions(ctx, req.EntityName, filter, req.Cursor, int(req.PageSize))
 	if err != nil {
 		return nil, err
 	}
 
-	return &proto.WithdrawResponse{
-		TransactionHash: txHash,
+	rs := make([]*proto.Transaction, 0, len(txs))
+	for _, tx := range txs {
+		rs = append(rs, toProtoTransaction(tx))
+	}
+
+	return &proto.ListTransactionsResponse{
+		Transactions: rs,
+		NextCursor:   nextCursor,
 	}, nil
 }
+
+func (s *PaymentHostServer) SubscribeDeposits(req *proto.SubscribeDepositsRequest, stream proto.PaymentHostService_SubscribeDepositsServer) error {
+	return s.entityService.SubscribeDeposits(
+		stream.Context(),
+		req.EntityName,
+		req.ContractAddress,
+		req.Cursor,
+		func(tx *models.Transaction) error {
+			return stream.Send(&proto.DepositEvent{
+				Transaction: toProtoTransaction(tx),
+				Cursor:      models.EncodeTransactionCursor(tx.Seq),
+			})
+		},
+	)
+}
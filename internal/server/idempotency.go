@@ -0,0 +1,190 @@
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log"
+
+	spb "google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	protobuf "google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+	"gorm.io/gorm"
+
+	"github.com/vangxitrum/payment-host/internal/models"
+)
+
+const idempotencyKeyField = "IdempotencyKey"
+
+// releasableErrorKinds are the domain errors a request fails with before it
+// sends anything.
+var releasableErrorKinds = map[string]bool{
+	models.ERROR_KIND_INVALID_ARGUMENT:    true,
+	models.ERROR_KIND_NOT_FOUND:           true,
+	models.ERROR_KIND_FAILED_PRECONDITION: true,
+	models.ERROR_KIND_RESOURCE_EXHAUSTED:  true,
+	models.ERROR_KIND_PERMISSION_DENIED:   true,
+}
+
+type idempotentRequest interface {
+	protobuf.Message
+	GetIdempotencyKey() string
+}
+
+// idempotencyUnaryInterceptor replays the stored response of a request that
+// carries an idempotency key which was already used for the same method and
+// payload. Keys are scoped to the authenticated caller. The key is released
+// again when the handler fails before anything was sent, so such a request can
+// be retried with the same key. Any other failure is stored and replayed like
+// a response, the request may have moved funds.
+func idempotencyUnaryInterceptor(repo models.IdempotencyRepository) grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		idempotentReq, ok := req.(idempotentRequest)
+		if !ok || idempotentReq.GetIdempotencyKey() == "" {
+			return handler(ctx, req)
+		}
+
+		key := idempotentReq.GetIdempotencyKey()
+		scope := info.FullMethod
+		if principal, ok := principalFromContext(ctx); ok {
+			scope = principal.Name + info.FullMethod
+		}
+		fingerprint, err := fingerprintRequest(idempotentReq)
+		if err != nil {
+			return nil, status.Newf(codes.Internal, "failed to fingerprint request").Err()
+		}
+
+		created, err := repo.CreateIfNotExists(ctx, models.NewIdempotencyRecord(scope, key, fingerprint))
+		if err != nil {
+			return nil, status.Newf(codes.Internal, "failed to store idempotency key").Err()
+		}
+
+		if !created {
+			return replayIdempotentResponse(ctx, repo, scope, key, fingerprint)
+		}
+
+		resp, err := handler(ctx, req)
+		if err != nil {
+			if isReleasableError(err) {
+				if err := repo.DeleteIdempotencyRecord(context.Background(), scope, key); err != nil {
+					log.Println("DeleteIdempotencyRecord error ", err)
+				}
+
+				return nil, err
+			}
+
+			data, marshalErr := protobuf.Marshal(status.Convert(toStatusError(err)).Proto())
+			if marshalErr == nil {
+				marshalErr = repo.FailIdempotencyRecord(context.Background(), scope, key, data)
+			}
+
+			if marshalErr != nil {
+				log.Println("FailIdempotencyRecord error ", marshalErr)
+			}
+
+			return nil, err
+		}
+
+		respMsg, ok := resp.(protobuf.Message)
+		if !ok {
+			return resp, nil
+		}
+
+		stored, err := anypb.New(respMsg)
+		if err == nil {
+			var data []byte
+			data, err = protobuf.Marshal(stored)
+			if err == nil {
+				err = repo.CompleteIdempotencyRecord(context.Background(), scope, key, data)
+			}
+		}
+
+		if err != nil {
+			log.Println("CompleteIdempotencyRecord error ", err)
+		}
+
+		return resp, nil
+	}
+}
+
+func replayIdempotentResponse(
+	ctx context.Context,
+	repo models.IdempotencyRepository,
+	scope, key, fingerprint string,
+) (interface{}, error) {
+	record, err := repo.GetIdempotencyRecord(ctx, scope, key)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, status.Newf(codes.Aborted, "idempotency key was released by a failed request, retry it").Err()
+		}
+
+		return nil, status.Newf(codes.Internal, "failed to get idempotency key").Err()
+	}
+
+	if record.Fingerprint != fingerprint {
+		return nil, status.Newf(codes.InvalidArgument, "idempotency key was already used with a different request").Err()
+	}
+
+	if record.Status == models.IDEMPOTENCY_STATUS_FAILED {
+		var stored spb.Status
+		if err := protobuf.Unmarshal(record.Response, &stored); err != nil {
+			return nil, status.Newf(codes.Internal, "failed to read stored response").Err()
+		}
+
+		return nil, status.FromProto(&stored).Err()
+	}
+
+	if record.Status != models.IDEMPOTENCY_STATUS_COMPLETED {
+		return nil, status.Newf(codes.Aborted, "request with this idempotency key is still in progress").Err()
+	}
+
+	var stored anypb.Any
+	if err := protobuf.Unmarshal(record.Response, &stored); err != nil {
+		return nil, status.Newf(codes.Internal, "failed to read stored response").Err()
+	}
+
+	resp, err := stored.UnmarshalNew()
+	if err != nil {
+		return nil, status.Newf(codes.Internal, "failed to read stored response").Err()
+	}
+
+	return resp, nil
+}
+
+// isReleasableError reports whether err rejected a request before it could
+// send anything, which leaves nothing to replay.
+func isReleasableError(err error) bool {
+	var domainErr *models.Error
+	if errors.As(err, &domainErr) {
+		return releasableErrorKinds[domainErr.Kind]
+	}
+
+	// The API layer validates requests before calling the services.
+	return status.Code(err) == codes.InvalidArgument
+}
+
+// fingerprintRequest hashes the deterministic encoding of req without its
+// idempotency key.
+func fingerprintRequest(req idempotentRequest) (string, error) {
+	msg := protobuf.Clone(req).ProtoReflect()
+	if field := msg.Descriptor().Fields().ByName(idempotencyKeyField); field != nil {
+		msg.Clear(field)
+	}
+
+	data, err := protobuf.MarshalOptions{Deterministic: true}.Marshal(msg.Interface())
+	if err != nil {
+		return "", err
+	}
+
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:]), nil
+}
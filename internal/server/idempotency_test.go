@@ -0,0 +1,130 @@
+package server
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+
+	"github.com/vangxitrum/payment-host/internal/models"
+	proto "github.com/vangxitrum/payment-host/internal/proto/payment_host"
+)
+
+type fakeIdempotencyRepository struct {
+	mu      sync.Mutex
+	records map[string]*models.IdempotencyRecord
+}
+
+func newFakeIdempotencyRepository() *fakeIdempotencyRepository {
+	return &fakeIdempotencyRepository{records: map[string]*models.IdempotencyRecord{}}
+}
+
+func (r *fakeIdempotencyRepository) CreateIfNotExists(_ context.Context, record *models.IdempotencyRecord) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.records[record.Scope+record.Key]; ok {
+		return false, nil
+	}
+
+	stored := *record
+	r.records[record.Scope+record.Key] = &stored
+	return true, nil
+}
+
+func (r *fakeIdempotencyRepository) GetIdempotencyRecord(_ context.Context, scope, key string) (*models.IdempotencyRecord, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	record, ok := r.records[scope+key]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	stored := *record
+	return &stored, nil
+}
+
+func (r *fakeIdempotencyRepository) CompleteIdempotencyRecord(_ context.Context, scope, key string, response []byte) error {
+	return r.update(scope, key, models.IDEMPOTENCY_STATUS_COMPLETED, response)
+}
+
+func (r *fakeIdempotencyRepository) FailIdempotencyRecord(_ context.Context, scope, key string, status []byte) error {
+	return r.update(scope, key, models.IDEMPOTENCY_STATUS_FAILED, status)
+}
+
+func (r *fakeIdempotencyRepository) DeleteIdempotencyRecord(_ context.Context, scope, key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.records, scope+key)
+	return nil
+}
+
+func (r *fakeIdempotencyRepository) update(scope, key, recordStatus string, response []byte) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if record, ok := r.records[scope+key]; ok {
+		record.Status = recordStatus
+		record.Response = response
+	}
+
+	return nil
+}
+
+func TestIdempotencyInterceptor(t *testing.T) {
+	info := &grpc.UnaryServerInfo{FullMethod: proto.PaymentHostService_Withdraw_FullMethodName}
+	req := &proto.WithdrawRequest{EntityName: "alice", Amount: "1", Denom: "aioz", IdempotencyKey: "k"}
+
+	cases := []struct {
+		name string
+		err  error
+		// kept reports whether a retry replays the failure instead of
+		// running the handler again.
+		kept bool
+	}{
+		{"rejected before sending", models.NewInsufficientBalanceError("aioz", decimal.NewFromInt(2), decimal.NewFromInt(1)), false},
+		{"invalid request", invalidArgument("Amount", "invalid amount"), false},
+		{"failed after broadcast", status.Newf(codes.Internal, "failed to send transaction").Err(), true},
+	}
+
+	for _, c := range cases {
+		interceptor := idempotencyUnaryInterceptor(newFakeIdempotencyRepository())
+		calls := 0
+		handler := func(context.Context, interface{}) (interface{}, error) {
+			calls++
+			if calls == 1 {
+				return nil, c.err
+			}
+
+			return &proto.WithdrawResponse{}, nil
+		}
+
+		if _, err := interceptor(context.Background(), req, info, handler); err == nil {
+			t.Fatalf("%s: expected an error", c.name)
+		}
+
+		_, err := interceptor(context.Background(), req, info, handler)
+		if c.kept {
+			if calls != 1 {
+				t.Errorf("%s: handler ran again on retry", c.name)
+			}
+
+			if status.Code(err) != status.Code(c.err) || status.Convert(err).Message() != status.Convert(c.err).Message() {
+				t.Errorf("%s: retry got %v, want the stored %v", c.name, err, c.err)
+			}
+
+			continue
+		}
+
+		if calls != 2 || err != nil {
+			t.Errorf("%s: retry ran the handler %d times with error %v, want a released key", c.name, calls, err)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package server
+
+import (
+	"github.com/google/uuid"
+
+	"github.com/vangxitrum/payment-host/internal/common/aiozcoin"
+	"github.com/vangxitrum/payment-host/internal/models"
+	proto "github.com/vangxitrum/payment-host/internal/proto/payment_host"
+)
+
+func toProtoTransaction(tx *models.Transaction) *proto.Transaction {
+	var replacesId string
+	if tx.ReplacesId != uuid.Nil {
+		replacesId = tx.ReplacesId.String()
+	}
+
+	var sweepId string
+	if tx.SweepId != uuid.Nil {
+		sweepId = tx.SweepId.String()
+	}
+
+	var invoiceId string
+	if tx.InvoiceId != uuid.Nil {
+		invoiceId = tx.InvoiceId.String()
+	}
+
+	return &proto.Transaction{
+		Id:              tx.Id.String(),
+		EntityId:        tx.EntityId.String(),
+		CosmosHash:      tx.CosmosHash,
+		EvmHash:         tx.EvmHash,
+		ContractAddress: tx.ContractAddress,
+		From:            tx.From,
+		To:              tx.To,
+		BlockNumber:     tx.BlockNumber,
+		Type:            tx.Type,
+		Denom:           tx.Denom,
+		Amount:          tx.Amount.String(),
+		Fee:             tx.Fee.String(),
+		Status:          tx.Status,
+		CreatedAt:       tx.CreatedAt,
+		UpdatedAt:       tx.UpdatedAt,
+		Nonce:           tx.Nonce,
+		ReplacesId:      replacesId,
+		SweepId:         sweepId,
+		Memo:            tx.Memo,
+		InvoiceId:       invoiceId,
+	}
+}
+
+func toProtoWithdrawalRequest(request *models.WithdrawalRequest) *proto.WithdrawalRequest {
+	approvals := make([]*proto.WithdrawalApproval, 0, len(request.Approvals))
+	for _, approval := range request.Approvals {
+		approvals = append(approvals, &proto.WithdrawalApproval{
+			Approver:  approval.Approver,
+			Decision:  approval.Decision,
+			Reason:    approval.Reason,
+			CreatedAt: approval.CreatedAt,
+		})
+	}
+
+	rs := &proto.WithdrawalRequest{
+		Id:                request.Id.String(),
+		EntityName:        request.EntityName,
+		ReceiverAddress:   request.ReceiverAddress,
+		Amount:            request.Amount.String(),
+		Denom:             request.Denom,
+		ContractAddress:   request.ContractAddress,
+		Max:               request.Max,
+		Status:            request.Status,
+		RequiredApprovals: int32(request.RequiredApprovals),
+		RequestedBy:       request.RequestedBy,
+		Approvals:         approvals,
+		FailureReason:     request.FailureReason,
+		CreatedAt:         request.CreatedAt,
+		UpdatedAt:         request.UpdatedAt,
+	}
+	if request.Transaction != nil {
+		rs.Transaction = toProtoTransaction(request.Transaction)
+	}
+
+	return rs
+}
+
+func toProtoWithdrawalQuote(quote *models.WithdrawalQuote) *proto.WithdrawalQuote {
+	denom := quote.ContractAddress
+	if denom == models.AIOZ_CONTRACT_ADDRESS {
+		denom = aiozcoin.DefaultDenom
+	}
+
+	return &proto.WithdrawalQuote{
+		Id:                    quote.Id.String(),
+		EntityName:            quote.EntityName,
+		ReceiverWalletAddress: quote.ReceiverAddress,
+		Amount:                quote.Amount.String(),
+		Denom:                 denom,
+		Fee:                   quote.Fee.String(),
+		GasLimit:              quote.GasLimit,
+		MaxFeePerGas:          quote.MaxFeePerGas.String(),
+		RequiredApprovals:     int32(quote.RequiredApprovals),
+		ExpiresAt:             quote.ExpiresAt,
+	}
+}
+
+func toProtoWithdrawalBatch(batch *models.WithdrawalBatch) *proto.WithdrawalBatch {
+	items := make([]*proto.WithdrawalBatchItem, 0, len(batch.Items))
+	for _, item := range batch.Items {
+		rs := &proto.WithdrawalBatchItem{
+			Id:                    item.Id.String(),
+			ReceiverWalletAddress: item.ReceiverAddress,
+			Amount:                item.Amount.String(),
+			Denom:                 item.Denom,
+			Status:                item.Status,
+			Error:                 item.Error,
+		}
+		if item.WithdrawalRequest != nil {
+			rs.WithdrawalRequest = toProtoWithdrawalRequest(item.WithdrawalRequest)
+		}
+
+		items = append(items, rs)
+	}
+
+	return &proto.WithdrawalBatch{
+		Id:         batch.Id.String(),
+		EntityName: batch.EntityName,
+		Status:     batch.Status,
+		Items:      items,
+		CreatedAt:  batch.CreatedAt,
+		UpdatedAt:  batch.UpdatedAt,
+	}
+}
+
+func toProtoInvoice(invoice *models.Invoice) *proto.Invoice {
+	denom := invoice.ContractAddress
+	if denom == models.AIOZ_CONTRACT_ADDRESS {
+		denom = aiozcoin.DefaultDenom
+	}
+
+	var memo string
+	if invoice.PayByMemo {
+		memo = invoice.Memo
+	}
+
+	return &proto.Invoice{
+		Id:         invoice.Id.String(),
+		EntityName: invoice.EntityName,
+		Amount:     invoice.Amount.String(),
+		Denom:      denom,
+		Address:    invoice.Address,
+		Memo:       memo,
+		PaidAmount: invoice.PaidAmount.String(),
+		Status:     invoice.Status,
+		ExpiresAt:  invoice.ExpiresAt,
+		CreatedAt:  invoice.CreatedAt,
+		UpdatedAt:  invoice.UpdatedAt,
+	}
+}
+
+func toProtoAllowedDestination(destination *models.AllowedDestination) *proto.AllowedDestination {
+	return &proto.AllowedDestination{
+		Address:   destination.Address,
+		Label:     destination.Label,
+		CreatedAt: destination.CreatedAt,
+	}
+}
@@ -3,13 +3,46 @@ package services
 import (
 	"context"
 
-	"github.com/ethereum/go-ethereum/common"
-	"github.com/shopspring/decimal"
+	"github.com/google/uuid"
+
 	"github.com/vangxitrum/payment-host/internal/models"
 )
 
 type EntityService interface {
 	Register(ctx context.Context, name string) (*models.Entity, error)
-	Withdraw(ctx context.Context, entityName string, amount decimal.Decimal, receiverAddress common.Address) (string,error)
+	CreateApiKey(ctx context.Context, entityName string) (*models.ApiKey, string, error)
+	RevokeApiKey(ctx context.Context, id uuid.UUID) error
+	Withdraw(ctx context.Context, params *models.WithdrawParams) (*models.WithdrawalRequest, error)
+	QuoteWithdraw(ctx context.Context, params *models.WithdrawParams) (*models.WithdrawalQuote, error)
+	ApproveWithdrawal(ctx context.Context, id uuid.UUID, approver string) (*models.WithdrawalRequest, error)
+	RejectWithdrawal(ctx context.Context, id uuid.UUID, approver string, reason string) (*models.WithdrawalRequest, error)
+	GetWithdrawalRequest(ctx context.Context, entityName string, id uuid.UUID) (*models.WithdrawalRequest, error)
+	ListWithdrawalRequests(ctx context.Context, status string, pageSize int) ([]*models.WithdrawalRequest, error)
+	BatchWithdraw(ctx context.Context, params *models.BatchWithdrawParams) (*models.WithdrawalBatch, error)
+	GetWithdrawalBatch(ctx context.Context, entityName string, id uuid.UUID) (*models.WithdrawalBatch, error)
+	ProcessWithdrawalBatches(ctx context.Context) error
+	SetWithdrawalPolicy(ctx context.Context, params *models.WithdrawalPolicyParams) (*models.WithdrawalPolicy, error)
+	AddAllowedDestination(ctx context.Context, entityName, address, label string) (*models.AllowedDestination, error)
+	RemoveAllowedDestination(ctx context.Context, entityName, address string) error
+	ListAllowedDestinations(ctx context.Context, entityName string) ([]*models.AllowedDestination, error)
+	CreateInvoice(ctx context.Context, params *models.InvoiceParams) (*models.Invoice, error)
+	GetInvoice(ctx context.Context, entityName string, id uuid.UUID) (*models.Invoice, error)
+	CancelInvoice(ctx context.Context, entityName string, id uuid.UUID) (*models.Invoice, error)
+	ReconcileNonces(ctx context.Context) error
+	SweepDeposits(ctx context.Context) error
+	RebalanceTreasury(ctx context.Context) error
+	TrackWithdrawals(ctx context.Context) error
+	SpeedUpTransaction(ctx context.Context, id uuid.UUID) (*models.Transaction, error)
+	CancelTransaction(ctx context.Context, id uuid.UUID) (*models.Transaction, error)
+	ListSuspenseTransactions(ctx context.Context, cursor string, pageSize int) ([]*models.Transaction, string, error)
+	AssignSuspenseTransaction(ctx context.Context, id uuid.UUID, entityName string) (*models.Transaction, error)
+	GetBalance(ctx context.Context, entityName string) (*models.WalletBalance, error)
+	ListTransactions(ctx context.Context, entityName string, filter models.TransactionFilter, cursor string, pageSize int) ([]*models.Transaction, string, error)
+	SubscribeDeposits(ctx context.Context, entityName string, contractAddress string, cursor string, handler func(*models.Transaction) error) error
 	WatchTransaction(ctx context.Context) error
+
+	GetScannerStatus(ctx context.Context) (*models.ScannerStatus, error)
+	SetPaymentMark(ctx context.Context, blockNumber int64) (*models.ScannerStatus, error)
+	RescanBlocks(ctx context.Context, fromBlock, toBlock int64) (int, error)
+	SetScannerPaused(ctx context.Context, paused bool) (*models.ScannerStatus, error)
 }
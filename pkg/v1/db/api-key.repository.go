@@ -0,0 +1,65 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/vangxitrum/payment-host/internal/models"
+)
+
+type ApiKeyRepository struct {
+	db *gorm.DB
+}
+
+func MustNewApiKeyRepository(db *gorm.DB, init bool) models.ApiKeyRepository {
+	if init {
+		if err := db.AutoMigrate(&models.ApiKey{}); err != nil {
+			panic(err)
+		}
+	}
+
+	return &ApiKeyRepository{
+		db: db,
+	}
+}
+
+func (r ApiKeyRepository) Create(ctx context.Context, apiKey *models.ApiKey) error {
+	if err := r.db.WithContext(ctx).
+		Omit("Entity").
+		Create(apiKey).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r ApiKeyRepository) GetApiKeyByHash(ctx context.Context, keyHash string) (*models.ApiKey, error) {
+	var rs models.ApiKey
+	if err := r.db.WithContext(ctx).
+		Preload("Entity").
+		Where("key_hash = ?", keyHash).
+		First(&rs).Error; err != nil {
+		return nil, err
+	}
+
+	return &rs, nil
+}
+
+func (r ApiKeyRepository) RevokeApiKeyById(ctx context.Context, id uuid.UUID) error {
+	rs := r.db.WithContext(ctx).
+		Model(models.ApiKey{}).
+		Where("id = ? and revoked_at = 0", id).
+		Update("revoked_at", time.Now().UTC().Unix())
+	if rs.Error != nil {
+		return rs.Error
+	}
+
+	if rs.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
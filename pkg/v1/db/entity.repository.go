@@ -5,6 +5,7 @@ import (
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	"github.com/vangxitrum/payment-host/internal/models"
 )
@@ -37,6 +38,7 @@ func (r EntityRepository) Create(ctx context.Context, entity *models.Entity) err
 func (r EntityRepository) GetEntityById(ctx context.Context, id uuid.UUID) (*models.Entity, error) {
 	var rs models.Entity
 	if err := r.db.WithContext(ctx).
+		Preload("Wallet").
 		Where("id = ?", id).
 		First(&rs).Error; err != nil {
 		return nil, err
@@ -51,6 +53,7 @@ func (r EntityRepository) GetEntityByName(
 ) (*models.Entity, error) {
 	var rs models.Entity
 	if err := r.db.WithContext(ctx).
+		Preload("Wallet").
 		Where("name = ?", name).
 		First(&rs).Error; err != nil {
 		return nil, err
@@ -66,6 +69,7 @@ func (r EntityRepository) GetEntityByWalletAddress(
 	var rs models.Entity
 	if err := r.db.WithContext(ctx).
 		Model(models.Entity{}).
+		Preload("Wallet").
 		Where("wallet_address = ?", walletAddress).
 		First(&rs).Error; err != nil {
 		return nil, err
@@ -74,6 +78,18 @@ func (r EntityRepository) GetEntityByWalletAddress(
 	return &rs, nil
 }
 
+func (r EntityRepository) LockEntity(ctx context.Context, id uuid.UUID) error {
+	var rs models.Entity
+	if err := r.db.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ?", id).
+		First(&rs).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
 func (r EntityRepository) DeleteEntityById(ctx context.Context, id uuid.UUID) error {
 	if err := r.db.WithContext(ctx).
 		Where("id = ?", id).
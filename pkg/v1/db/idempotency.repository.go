@@ -0,0 +1,103 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/vangxitrum/payment-host/internal/models"
+)
+
+type IdempotencyRepository struct {
+	db *gorm.DB
+}
+
+func MustNewIdempotencyRepository(db *gorm.DB, init bool) models.IdempotencyRepository {
+	if init {
+		if err := db.AutoMigrate(&models.IdempotencyRecord{}); err != nil {
+			panic(err)
+		}
+	}
+
+	return &IdempotencyRepository{
+		db: db,
+	}
+}
+
+func (r IdempotencyRepository) CreateIfNotExists(
+	ctx context.Context,
+	record *models.IdempotencyRecord,
+) (bool, error) {
+	rs := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(record)
+	if rs.Error != nil {
+		return false, rs.Error
+	}
+
+	return rs.RowsAffected == 1, nil
+}
+
+func (r IdempotencyRepository) GetIdempotencyRecord(
+	ctx context.Context,
+	scope, key string,
+) (*models.IdempotencyRecord, error) {
+	var rs models.IdempotencyRecord
+	if err := r.db.WithContext(ctx).
+		Where("scope = ? and key = ?", scope, key).
+		First(&rs).Error; err != nil {
+		return nil, err
+	}
+
+	return &rs, nil
+}
+
+func (r IdempotencyRepository) CompleteIdempotencyRecord(
+	ctx context.Context,
+	scope, key string,
+	response []byte,
+) error {
+	if err := r.db.WithContext(ctx).
+		Model(models.IdempotencyRecord{}).
+		Where("scope = ? and key = ?", scope, key).
+		Updates(map[string]interface{}{
+			"status":     models.IDEMPOTENCY_STATUS_COMPLETED,
+			"response":   response,
+			"updated_at": time.Now().UTC().Unix(),
+		}).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r IdempotencyRepository) FailIdempotencyRecord(
+	ctx context.Context,
+	scope, key string,
+	status []byte,
+) error {
+	if err := r.db.WithContext(ctx).
+		Model(models.IdempotencyRecord{}).
+		Where("scope = ? and key = ?", scope, key).
+		Updates(map[string]interface{}{
+			"status":     models.IDEMPOTENCY_STATUS_FAILED,
+			"response":   status,
+			"updated_at": time.Now().UTC().Unix(),
+		}).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r IdempotencyRepository) DeleteIdempotencyRecord(ctx context.Context, scope, key string) error {
+	if err := r.db.WithContext(ctx).
+		Where("scope = ? and key = ?", scope, key).
+		Delete(&models.IdempotencyRecord{}).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,125 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+
+	"github.com/vangxitrum/payment-host/internal/models"
+)
+
+type InvoiceRepository struct {
+	db *gorm.DB
+}
+
+func MustNewInvoiceRepository(db *gorm.DB, init bool) models.InvoiceRepository {
+	if init {
+		if err := db.AutoMigrate(&models.Invoice{}); err != nil {
+			panic(err)
+		}
+	}
+
+	return InvoiceRepository{
+		db: db,
+	}
+}
+
+func (r InvoiceRepository) Create(ctx context.Context, invoice *models.Invoice) error {
+	if err := r.db.WithContext(ctx).Create(invoice).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r InvoiceRepository) GetInvoiceById(ctx context.Context, id uuid.UUID) (*models.Invoice, error) {
+	var rs models.Invoice
+	if err := r.db.WithContext(ctx).
+		Where("id = ?", id).
+		First(&rs).Error; err != nil {
+		return nil, err
+	}
+
+	return &rs, nil
+}
+
+func (r InvoiceRepository) FindOpenInvoice(
+	ctx context.Context,
+	address string,
+	contractAddress string,
+) (*models.Invoice, error) {
+	var rs models.Invoice
+	if err := r.db.WithContext(ctx).
+		Where("address = ? and lower(contract_address) = lower(?) and pay_by_memo = ?", address, contractAddress, false).
+		Where("status in ?", []string{models.INVOICE_STATUS_OPEN, models.INVOICE_STATUS_PARTIALLY_PAID}).
+		Order("created_at asc").
+		First(&rs).Error; err != nil {
+		return nil, err
+	}
+
+	return &rs, nil
+}
+
+func (r InvoiceRepository) ApplyInvoicePayment(
+	ctx context.Context,
+	id uuid.UUID,
+	amount decimal.Decimal,
+	paidAt int64,
+) (bool, error) {
+	// Postgres evaluates every assignment against the row before the update.
+	result := r.db.WithContext(ctx).
+		Model(models.Invoice{}).
+		Where("id = ? and expires_at > ?", id, paidAt).
+		Where("status not in ?", []string{models.INVOICE_STATUS_EXPIRED, models.INVOICE_STATUS_CANCELED}).
+		Updates(map[string]interface{}{
+			"paid_amount": gorm.Expr("paid_amount + ?", amount),
+			"status": gorm.Expr(
+				"case when paid_amount + ? < amount then ? when paid_amount + ? = amount then ? else ? end",
+				amount,
+				models.INVOICE_STATUS_PARTIALLY_PAID,
+				amount,
+				models.INVOICE_STATUS_PAID,
+				models.INVOICE_STATUS_OVERPAID,
+			),
+			"updated_at": time.Now().UTC().Unix(),
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return result.RowsAffected > 0, nil
+}
+
+func (r InvoiceRepository) CancelInvoice(ctx context.Context, id uuid.UUID) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(models.Invoice{}).
+		Where("id = ? and status = ?", id, models.INVOICE_STATUS_OPEN).
+		Updates(map[string]interface{}{
+			"status":     models.INVOICE_STATUS_CANCELED,
+			"updated_at": time.Now().UTC().Unix(),
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return result.RowsAffected > 0, nil
+}
+
+func (r InvoiceRepository) ExpireInvoices(ctx context.Context, now int64) (int64, error) {
+	result := r.db.WithContext(ctx).
+		Model(models.Invoice{}).
+		Where("status in ?", []string{models.INVOICE_STATUS_OPEN, models.INVOICE_STATUS_PARTIALLY_PAID}).
+		Where("expires_at <= ?", now).
+		Updates(map[string]interface{}{
+			"status":     models.INVOICE_STATUS_EXPIRED,
+			"updated_at": time.Now().UTC().Unix(),
+		})
+	if result.Error != nil {
+		return 0, result.Error
+	}
+
+	return result.RowsAffected, nil
+}
@@ -49,6 +49,17 @@ func (r PaymentMarkRepository) UpdatePaymentMarkByChainId(ctx context.Context, c
 	return nil
 }
 
+func (r PaymentMarkRepository) UpdatePaymentMarkPausedByChainId(ctx context.Context, chainId int64, paused bool) error {
+	if err := r.db.WithContext(ctx).
+		Model(models.PaymentMark{}).
+		Where("chain_id = ?", chainId).
+		Update("paused", paused).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
 func (r PaymentMarkRepository) DeletePaymentMarkByChainId(ctx context.Context, chainId int64) error {
 	if err := r.db.WithContext(ctx).
 		Where("chain_id = ?", chainId).
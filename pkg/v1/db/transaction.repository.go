@@ -2,12 +2,19 @@ package db
 
 import (
 	"context"
+	"time"
 
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
 	"gorm.io/gorm"
 
 	"github.com/vangxitrum/payment-host/internal/models"
 )
 
+// depositSequenceLock is the key of the advisory lock serializing the writers
+// of deposits.
+const depositSequenceLock = 7305001
+
 type TransactionRepository struct {
 	db *gorm.DB
 }
@@ -33,6 +40,17 @@ func (r TransactionRepository) Create(ctx context.Context, transaction *models.T
 	return nil
 }
 
+func (r TransactionRepository) GetTransactionById(ctx context.Context, id uuid.UUID) (*models.Transaction, error) {
+	var tx models.Transaction
+	if err := r.db.WithContext(ctx).
+		Where("id = ?", id).
+		First(&tx).Error; err != nil {
+		return nil, err
+	}
+
+	return &tx, nil
+}
+
 func (r TransactionRepository) GetTransactionByHashIndexAndReceiverAddr(
 	ctx context.Context,
 	hash string,
@@ -49,3 +67,303 @@ func (r TransactionRepository) GetTransactionByHashIndexAndReceiverAddr(
 
 	return &tx, nil
 }
+
+func (r TransactionRepository) GetTransactionByEvmHashAndType(
+	ctx context.Context,
+	hash string,
+	txType string,
+) (*models.Transaction, error) {
+	var tx models.Transaction
+	if err := r.db.WithContext(ctx).
+		Model(models.Transaction{}).
+		Where("lower(evm_hash) = lower(?) and type = ?", hash, txType).
+		First(&tx).Error; err != nil {
+		return nil, err
+	}
+
+	return &tx, nil
+}
+
+// ListTransactions returns transactions newest first. A non-zero cursor only
+// returns transactions strictly older than the one it points at, so pages stay
+// stable while new transactions are being recorded.
+func (r TransactionRepository) ListTransactions(
+	ctx context.Context,
+	filter models.TransactionFilter,
+	cursor int64,
+	limit int,
+) ([]*models.Transaction, error) {
+	query := applyTransactionFilter(r.db.WithContext(ctx).Model(models.Transaction{}), filter)
+	if cursor > 0 {
+		query = query.Where("seq < ?", cursor)
+	}
+
+	var rs []*models.Transaction
+	if err := query.
+		Order("seq desc").
+		Limit(limit).
+		Find(&rs).Error; err != nil {
+		return nil, err
+	}
+
+	return rs, nil
+}
+
+// ListTransactionsAfter returns transactions recorded after cursor, oldest
+// first.
+func (r TransactionRepository) ListTransactionsAfter(
+	ctx context.Context,
+	filter models.TransactionFilter,
+	cursor int64,
+	limit int,
+) ([]*models.Transaction, error) {
+	var rs []*models.Transaction
+	if err := applyTransactionFilter(r.db.WithContext(ctx).Model(models.Transaction{}), filter).
+		Where("seq > ?", cursor).
+		Order("seq asc").
+		Limit(limit).
+		Find(&rs).Error; err != nil {
+		return nil, err
+	}
+
+	return rs, nil
+}
+
+func (r TransactionRepository) GetLatestTransactionSeq(ctx context.Context) (int64, error) {
+	var seq int64
+	if err := r.db.WithContext(ctx).
+		Model(models.Transaction{}).
+		Select("coalesce(max(seq), 0)").
+		Scan(&seq).Error; err != nil {
+		return 0, err
+	}
+
+	return seq, nil
+}
+
+func (r TransactionRepository) LockDepositSequence(ctx context.Context) error {
+	if err := r.db.WithContext(ctx).
+		Exec("select pg_advisory_xact_lock(?)", depositSequenceLock).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r TransactionRepository) ListUnsweptDeposits(
+	ctx context.Context,
+	cursor int64,
+	limit int,
+) ([]*models.Transaction, error) {
+	// A deposit linked to a sweep that failed or was replaced is swept again.
+	droppedSweeps := r.db.
+		Model(models.Transaction{}).
+		Select("id").
+		Where("type = ? and status in ?", models.CONTRACT_SWEEP_TYPE, []string{models.TX_STATUS_FAILED, models.TX_STATUS_REPLACED})
+
+	var rs []*models.Transaction
+	if err := r.db.WithContext(ctx).
+		Model(models.Transaction{}).
+		Where("type = ? and entity_id <> ?", models.CONTRACT_IN_TYPE, uuid.Nil).
+		Where("sweep_id is null or sweep_id = ? or sweep_id in (?)", uuid.Nil, droppedSweeps).
+		Where("seq > ?", cursor).
+		Order("seq asc").
+		Limit(limit).
+		Find(&rs).Error; err != nil {
+		return nil, err
+	}
+
+	return rs, nil
+}
+
+func (r TransactionRepository) GetWithdrawalTotals(
+	ctx context.Context,
+	entityId uuid.UUID,
+	contractAddress string,
+	since int64,
+) (decimal.Decimal, int64, error) {
+	var rs struct {
+		Total decimal.Decimal
+		Count int64
+	}
+	// A replacement only counts once it is mined, until then the transaction
+	// it replaces is counted.
+	if err := r.db.WithContext(ctx).
+		Model(models.Transaction{}).
+		Select("coalesce(sum(amount), 0) as total, count(*) as count").
+		Where("entity_id = ? and type = ? and lower(contract_address) = lower(?)", entityId, models.CONTRACT_OUT_TYPE, contractAddress).
+		Where("status in ?", []string{models.TX_STATUS_PENDING, models.TX_STATUS_CONFIRMED}).
+		Where("replaces_id is null or replaces_id = ? or status = ?", uuid.Nil, models.TX_STATUS_CONFIRMED).
+		Where("created_at >= ?", since).
+		Scan(&rs).Error; err != nil {
+		return decimal.Zero, 0, err
+	}
+
+	return rs.Total, rs.Count, nil
+}
+
+func (r TransactionRepository) GetLedgerBalance(
+	ctx context.Context,
+	entityId uuid.UUID,
+	contractAddress string,
+) (decimal.Decimal, error) {
+	var rs struct {
+		Total decimal.Decimal
+	}
+	// Withdrawals are counted like in GetWithdrawalTotals. The fees of token
+	// withdrawals are paid by the wallet sending them, not by the entity. An
+	// AIOZ withdrawal that failed once mined still burned its fee.
+	if err := r.db.WithContext(ctx).
+		Model(models.Transaction{}).
+		Select(
+			"coalesce(sum(case when type = ? then amount when status = ? then -fee when contract_address = ? then -amount - fee else -amount end), 0) as total",
+			models.CONTRACT_IN_TYPE,
+			models.TX_STATUS_FAILED,
+			models.AIOZ_CONTRACT_ADDRESS,
+		).
+		Where("entity_id = ? and lower(contract_address) = lower(?)", entityId, contractAddress).
+		Where(
+			r.db.Where("type = ?", models.CONTRACT_IN_TYPE).
+				Or(
+					"type = ? and status in ? and (replaces_id is null or replaces_id = ? or status = ?)",
+					models.CONTRACT_OUT_TYPE,
+					[]string{models.TX_STATUS_PENDING, models.TX_STATUS_CONFIRMED},
+					uuid.Nil,
+					models.TX_STATUS_CONFIRMED,
+				).
+				Or(
+					"type = ? and status = ? and block_number > 0 and contract_address = ?",
+					models.CONTRACT_OUT_TYPE,
+					models.TX_STATUS_FAILED,
+					models.AIOZ_CONTRACT_ADDRESS,
+				),
+		).
+		Scan(&rs).Error; err != nil {
+		return decimal.Zero, err
+	}
+
+	return rs.Total, nil
+}
+
+func applyTransactionFilter(query *gorm.DB, filter models.TransactionFilter) *gorm.DB {
+	if filter.EntityId != uuid.Nil {
+		query = query.Where("entity_id = ?", filter.EntityId)
+	}
+
+	if filter.Unattributed {
+		query = query.Where("entity_id is null or entity_id = ?", uuid.Nil)
+	}
+
+	if filter.Type != "" {
+		query = query.Where("type = ?", filter.Type)
+	}
+
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+
+	if filter.Denom != "" {
+		query = query.Where("denom = ?", filter.Denom)
+	}
+
+	if filter.ContractAddress != "" {
+		query = query.Where("lower(contract_address) = lower(?)", filter.ContractAddress)
+	}
+
+	if filter.FromBlock > 0 {
+		query = query.Where("block_number >= ?", filter.FromBlock)
+	}
+
+	if filter.ToBlock > 0 {
+		query = query.Where("block_number <= ?", filter.ToBlock)
+	}
+
+	if filter.FromTime > 0 {
+		query = query.Where("created_at >= ?", filter.FromTime)
+	}
+
+	if filter.ToTime > 0 {
+		query = query.Where("created_at <= ?", filter.ToTime)
+	}
+
+	return query
+}
+
+func (r TransactionRepository) UpdateTransactionStatus(
+	ctx context.Context,
+	id uuid.UUID,
+	status string,
+) error {
+	if err := r.db.WithContext(ctx).
+		Model(models.Transaction{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"status":     status,
+			"updated_at": time.Now().UTC().Unix(),
+		}).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r TransactionRepository) AssignTransaction(
+	ctx context.Context,
+	id uuid.UUID,
+	entityId uuid.UUID,
+) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(models.Transaction{}).
+		Where("id = ? and type = ?", id, models.CONTRACT_IN_TYPE).
+		Where("entity_id is null or entity_id = ?", uuid.Nil).
+		Updates(map[string]interface{}{
+			"entity_id":  entityId,
+			"seq":        gorm.Expr("nextval(pg_get_serial_sequence('transactions', 'seq'))"),
+			"updated_at": time.Now().UTC().Unix(),
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return result.RowsAffected > 0, nil
+}
+
+func (r TransactionRepository) UpdateTransactionReceipt(
+	ctx context.Context,
+	id uuid.UUID,
+	status string,
+	blockNumber uint64,
+	fee decimal.Decimal,
+) error {
+	if err := r.db.WithContext(ctx).
+		Model(models.Transaction{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"status":       status,
+			"block_number": blockNumber,
+			"fee":          fee,
+			"updated_at":   time.Now().UTC().Unix(),
+		}).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r TransactionRepository) SetTransactionsSweepId(
+	ctx context.Context,
+	ids []uuid.UUID,
+	sweepId uuid.UUID,
+) error {
+	if err := r.db.WithContext(ctx).
+		Model(models.Transaction{}).
+		Where("id in ?", ids).
+		Updates(map[string]interface{}{
+			"sweep_id":   sweepId,
+			"updated_at": time.Now().UTC().Unix(),
+		}).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
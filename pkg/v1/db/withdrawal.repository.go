@@ -0,0 +1,404 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/vangxitrum/payment-host/internal/models"
+)
+
+type WithdrawalRepository struct {
+	db *gorm.DB
+}
+
+func MustNewWithdrawalRepository(db *gorm.DB, init bool) models.WithdrawalRepository {
+	if init {
+		if err := db.AutoMigrate(
+			&models.WithdrawalRequest{},
+			&models.WithdrawalApproval{},
+			&models.WithdrawalPolicy{},
+			&models.AllowedDestination{},
+			&models.WithdrawalQuote{},
+			&models.WithdrawalBatch{},
+			&models.WithdrawalBatchItem{},
+		); err != nil {
+			panic(err)
+		}
+	}
+
+	return WithdrawalRepository{
+		db: db,
+	}
+}
+
+func (r WithdrawalRepository) CreateWithdrawalRequest(ctx context.Context, request *models.WithdrawalRequest) error {
+	if err := r.db.WithContext(ctx).
+		Omit("Approvals", "Transaction").
+		Create(request).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r WithdrawalRepository) GetWithdrawalRequestById(ctx context.Context, id uuid.UUID) (*models.WithdrawalRequest, error) {
+	var rs models.WithdrawalRequest
+	if err := r.db.WithContext(ctx).
+		Preload("Approvals", func(db *gorm.DB) *gorm.DB {
+			return db.Order("created_at asc")
+		}).
+		Preload("Transaction").
+		Where("id = ?", id).
+		First(&rs).Error; err != nil {
+		return nil, err
+	}
+
+	return &rs, nil
+}
+
+// ListWithdrawalRequests returns the oldest requests in status first, or
+// the newest requests of any status when status is empty.
+func (r WithdrawalRepository) ListWithdrawalRequests(
+	ctx context.Context,
+	status string,
+	limit int,
+) ([]*models.WithdrawalRequest, error) {
+	query := r.db.WithContext(ctx).
+		Preload("Approvals").
+		Preload("Transaction")
+	if status != "" {
+		query = query.Where("status = ?", status).Order("created_at asc")
+	} else {
+		query = query.Order("created_at desc")
+	}
+
+	var rs []*models.WithdrawalRequest
+	if err := query.
+		Limit(limit).
+		Find(&rs).Error; err != nil {
+		return nil, err
+	}
+
+	return rs, nil
+}
+
+func (r WithdrawalRepository) GetPendingWithdrawalTotals(ctx context.Context) (map[string]decimal.Decimal, error) {
+	var rows []struct {
+		ContractAddress string
+		Total           decimal.Decimal
+	}
+	if err := r.db.WithContext(ctx).
+		Model(models.WithdrawalRequest{}).
+		Select("contract_address, coalesce(sum(amount), 0) as total").
+		Where("status in ?", []string{models.WITHDRAWAL_STATUS_REQUESTED, models.WITHDRAWAL_STATUS_APPROVED}).
+		Group("contract_address").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	rs := make(map[string]decimal.Decimal, len(rows))
+	for _, row := range rows {
+		rs[row.ContractAddress] = row.Total
+	}
+
+	return rs, nil
+}
+
+func (r WithdrawalRepository) UpdateWithdrawalRequestStatus(
+	ctx context.Context,
+	id uuid.UUID,
+	fromStatus, toStatus string,
+) (bool, error) {
+	rs := r.db.WithContext(ctx).
+		Model(models.WithdrawalRequest{}).
+		Where("id = ? and status = ?", id, fromStatus).
+		Updates(map[string]interface{}{
+			"status":     toStatus,
+			"updated_at": time.Now().UTC().Unix(),
+		})
+	if rs.Error != nil {
+		return false, rs.Error
+	}
+
+	return rs.RowsAffected == 1, nil
+}
+
+func (r WithdrawalRepository) CompleteWithdrawalRequest(
+	ctx context.Context,
+	id uuid.UUID,
+	transactionId *uuid.UUID,
+	status, failureReason string,
+) error {
+	if err := r.db.WithContext(ctx).
+		Model(models.WithdrawalRequest{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"status":         status,
+			"transaction_id": transactionId,
+			"failure_reason": failureReason,
+			"updated_at":     time.Now().UTC().Unix(),
+		}).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r WithdrawalRepository) CreateWithdrawalApproval(
+	ctx context.Context,
+	approval *models.WithdrawalApproval,
+) (bool, error) {
+	rs := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(approval)
+	if rs.Error != nil {
+		return false, rs.Error
+	}
+
+	return rs.RowsAffected == 1, nil
+}
+
+func (r WithdrawalRepository) GetWithdrawalPolicy(
+	ctx context.Context,
+	entityId uuid.UUID,
+	contractAddress string,
+) (*models.WithdrawalPolicy, error) {
+	var rs models.WithdrawalPolicy
+	if err := r.db.WithContext(ctx).
+		Where("entity_id = ? and contract_address = ?", entityId, contractAddress).
+		First(&rs).Error; err != nil {
+		return nil, err
+	}
+
+	return &rs, nil
+}
+
+func (r WithdrawalRepository) UpsertWithdrawalPolicy(ctx context.Context, policy *models.WithdrawalPolicy) error {
+	if err := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "entity_id"}, {Name: "contract_address"}},
+			DoUpdates: clause.AssignmentColumns([]string{
+				"approval_threshold",
+				"required_approvals",
+				"max_per_tx",
+				"daily_cap",
+				"weekly_cap",
+				"max_daily_count",
+				"allowlist_enabled",
+				"allowlist_cooldown",
+				"updated_at",
+			}),
+		}).
+		Create(policy).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r WithdrawalRepository) CreateAllowedDestination(
+	ctx context.Context,
+	destination *models.AllowedDestination,
+) (bool, error) {
+	rs := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(destination)
+	if rs.Error != nil {
+		return false, rs.Error
+	}
+
+	return rs.RowsAffected == 1, nil
+}
+
+func (r WithdrawalRepository) GetAllowedDestination(
+	ctx context.Context,
+	entityId uuid.UUID,
+	address string,
+) (*models.AllowedDestination, error) {
+	var rs models.AllowedDestination
+	if err := r.db.WithContext(ctx).
+		Where("entity_id = ? and address = ?", entityId, address).
+		First(&rs).Error; err != nil {
+		return nil, err
+	}
+
+	return &rs, nil
+}
+
+func (r WithdrawalRepository) ListAllowedDestinations(
+	ctx context.Context,
+	entityId uuid.UUID,
+) ([]*models.AllowedDestination, error) {
+	var rs []*models.AllowedDestination
+	if err := r.db.WithContext(ctx).
+		Where("entity_id = ?", entityId).
+		Order("created_at asc").
+		Find(&rs).Error; err != nil {
+		return nil, err
+	}
+
+	return rs, nil
+}
+
+func (r WithdrawalRepository) DeleteAllowedDestination(
+	ctx context.Context,
+	entityId uuid.UUID,
+	address string,
+) error {
+	rs := r.db.WithContext(ctx).
+		Where("entity_id = ? and address = ?", entityId, address).
+		Delete(&models.AllowedDestination{})
+	if rs.Error != nil {
+		return rs.Error
+	}
+
+	if rs.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+func (r WithdrawalRepository) CreateWithdrawalQuote(ctx context.Context, quote *models.WithdrawalQuote) error {
+	if err := r.db.WithContext(ctx).
+		Create(quote).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r WithdrawalRepository) GetWithdrawalQuoteById(ctx context.Context, id uuid.UUID) (*models.WithdrawalQuote, error) {
+	var rs models.WithdrawalQuote
+	if err := r.db.WithContext(ctx).
+		Where("id = ?", id).
+		First(&rs).Error; err != nil {
+		return nil, err
+	}
+
+	return &rs, nil
+}
+
+func (r WithdrawalRepository) UseWithdrawalQuote(ctx context.Context, id uuid.UUID, now int64) (bool, error) {
+	rs := r.db.WithContext(ctx).
+		Model(models.WithdrawalQuote{}).
+		Where("id = ? and used_at = 0 and expires_at > ?", id, now).
+		Update("used_at", now)
+	if rs.Error != nil {
+		return false, rs.Error
+	}
+
+	return rs.RowsAffected > 0, nil
+}
+
+func (r WithdrawalRepository) CreateWithdrawalBatch(ctx context.Context, batch *models.WithdrawalBatch) error {
+	if err := r.db.WithContext(ctx).
+		Transaction(func(tx *gorm.DB) error {
+			if err := tx.Omit("Items").Create(batch).Error; err != nil {
+				return err
+			}
+
+			return tx.Omit("WithdrawalRequest").CreateInBatches(batch.Items, 100).Error
+		}); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r WithdrawalRepository) GetWithdrawalBatchById(ctx context.Context, id uuid.UUID) (*models.WithdrawalBatch, error) {
+	var rs models.WithdrawalBatch
+	if err := preloadWithdrawalBatchItems(r.db.WithContext(ctx)).
+		Where("id = ?", id).
+		First(&rs).Error; err != nil {
+		return nil, err
+	}
+
+	return &rs, nil
+}
+
+// ListWithdrawalBatches returns the oldest batches in status first.
+func (r WithdrawalRepository) ListWithdrawalBatches(
+	ctx context.Context,
+	status string,
+	limit int,
+) ([]*models.WithdrawalBatch, error) {
+	var rs []*models.WithdrawalBatch
+	if err := preloadWithdrawalBatchItems(r.db.WithContext(ctx)).
+		Where("status = ?", status).
+		Order("created_at asc").
+		Limit(limit).
+		Find(&rs).Error; err != nil {
+		return nil, err
+	}
+
+	return rs, nil
+}
+
+func (r WithdrawalRepository) UpdateWithdrawalBatchStatus(ctx context.Context, id uuid.UUID, status string) error {
+	if err := r.db.WithContext(ctx).
+		Model(models.WithdrawalBatch{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"status":     status,
+			"updated_at": time.Now().UTC().Unix(),
+		}).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r WithdrawalRepository) UpdateWithdrawalBatchItem(ctx context.Context, item *models.WithdrawalBatchItem) error {
+	item.UpdatedAt = time.Now().UTC().Unix()
+	if err := r.db.WithContext(ctx).
+		Model(models.WithdrawalBatchItem{}).
+		Where("id = ?", item.Id).
+		Updates(map[string]interface{}{
+			"status":                item.Status,
+			"error":                 item.Error,
+			"withdrawal_request_id": item.WithdrawalRequestId,
+			"updated_at":            item.UpdatedAt,
+		}).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r WithdrawalRepository) ClaimWithdrawalBatchItem(ctx context.Context, item *models.WithdrawalBatchItem) (bool, error) {
+	updatedAt := time.Now().UTC().Unix()
+	rs := r.db.WithContext(ctx).
+		Model(models.WithdrawalBatchItem{}).
+		Where("id = ? and status = ?", item.Id, models.BATCH_ITEM_STATUS_QUEUED).
+		Updates(map[string]interface{}{
+			"status":     models.BATCH_ITEM_STATUS_SENDING,
+			"updated_at": updatedAt,
+		})
+	if rs.Error != nil {
+		return false, rs.Error
+	}
+
+	if rs.RowsAffected != 1 {
+		return false, nil
+	}
+
+	item.Status = models.BATCH_ITEM_STATUS_SENDING
+	item.UpdatedAt = updatedAt
+	return true, nil
+}
+
+func preloadWithdrawalBatchItems(query *gorm.DB) *gorm.DB {
+	return query.
+		Preload("Items", func(db *gorm.DB) *gorm.DB {
+			return db.Order("position asc")
+		}).
+		Preload("Items.WithdrawalRequest").
+		Preload("Items.WithdrawalRequest.Approvals").
+		Preload("Items.WithdrawalRequest.Transaction")
+}
@@ -0,0 +1,184 @@
+package services
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+
+	"github.com/vangxitrum/payment-host/internal/models"
+)
+
+// ApproveWithdrawal records the approval of approver. The request is executed
+// by the approval that completes it.
+func (s *EntityService) ApproveWithdrawal(
+	ctx context.Context,
+	id uuid.UUID,
+	approver string,
+) (*models.WithdrawalRequest, error) {
+	request, err := s.decideWithdrawal(ctx, id, approver, models.WITHDRAWAL_DECISION_APPROVED, "")
+	if err != nil {
+		return nil, err
+	}
+
+	if request.ApprovalCount() < request.RequiredApprovals {
+		return request, nil
+	}
+
+	// Approvals can race, only the one that moves the request on executes it.
+	updated, err := s.withdrawalRepo.UpdateWithdrawalRequestStatus(
+		ctx,
+		id,
+		models.WITHDRAWAL_STATUS_REQUESTED,
+		models.WITHDRAWAL_STATUS_APPROVED,
+	)
+	if err != nil {
+		return nil, status.Newf(codes.Internal, "failed to update withdrawal request").Err()
+	}
+
+	if !updated {
+		return s.getWithdrawalRequest(ctx, id)
+	}
+
+	request.Status = models.WITHDRAWAL_STATUS_APPROVED
+	return s.executeWithdrawalRequest(ctx, request, nil)
+}
+
+// RejectWithdrawal rejects the request, a single rejection is final.
+func (s *EntityService) RejectWithdrawal(
+	ctx context.Context,
+	id uuid.UUID,
+	approver string,
+	reason string,
+) (*models.WithdrawalRequest, error) {
+	if _, err := s.decideWithdrawal(ctx, id, approver, models.WITHDRAWAL_DECISION_REJECTED, reason); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.withdrawalRepo.UpdateWithdrawalRequestStatus(
+		ctx,
+		id,
+		models.WITHDRAWAL_STATUS_REQUESTED,
+		models.WITHDRAWAL_STATUS_REJECTED,
+	); err != nil {
+		return nil, status.Newf(codes.Internal, "failed to update withdrawal request").Err()
+	}
+
+	return s.getWithdrawalRequest(ctx, id)
+}
+
+// decideWithdrawal stores the decision of approver on a request that still
+// waits for approvals and returns the request including it.
+func (s *EntityService) decideWithdrawal(
+	ctx context.Context,
+	id uuid.UUID,
+	approver, decision, reason string,
+) (*models.WithdrawalRequest, error) {
+	if approver == "" {
+		return nil, models.NewInvalidArgumentError(models.REASON_INVALID_ARGUMENT, "approver", "approver is required")
+	}
+
+	request, err := s.getWithdrawalRequest(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if request.Status != models.WITHDRAWAL_STATUS_REQUESTED {
+		return nil, models.NewFailedPreconditionError(
+			models.REASON_WITHDRAWAL_DECIDED,
+			"withdrawal request",
+			"withdrawal request is "+request.Status,
+		)
+	}
+
+	// Without a requester, any approver may be the one who asked for the
+	// withdrawal. Such a request can only be rejected.
+	if request.RequestedBy == "" && decision == models.WITHDRAWAL_DECISION_APPROVED {
+		return nil, models.NewFailedPreconditionError(
+			models.REASON_REQUESTER_UNKNOWN,
+			"withdrawal request",
+			"a withdrawal without a requester can not be approved",
+		)
+	}
+
+	if request.RequestedBy == approver {
+		return nil, models.NewFailedPreconditionError(
+			models.REASON_SELF_APPROVAL,
+			"approver",
+			"a withdrawal can not be decided by its requester",
+		)
+	}
+
+	created, err := s.withdrawalRepo.CreateWithdrawalApproval(
+		ctx,
+		models.NewWithdrawalApproval(id, approver, decision, reason),
+	)
+	if err != nil {
+		return nil, status.Newf(codes.Internal, "failed to save withdrawal approval").Err()
+	}
+
+	if !created {
+		return nil, models.NewFailedPreconditionError(
+			models.REASON_WITHDRAWAL_DECIDED,
+			"approver",
+			"approver already decided on this withdrawal",
+		)
+	}
+
+	return s.getWithdrawalRequest(ctx, id)
+}
+
+// GetWithdrawalRequest returns a request of entityName, or of any entity when
+// entityName is empty.
+func (s *EntityService) GetWithdrawalRequest(
+	ctx context.Context,
+	entityName string,
+	id uuid.UUID,
+) (*models.WithdrawalRequest, error) {
+	request, err := s.getWithdrawalRequest(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if entityName != "" && request.EntityName != entityName {
+		return nil, models.NewNotFoundError(models.REASON_WITHDRAWAL_NOT_FOUND, "withdrawal request", id.String())
+	}
+
+	return request, nil
+}
+
+func (s *EntityService) ListWithdrawalRequests(
+	ctx context.Context,
+	requestStatus string,
+	pageSize int,
+) ([]*models.WithdrawalRequest, error) {
+	if pageSize <= 0 {
+		pageSize = defaultPageSize
+	}
+
+	if pageSize > maxPageSize {
+		pageSize = maxPageSize
+	}
+
+	requests, err := s.withdrawalRepo.ListWithdrawalRequests(ctx, requestStatus, pageSize)
+	if err != nil {
+		return nil, status.Newf(codes.Internal, "failed to list withdrawal requests").Err()
+	}
+
+	return requests, nil
+}
+
+func (s *EntityService) getWithdrawalRequest(ctx context.Context, id uuid.UUID) (*models.WithdrawalRequest, error) {
+	request, err := s.withdrawalRepo.GetWithdrawalRequestById(ctx, id)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, models.NewNotFoundError(models.REASON_WITHDRAWAL_NOT_FOUND, "withdrawal request", id.String())
+		}
+
+		return nil, status.Newf(codes.Internal, "failed to get withdrawal request").Err()
+	}
+
+	return request, nil
+}
@@ -0,0 +1,135 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/vangxitrum/payment-host/internal/models"
+)
+
+// requireApprovals makes withdrawals of entity above threshold wait for one
+// approval.
+func (e *testEnv) requireApprovals(t *testing.T, entity *models.Entity, threshold int64) {
+	t.Helper()
+
+	policy := models.NewWithdrawalPolicy(entity.Id, models.AIOZ_CONTRACT_ADDRESS)
+	policy.ApprovalThreshold = decimal.NewFromInt(threshold)
+	policy.RequiredApprovals = 1
+	if err := e.withdrawalRepo.UpsertWithdrawalPolicy(context.Background(), policy); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWithdrawApprovals(t *testing.T) {
+	ctx := context.Background()
+	env := newTestEnv(t)
+	entity := env.addEntity(t, "shop")
+	env.deposit(t, entity, 1000000)
+	env.backend.setBalance(env.hotAddr, 1000000000)
+	env.requireApprovals(t, entity, 50000)
+
+	request, err := env.service.Withdraw(ctx, withdrawParams(entity, 100000, "alice"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if request.Status != models.WITHDRAWAL_STATUS_REQUESTED {
+		t.Fatalf("status = %s, want %s", request.Status, models.WITHDRAWAL_STATUS_REQUESTED)
+	}
+
+	if sent := env.backend.sentTransactions(); len(sent) != 0 {
+		t.Fatalf("sent %d transactions before approval, want none", len(sent))
+	}
+
+	_, err = env.service.ApproveWithdrawal(ctx, request.Id, "alice")
+	requireReason(t, err, models.REASON_SELF_APPROVAL)
+
+	request, err = env.service.ApproveWithdrawal(ctx, request.Id, "bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if request.Status != models.WITHDRAWAL_STATUS_EXECUTED || request.TransactionId == nil {
+		t.Fatalf("status = %s, want %s with a transaction", request.Status, models.WITHDRAWAL_STATUS_EXECUTED)
+	}
+
+	if sent := env.backend.sentTransactions(); len(sent) != 1 {
+		t.Fatalf("sent %d transactions, want 1", len(sent))
+	}
+
+	_, err = env.service.ApproveWithdrawal(ctx, request.Id, "carol")
+	requireReason(t, err, models.REASON_WITHDRAWAL_DECIDED)
+}
+
+func TestApprovedMaxWithdrawalKeepsItsAmount(t *testing.T) {
+	ctx := context.Background()
+	env := newTestEnv(t)
+	entity := env.addEntity(t, "shop")
+	env.deposit(t, entity, 1000000)
+	env.backend.setBalance(env.hotAddr, 1000000000)
+	env.requireApprovals(t, entity, 50000)
+
+	params := withdrawParams(entity, 0, "alice")
+	params.Max = true
+	request, err := env.service.Withdraw(ctx, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	approved := int64(1000000 - testTransferFee)
+	if request.Amount.IntPart() != approved {
+		t.Fatalf("amount = %s, want %d", request.Amount, approved)
+	}
+
+	// A deposit arriving before the approval is not swept into the
+	// withdrawal.
+	env.deposit(t, entity, 500000)
+
+	if _, err := env.service.ApproveWithdrawal(ctx, request.Id, "bob"); err != nil {
+		t.Fatal(err)
+	}
+
+	if sent := env.backend.sentTransactions(); len(sent) != 1 || sent[0].Value().Int64() != approved {
+		t.Fatalf("sent %v, want one transfer of %d", sent, approved)
+	}
+
+	if got, want := env.ledger(t, entity), int64(500000); got != want {
+		t.Fatalf("ledger = %d, want %d", got, want)
+	}
+}
+
+func TestWithdrawalWithoutRequester(t *testing.T) {
+	ctx := context.Background()
+	env := newTestEnv(t)
+	entity := env.addEntity(t, "shop")
+	env.deposit(t, entity, 1000000)
+	env.backend.setBalance(env.hotAddr, 1000000000)
+	env.requireApprovals(t, entity, 50000)
+
+	request, err := env.service.Withdraw(ctx, withdrawParams(entity, 100000, ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = env.service.ApproveWithdrawal(ctx, request.Id, "")
+	requireReason(t, err, models.REASON_INVALID_ARGUMENT)
+
+	// Any approver may have made the request.
+	_, err = env.service.ApproveWithdrawal(ctx, request.Id, "bob")
+	requireReason(t, err, models.REASON_REQUESTER_UNKNOWN)
+
+	request, err = env.service.RejectWithdrawal(ctx, request.Id, "bob", "unknown requester")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if request.Status != models.WITHDRAWAL_STATUS_REJECTED {
+		t.Fatalf("status = %s, want %s", request.Status, models.WITHDRAWAL_STATUS_REJECTED)
+	}
+
+	if sent := env.backend.sentTransactions(); len(sent) != 0 {
+		t.Fatalf("sent %d transactions, want none", len(sent))
+	}
+}
@@ -0,0 +1,313 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+
+	"github.com/vangxitrum/payment-host/internal/common/aiozcoin"
+	"github.com/vangxitrum/payment-host/internal/common/erc20"
+	"github.com/vangxitrum/payment-host/internal/common/gasprice"
+	"github.com/vangxitrum/payment-host/internal/models"
+)
+
+const (
+	maxBatchSize = 500
+
+	// batchesPerRun bounds the batches a single ProcessWithdrawalBatches run
+	// picks up.
+	batchesPerRun = 20
+)
+
+// BatchWithdraw checks that the entity and its payout wallet can pay for every
+// item of the batch, fees included, and stores it. The items are then withdrawn one after
+// the other in the background, each as a withdrawal request of its own, so
+// approvals and limits apply per item and an item can fail on its own.
+func (s *EntityService) BatchWithdraw(
+	ctx context.Context,
+	params *models.BatchWithdrawParams,
+) (*models.WithdrawalBatch, error) {
+	if len(params.Items) == 0 {
+		return nil, models.NewInvalidArgumentError(models.REASON_INVALID_ARGUMENT, "Items", "must not be empty")
+	}
+
+	if len(params.Items) > maxBatchSize {
+		return nil, models.NewInvalidArgumentError(
+			models.REASON_INVALID_ARGUMENT,
+			"Items",
+			fmt.Sprintf("must not have more than %d items", maxBatchSize),
+		)
+	}
+
+	entity, err := s.getEntityByName(ctx, params.EntityName)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.checkBatchBalance(ctx, entity, params.Items); err != nil {
+		return nil, err
+	}
+
+	batch := models.NewWithdrawalBatch(entity, params)
+	if err := s.withdrawalRepo.CreateWithdrawalBatch(ctx, batch); err != nil {
+		return nil, status.Newf(codes.Internal, "failed to save withdrawal batch").Err()
+	}
+
+	go func() {
+		if err := s.ProcessWithdrawalBatches(context.Background()); err != nil {
+			log.Println("ProcessWithdrawalBatches error ", err)
+		}
+	}()
+
+	return batch, nil
+}
+
+// checkBatchBalance sums the items per asset and checks the totals against
+// the ledger of the entity and the balances of its payout wallet. The fees of
+// all items are estimated with the current gas price, the items are priced
+// again when they are sent.
+func (s *EntityService) checkBatchBalance(
+	ctx context.Context,
+	entity *models.Entity,
+	items []*models.BatchWithdrawItem,
+) error {
+	type assetTotal struct {
+		amount decimal.Decimal
+		count  int64
+		// call is the transfer of the first item, used to estimate the fee of
+		// every item of the group.
+		call *transferCall
+		// bank groups the native items to aioz1 addresses, which are sent as
+		// Cosmos transfers.
+		bank bool
+	}
+
+	totals := make(map[string]*assetTotal)
+	for i, item := range items {
+		contractAddr, amount, err := s.resolveAmount(ctx, item.Amount, item.Denom)
+		if err != nil {
+			return batchItemError(i, err)
+		}
+
+		receiverAddr, bech32, ok := parseAddress(item.ReceiverAddress)
+		if !ok {
+			return batchItemError(
+				i,
+				models.NewInvalidArgumentError(models.REASON_INVALID_ADDRESS, "ReceiverWalletAddress", "invalid wallet address"),
+			)
+		}
+
+		bank := bech32 && contractAddr == models.AIOZ_CONTRACT_ADDRESS
+		key := contractAddr
+		if bank {
+			key = "bank"
+		}
+
+		total, ok := totals[key]
+		if !ok {
+			call, err := newTransferCall(contractAddr, receiverAddr, amount.BigInt())
+			if err != nil {
+				return status.Newf(codes.Internal, "failed to encode transfer").Err()
+			}
+
+			total = &assetTotal{call: call, bank: bank}
+			totals[key] = total
+		}
+
+		total.amount = total.amount.Add(amount)
+		total.count++
+	}
+
+	from, privateKey, err := s.payoutWallet(entity)
+	if err != nil {
+		return err
+	}
+
+	balance, err := s.ethClient.BalanceAt(ctx, from, nil)
+	if err != nil {
+		return status.Newf(codes.Internal, "failed to get balance").Err()
+	}
+
+	pricing, err := gasprice.Suggest(ctx, s.ethClient, s.gasCaps)
+	if err != nil {
+		return gasPriceError(err)
+	}
+
+	// required is what the payout wallet pays in AIOZ, nativeDebit what the
+	// native items take from the ledger.
+	required := decimal.Zero
+	nativeDebit := decimal.Zero
+	for _, total := range totals {
+		var fee *big.Int
+		switch {
+		case total.bank:
+			send, err := s.prepareBankSend(ctx, privateKey, from, total.call.to, decimal.NewFromBigInt(total.call.value, 0), s.gasCaps)
+			if err != nil {
+				return err
+			}
+
+			fee = send.Fee.AmountOf(aiozcoin.DefaultDenom).BigInt()
+		case total.call.isToken():
+			if err := s.checkLedgerBalance(ctx, entity.Id, total.call.contractAddr, total.amount); err != nil {
+				return err
+			}
+
+			tokenBalance, err := erc20.BalanceOf(ctx, s.ethClient, common.HexToAddress(total.call.contractAddr), from)
+			if err != nil {
+				return status.Newf(codes.Internal, "failed to get token balance").Err()
+			}
+
+			if available := decimal.NewFromBigInt(tokenBalance, 0); available.LessThan(total.amount) {
+				return s.insufficientPayoutError(from, total.call.contractAddr, total.amount, available)
+			}
+
+			gasLimit, err := s.ethClient.EstimateGas(ctx, ethereum.CallMsg{
+				From:  from,
+				To:    &total.call.to,
+				Value: total.call.value,
+				Data:  total.call.data,
+			})
+			if err != nil {
+				return status.Newf(codes.Internal, "failed to estimate gas").Err()
+			}
+
+			fee = new(big.Int).Mul(pricing.MaxFeePerGas(), new(big.Int).SetUint64(gasLimit))
+		default:
+			fee = new(big.Int).Mul(pricing.MaxFeePerGas(), new(big.Int).SetUint64(nativeTransferGas))
+		}
+
+		fees := decimal.NewFromBigInt(fee, 0).Mul(decimal.NewFromInt(total.count))
+		required = required.Add(fees)
+		if !total.call.isToken() {
+			required = required.Add(total.amount)
+			nativeDebit = nativeDebit.Add(total.amount).Add(fees)
+		}
+	}
+
+	if nativeDebit.IsPositive() {
+		if err := s.checkLedgerBalance(ctx, entity.Id, models.AIOZ_CONTRACT_ADDRESS, nativeDebit); err != nil {
+			return err
+		}
+	}
+
+	if balance.Cmp(required.BigInt()) < 0 {
+		return s.insufficientPayoutError(
+			from,
+			models.AIOZ_CONTRACT_ADDRESS,
+			required,
+			decimal.NewFromBigInt(balance, 0),
+		)
+	}
+
+	return nil
+}
+
+// batchItemError points an invalid argument error at the item it is about.
+func batchItemError(index int, err error) error {
+	e, ok := err.(*models.Error)
+	if !ok || e.Kind != models.ERROR_KIND_INVALID_ARGUMENT {
+		return err
+	}
+
+	itemErr := *e
+	itemErr.Subject = fmt.Sprintf("Items[%d].%s", index, e.Subject)
+	return &itemErr
+}
+
+// ProcessWithdrawalBatches withdraws the queued items of the batches still
+// being processed. Runs never overlap, a run that finds another one going
+// returns right away.
+func (s *EntityService) ProcessWithdrawalBatches(ctx context.Context) error {
+	if !s.batchMu.TryLock() {
+		return nil
+	}
+	defer s.batchMu.Unlock()
+
+	batches, err := s.withdrawalRepo.ListWithdrawalBatches(ctx, models.BATCH_STATUS_PROCESSING, batchesPerRun)
+	if err != nil {
+		return status.Newf(codes.Internal, "failed to list withdrawal batches").Err()
+	}
+
+	for _, batch := range batches {
+		if err := s.processWithdrawalBatch(ctx, batch); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *EntityService) processWithdrawalBatch(ctx context.Context, batch *models.WithdrawalBatch) error {
+	for _, item := range batch.Items {
+		switch item.Status {
+		case models.BATCH_ITEM_STATUS_SENDING:
+			item.Status = models.BATCH_ITEM_STATUS_FAILED
+			item.Error = "interrupted while sending, check the withdrawal requests of the entity before retrying"
+		case models.BATCH_ITEM_STATUS_QUEUED:
+			// Another instance may be sending the item already.
+			claimed, err := s.withdrawalRepo.ClaimWithdrawalBatchItem(ctx, item)
+			if err != nil {
+				return status.Newf(codes.Internal, "failed to update withdrawal batch item").Err()
+			}
+
+			if !claimed {
+				continue
+			}
+
+			// Items are sent one at a time so that they take the nonces of the
+			// wallet in order.
+			request, err := s.Withdraw(ctx, batch.WithdrawParams(item))
+			if err != nil {
+				item.Status = models.BATCH_ITEM_STATUS_FAILED
+				item.Error = status.Convert(err).Message()
+			} else {
+				item.Status = models.BATCH_ITEM_STATUS_SUBMITTED
+				item.WithdrawalRequestId = &request.Id
+			}
+		default:
+			continue
+		}
+
+		if err := s.withdrawalRepo.UpdateWithdrawalBatchItem(ctx, item); err != nil {
+			return status.Newf(codes.Internal, "failed to update withdrawal batch item").Err()
+		}
+	}
+
+	if err := s.withdrawalRepo.UpdateWithdrawalBatchStatus(ctx, batch.Id, models.BATCH_STATUS_COMPLETED); err != nil {
+		return status.Newf(codes.Internal, "failed to update withdrawal batch").Err()
+	}
+
+	return nil
+}
+
+// GetWithdrawalBatch returns a batch of entityName along with the withdrawal
+// request and transaction of every submitted item.
+func (s *EntityService) GetWithdrawalBatch(
+	ctx context.Context,
+	entityName string,
+	id uuid.UUID,
+) (*models.WithdrawalBatch, error) {
+	batch, err := s.withdrawalRepo.GetWithdrawalBatchById(ctx, id)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, models.NewNotFoundError(models.REASON_BATCH_NOT_FOUND, "withdrawal batch", id.String())
+		}
+
+		return nil, status.Newf(codes.Internal, "failed to get withdrawal batch").Err()
+	}
+
+	if batch.EntityName != entityName {
+		return nil, models.NewNotFoundError(models.REASON_BATCH_NOT_FOUND, "withdrawal batch", id.String())
+	}
+
+	return batch, nil
+}
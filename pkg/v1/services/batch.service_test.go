@@ -0,0 +1,136 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/vangxitrum/payment-host/internal/common/aiozcoin"
+	"github.com/vangxitrum/payment-host/internal/models"
+)
+
+func TestBatchWithdrawAboveLedger(t *testing.T) {
+	ctx := context.Background()
+	env := newTestEnv(t)
+	entity := env.addEntity(t, "shop")
+	env.deposit(t, entity, 200000)
+	env.backend.setBalance(env.hotAddr, 1000000000)
+
+	// Each item fits the ledger, both with their fees do not.
+	_, err := env.service.BatchWithdraw(ctx, &models.BatchWithdrawParams{
+		EntityName:  entity.Name,
+		RequestedBy: "alice",
+		Items: []*models.BatchWithdrawItem{
+			{ReceiverAddress: newAddress().Hex(), Amount: decimal.NewFromInt(90000), Denom: aiozcoin.DefaultDenom},
+			{ReceiverAddress: newAddress().Hex(), Amount: decimal.NewFromInt(90000), Denom: aiozcoin.DefaultDenom},
+		},
+	})
+	requireReason(t, err, models.REASON_INSUFFICIENT_BALANCE)
+
+	if len(env.store.batches) != 0 {
+		t.Fatalf("stored %d batches, want none", len(env.store.batches))
+	}
+}
+
+func TestProcessWithdrawalBatches(t *testing.T) {
+	ctx := context.Background()
+	env := newTestEnv(t)
+	entity := env.addEntity(t, "shop")
+	env.deposit(t, entity, 200000)
+	env.backend.setBalance(env.hotAddr, 1000000000)
+
+	batch := models.NewWithdrawalBatch(entity, &models.BatchWithdrawParams{
+		EntityName:  entity.Name,
+		RequestedBy: "alice",
+		Items: []*models.BatchWithdrawItem{
+			{ReceiverAddress: newAddress().Hex(), Amount: decimal.NewFromInt(50000), Denom: aiozcoin.DefaultDenom},
+			{ReceiverAddress: newAddress().Hex(), Amount: decimal.NewFromInt(100000), Denom: aiozcoin.DefaultDenom},
+			{ReceiverAddress: newAddress().Hex(), Amount: decimal.NewFromInt(100000), Denom: aiozcoin.DefaultDenom},
+		},
+	})
+	// The first item was being sent when processing was interrupted.
+	batch.Items[0].Status = models.BATCH_ITEM_STATUS_SENDING
+	if err := env.withdrawalRepo.CreateWithdrawalBatch(ctx, batch); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := env.service.ProcessWithdrawalBatches(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	batch, err := env.withdrawalRepo.GetWithdrawalBatchById(ctx, batch.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if batch.Status != models.BATCH_STATUS_COMPLETED {
+		t.Fatalf("batch status = %s, want %s", batch.Status, models.BATCH_STATUS_COMPLETED)
+	}
+
+	interrupted, submitted, unfunded := batch.Items[0], batch.Items[1], batch.Items[2]
+	if interrupted.Status != models.BATCH_ITEM_STATUS_FAILED || !strings.Contains(interrupted.Error, "interrupted") {
+		t.Fatalf("interrupted item is %s (%s), want failed as interrupted", interrupted.Status, interrupted.Error)
+	}
+
+	if submitted.Status != models.BATCH_ITEM_STATUS_SUBMITTED || submitted.WithdrawalRequestId == nil {
+		t.Fatalf("second item is %s (%s), want submitted", submitted.Status, submitted.Error)
+	}
+
+	// The second item left less than the third needs on the ledger.
+	if unfunded.Status != models.BATCH_ITEM_STATUS_FAILED {
+		t.Fatalf("third item is %s, want failed", unfunded.Status)
+	}
+
+	if sent := env.backend.sentTransactions(); len(sent) != 1 {
+		t.Fatalf("sent %d transactions, want 1", len(sent))
+	}
+}
+
+func TestProcessWithdrawalBatchSkipsClaimedItems(t *testing.T) {
+	ctx := context.Background()
+	env := newTestEnv(t)
+	entity := env.addEntity(t, "shop")
+	env.deposit(t, entity, 200000)
+	env.backend.setBalance(env.hotAddr, 1000000000)
+
+	batch := models.NewWithdrawalBatch(entity, &models.BatchWithdrawParams{
+		EntityName:  entity.Name,
+		RequestedBy: "alice",
+		Items: []*models.BatchWithdrawItem{
+			{ReceiverAddress: newAddress().Hex(), Amount: decimal.NewFromInt(50000), Denom: aiozcoin.DefaultDenom},
+		},
+	})
+	if err := env.withdrawalRepo.CreateWithdrawalBatch(ctx, batch); err != nil {
+		t.Fatal(err)
+	}
+
+	// Another instance claims the item after this one listed the batch.
+	listed, err := env.withdrawalRepo.GetWithdrawalBatchById(ctx, batch.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claimed, err := env.withdrawalRepo.ClaimWithdrawalBatchItem(ctx, batch.Items[0])
+	if err != nil || !claimed {
+		t.Fatalf("claimed = %v, %v, want the item claimed", claimed, err)
+	}
+
+	if err := env.service.processWithdrawalBatch(ctx, listed); err != nil {
+		t.Fatal(err)
+	}
+
+	if sent := env.backend.sentTransactions(); len(sent) != 0 {
+		t.Fatalf("sent %d transactions, want none", len(sent))
+	}
+
+	stored, err := env.withdrawalRepo.GetWithdrawalBatchById(ctx, batch.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if item := stored.Items[0]; item.Status != models.BATCH_ITEM_STATUS_SENDING {
+		t.Fatalf("item is %s (%s), want left to the instance sending it", item.Status, item.Error)
+	}
+}
@@ -0,0 +1,44 @@
+package services
+
+import "sync"
+
+// depositBroker wakes up deposit subscribers whenever the scanner records a
+// new deposit. It only carries a signal: subscribers read the deposits
+// themselves from the database, which keeps delivery gap-free even when a
+// subscriber is slow or the deposit was recorded by another instance.
+type depositBroker struct {
+	mu          sync.Mutex
+	subscribers map[chan struct{}]struct{}
+}
+
+func newDepositBroker() *depositBroker {
+	return &depositBroker{
+		subscribers: make(map[chan struct{}]struct{}),
+	}
+}
+
+func (b *depositBroker) subscribe() (<-chan struct{}, func()) {
+	ch := make(chan struct{}, 1)
+
+	b.mu.Lock()
+	b.subscribers[ch] = struct{}{}
+	b.mu.Unlock()
+
+	return ch, func() {
+		b.mu.Lock()
+		delete(b.subscribers, ch)
+		b.mu.Unlock()
+	}
+}
+
+func (b *depositBroker) publish() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for ch := range b.subscribers {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
@@ -2,13 +2,17 @@ package services
 
 import (
 	"context"
+	"crypto/ecdsa"
 	"encoding/json"
 	"fmt"
 	"log"
 	"math/big"
+	"sync"
 	"time"
 
 	bank "github.com/cosmos/cosmos-sdk/x/bank/types"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
@@ -25,24 +29,76 @@ import (
 	sdk "github.com/cosmos/cosmos-sdk/types"
 	accaddress "github.com/vangxitrum/payment-host/internal/common/accaddress"
 	"github.com/vangxitrum/payment-host/internal/common/blockchain"
+	"github.com/vangxitrum/payment-host/internal/common/erc20"
+	"github.com/vangxitrum/payment-host/internal/common/gasprice"
+	"github.com/vangxitrum/payment-host/internal/common/nonce"
+	"github.com/vangxitrum/payment-host/internal/common/slack"
 	"github.com/vangxitrum/payment-host/internal/models"
 	internal_services "github.com/vangxitrum/payment-host/internal/services"
 	"github.com/vangxitrum/payment-host/pkg/v1/db"
 )
 
+const (
+	defaultPageSize = 50
+	maxPageSize     = 500
+
+	subscriptionBatchSize    = 100
+	subscriptionPollInterval = 30 * time.Second
+)
+
+// ethBackend is the part of the EVM node API the service uses, it is
+// implemented by *ethclient.Client.
+type ethBackend interface {
+	bind.DeployBackend
+	ethereum.ContractCaller
+	gasprice.Backend
+
+	BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (*big.Int, error)
+	NonceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (uint64, error)
+	PendingNonceAt(ctx context.Context, account common.Address) (uint64, error)
+	EstimateGas(ctx context.Context, msg ethereum.CallMsg) (uint64, error)
+	SendTransaction(ctx context.Context, tx *types.Transaction) error
+}
+
 type EntityService struct {
 	rpcClient *httpClient.HTTP
-	ethClient *ethclient.Client
+	ethClient ethBackend
 
 	entityRepo        models.EntityRepository
 	paymentMarkRepo   models.PaymentMarkRepository
 	walletAddressRepo models.WalletRepository
 	txRepo            models.TransactionRepository
+	apiKeyRepo        models.ApiKeyRepository
+	withdrawalRepo    models.WithdrawalRepository
+	invoiceRepo       models.InvoiceRepository
+
+	deposits *depositBroker
+	scanMu   *sync.Mutex
+	batchMu  *sync.Mutex
+	sweepMu  *sync.Mutex
+	nonces   *nonce.Manager
 
 	chainId *big.Int
 
 	businessWalletAddr string
 	passphrase         string
+	tokenContracts     []common.Address
+	gasCaps            gasprice.Caps
+	sweepThresholds    map[string]decimal.Decimal
+	// hotWalletKey is the key of the business wallet, which pays withdrawals
+	// and gas top-ups. Without it withdrawals are paid from entity wallets.
+	hotWalletKey  *ecdsa.PrivateKey
+	hotWalletAddr common.Address
+	// treasury is nil when no target range is set.
+	treasury *treasury
+	alerts   *slack.Client
+
+	// inTx is set when the repositories run in a database transaction
+	// already.
+	inTx bool
+	// runTx runs the transactions of transact instead of the database when
+	// set, for repositories that are not backed by it.
+	runTx func(ctx context.Context, fn func(*EntityService) error) error
 }
 
 func MustNewEntityService(
@@ -50,11 +106,21 @@ func MustNewEntityService(
 	ethUrl,
 	passphrase,
 	businessAddr string,
+	tokenContracts []string,
+	gasCaps gasprice.Caps,
+	sweepThresholds []string,
+	hotWalletKey string,
+	coldWalletAddr string,
+	treasuryRanges []string,
+	alerts *slack.Client,
 
 	entityRepository models.EntityRepository,
 	paymentMarkRepository models.PaymentMarkRepository,
 	walletAddressRepository models.WalletRepository,
 	txRepo models.TransactionRepository,
+	apiKeyRepo models.ApiKeyRepository,
+	withdrawalRepo models.WithdrawalRepository,
+	invoiceRepo models.InvoiceRepository,
 ) internal_services.EntityService {
 	rpcClient, err := lens.NewRPCClient(rpcUrl, time.Second*5)
 	if err != nil {
@@ -71,8 +137,51 @@ func MustNewEntityService(
 		panic(err)
 	}
 
+	contracts := make([]common.Address, 0, len(tokenContracts))
+	for _, contract := range tokenContracts {
+		if !common.IsHexAddress(contract) {
+			panic(fmt.Sprintf("invalid token contract address %q", contract))
+		}
+
+		contracts = append(contracts, common.HexToAddress(contract))
+	}
+
 	setupConfig()
 
+	thresholds, err := parseSweepThresholds(sweepThresholds, contracts)
+	if err != nil {
+		panic(err)
+	}
+
+	hotKey, err := parseHotWalletKey(hotWalletKey)
+	if err != nil {
+		panic(err)
+	}
+
+	// Withdrawals are paid from the business wallet, deposits to it included.
+	var hotAddr common.Address
+	if hotKey != nil {
+		hotAddr = crypto.PubkeyToAddress(hotKey.PublicKey)
+		if addr, _, ok := parseAddress(businessAddr); !ok || addr != hotAddr {
+			panic("the hot wallet key must be the key of the business address")
+		}
+	}
+
+	// Swept deposits can only be withdrawn from the business wallet.
+	if len(thresholds) > 0 && hotKey == nil {
+		panic("sweep thresholds need the hot wallet key")
+	}
+
+	treasury, err := newTreasury(coldWalletAddr, treasuryRanges, contracts)
+	if err != nil {
+		panic(err)
+	}
+
+	// The treasury moves funds out of the business wallet, it needs its key.
+	if treasury != nil && hotKey == nil {
+		panic("treasury ranges need the hot wallet key")
+	}
+
 	return &EntityService{
 		rpcClient: rpcClient,
 		ethClient: ethClient,
@@ -82,9 +191,25 @@ func MustNewEntityService(
 		paymentMarkRepo:   paymentMarkRepository,
 		walletAddressRepo: walletAddressRepository,
 		txRepo:            txRepo,
+		apiKeyRepo:        apiKeyRepo,
+		withdrawalRepo:    withdrawalRepo,
+		invoiceRepo:       invoiceRepo,
+
+		deposits: newDepositBroker(),
+		scanMu:   &sync.Mutex{},
+		batchMu:  &sync.Mutex{},
+		sweepMu:  &sync.Mutex{},
+		nonces:   nonce.NewManager(ethClient),
 
 		businessWalletAddr: businessAddr,
 		passphrase:         passphrase,
+		tokenContracts:     contracts,
+		gasCaps:            gasCaps,
+		sweepThresholds:    thresholds,
+		hotWalletKey:       hotKey,
+		hotWalletAddr:      hotAddr,
+		treasury:           treasury,
+		alerts:             alerts,
 	}
 }
 
@@ -110,66 +235,284 @@ func (s *EntityService) Register(ctx context.Context, name string) (*models.Enti
 	return entity, nil
 }
 
-func (s *EntityService) Withdraw(
-	ctx context.Context,
-	entityName string,
-	amount decimal.Decimal,
-	receiverAddr common.Address,
-) (string, error) {
-	entity, err := s.entityRepo.GetEntityByName(ctx, entityName)
+func (s *EntityService) CreateApiKey(ctx context.Context, entityName string) (*models.ApiKey, string, error) {
+	entity, err := s.getEntityByName(ctx, entityName)
+	if err != nil {
+		return nil, "", err
+	}
+
+	apiKey, key, err := models.NewApiKey(entity.Id)
 	if err != nil {
-		return "", status.Newf(codes.Internal, "failed to get entity").Err()
+		return nil, "", status.Newf(codes.Internal, "failed to generate api key").Err()
+	}
+
+	if err := s.apiKeyRepo.Create(ctx, apiKey); err != nil {
+		return nil, "", status.Newf(codes.Internal, "failed to create api key").Err()
+	}
+
+	return apiKey, key, nil
+}
+
+func (s *EntityService) RevokeApiKey(ctx context.Context, id uuid.UUID) error {
+	if err := s.apiKeyRepo.RevokeApiKeyById(ctx, id); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return models.NewNotFoundError(models.REASON_API_KEY_NOT_FOUND, "api key", id.String())
+		}
+
+		return status.Newf(codes.Internal, "failed to revoke api key").Err()
 	}
 
-	entityPrivateKey, err := crypto.ToECDSA(entity.Wallet.PrivateKey)
+	return nil
+}
+
+func (s *EntityService) getEntityByName(ctx context.Context, name string) (*models.Entity, error) {
+	entity, err := s.entityRepo.GetEntityByName(ctx, name)
 	if err != nil {
-		return "", status.Newf(codes.Internal, "failed to get private key").Err()
+		if err == gorm.ErrRecordNotFound {
+			return nil, models.NewEntityNotFoundError(name)
+		}
+
+		return nil, status.Newf(codes.Internal, "failed to get entity").Err()
+	}
+
+	return entity, nil
+}
+
+// transact runs fn with a service whose repositories share one database
+// transaction, or with s when its repositories do already.
+func (s *EntityService) transact(ctx context.Context, fn func(*EntityService) error) error {
+	if s.inTx {
+		return fn(s)
+	}
+
+	if s.runTx != nil {
+		return s.runTx(ctx, fn)
+	}
+
+	return db.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(s.NewEntityServiceWithTx(tx))
+	})
+}
+
+func (s *EntityService) GetBalance(ctx context.Context, entityName string) (*models.WalletBalance, error) {
+	entity, err := s.getEntityByName(ctx, entityName)
+	if err != nil {
+		return nil, err
+	}
+
+	if entity.Wallet == nil {
+		return nil, status.Newf(codes.Internal, "entity has no wallet").Err()
 	}
 
 	entityWallet := common.HexToAddress(entity.WalletAddress)
-	balance, err := s.ethClient.BalanceAt(ctx, entityWallet, nil)
+	native, err := s.ethClient.BalanceAt(ctx, entityWallet, nil)
 	if err != nil {
-		return "", status.Newf(codes.Internal, "failed to get balance").Err()
+		return nil, status.Newf(codes.Internal, "failed to get balance").Err()
 	}
 
-	if balance.Cmp(amount.BigInt()) < 0 {
-		return "", status.Newf(codes.Internal, "not enough balance").Err()
+	availableNative, err := s.getLedgerBalance(ctx, entity.Id, models.AIOZ_CONTRACT_ADDRESS)
+	if err != nil {
+		return nil, err
 	}
 
-	nonce, err := s.ethClient.PendingNonceAt(ctx, entityWallet)
+	tokens := make([]*models.TokenBalance, 0, len(s.tokenContracts))
+	availableTokens := make([]*models.TokenBalance, 0, len(s.tokenContracts))
+	for _, contract := range s.tokenContracts {
+		amount, err := erc20.BalanceOf(ctx, s.ethClient, contract, entityWallet)
+		if err != nil {
+			return nil, status.Newf(codes.Internal, "failed to get token balance of %s", contract.Hex()).Err()
+		}
+
+		tokens = append(tokens, &models.TokenBalance{
+			ContractAddress: contract.Hex(),
+			Amount:          decimal.NewFromBigInt(amount, 0),
+		})
+
+		available, err := s.getLedgerBalance(ctx, entity.Id, contract.Hex())
+		if err != nil {
+			return nil, err
+		}
+
+		availableTokens = append(availableTokens, &models.TokenBalance{
+			ContractAddress: contract.Hex(),
+			Amount:          available,
+		})
+	}
+
+	return &models.WalletBalance{
+		Address:         entity.WalletAddress,
+		Native:          decimal.NewFromBigInt(native, 0),
+		Tokens:          tokens,
+		Balance:         entity.Wallet.Balance,
+		Debt:            entity.Wallet.Debt,
+		FreeBalance:     entity.Wallet.FreeBalance,
+		AvailableNative: availableNative,
+		AvailableTokens: availableTokens,
+	}, nil
+}
+
+func (s *EntityService) ListTransactions(
+	ctx context.Context,
+	entityName string,
+	filter models.TransactionFilter,
+	cursor string,
+	pageSize int,
+) ([]*models.Transaction, string, error) {
+	if entityName != "" {
+		entity, err := s.getEntityByName(ctx, entityName)
+		if err != nil {
+			return nil, "", err
+		}
+
+		filter.EntityId = entity.Id
+	}
+
+	seq, err := models.DecodeTransactionCursor(cursor)
 	if err != nil {
-		return "", status.Newf(codes.Internal, "failed to get nonce").Err()
+		return nil, "", models.NewInvalidArgumentError(models.REASON_INVALID_CURSOR, "Cursor", "invalid cursor")
+	}
+
+	if pageSize <= 0 {
+		pageSize = defaultPageSize
 	}
 
-	gasLimit := uint64(21000)
-	gasPrice, err := s.ethClient.SuggestGasPrice(ctx)
+	if pageSize > maxPageSize {
+		pageSize = maxPageSize
+	}
+
+	txs, err := s.txRepo.ListTransactions(ctx, filter, seq, pageSize+1)
 	if err != nil {
-		return "", status.Newf(codes.Internal, "failed to get gas price").Err()
+		return nil, "", status.Newf(codes.Internal, "failed to list transactions").Err()
 	}
 
-	tx := types.NewTransaction(nonce, receiverAddr, amount.BigInt(), gasLimit, gasPrice, nil)
-	signedTx, err := types.SignTx(tx, types.NewEIP155Signer(s.chainId), entityPrivateKey)
+	nextCursor := ""
+	if len(txs) > pageSize {
+		txs = txs[:pageSize]
+		nextCursor = models.EncodeTransactionCursor(txs[pageSize-1].Seq)
+	}
+
+	return txs, nextCursor, nil
+}
+
+// SubscribeDeposits calls handler for every deposit recorded after cursor, in
+// the order they were recorded, until ctx is done or handler fails. An empty
+// cursor starts with the next deposit. A deposit of the suspense queue is
+// delivered again once it is assigned to an entity.
+func (s *EntityService) SubscribeDeposits(
+	ctx context.Context,
+	entityName string,
+	contractAddress string,
+	cursor string,
+	handler func(*models.Transaction) error,
+) error {
+	filter := models.TransactionFilter{
+		Type:            models.CONTRACT_IN_TYPE,
+		ContractAddress: contractAddress,
+	}
+
+	if entityName != "" {
+		entity, err := s.getEntityByName(ctx, entityName)
+		if err != nil {
+			return err
+		}
+
+		filter.EntityId = entity.Id
+	}
+
+	notify, unsubscribe := s.deposits.subscribe()
+	defer unsubscribe()
+
+	lastSeq, err := models.DecodeTransactionCursor(cursor)
 	if err != nil {
-		return "", status.Newf(codes.Internal, "failed to sign transaction").Err()
+		return models.NewInvalidArgumentError(models.REASON_INVALID_CURSOR, "Cursor", "invalid cursor")
 	}
 
-	if err := s.ethClient.SendTransaction(ctx, signedTx); err != nil {
-		return "", status.Newf(codes.Internal, "failed to send transaction").Err()
+	if cursor == "" {
+		lastSeq, err = s.txRepo.GetLatestTransactionSeq(ctx)
+		if err != nil {
+			return status.Newf(codes.Internal, "failed to get latest transaction").Err()
+		}
 	}
 
-	return signedTx.Hash().Hex(), nil
+	ticker := time.NewTicker(subscriptionPollInterval)
+	defer ticker.Stop()
+
+	for {
+		txs, err := s.txRepo.ListTransactionsAfter(ctx, filter, lastSeq, subscriptionBatchSize)
+		if err != nil {
+			return status.Newf(codes.Internal, "failed to list deposits").Err()
+		}
+
+		for _, tx := range txs {
+			if err := handler(tx); err != nil {
+				return err
+			}
+
+			lastSeq = tx.Seq
+		}
+
+		if len(txs) == subscriptionBatchSize {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-notify:
+		case <-ticker.C:
+		}
+	}
 }
 
 func (s EntityService) WatchTransaction(ctx context.Context) error {
+	s.scanMu.Lock()
+	defer s.scanMu.Unlock()
+
+	scanStart := time.Now().UTC().Unix()
 	chainStatus, err := s.rpcClient.Status(ctx)
 	if err != nil {
 		return status.Newf(codes.Internal, "failed to get status").Err()
 	}
 
 	latestBlock := chainStatus.SyncInfo.LatestBlockHeight
+	paymentMark, err := s.getOrCreatePaymentMark(ctx, latestBlock)
+	if err != nil {
+		return err
+	}
+
+	if paymentMark.Paused {
+		return nil
+	}
+
+	fromBlock := paymentMark.BlockNumber
+	toBlock := fromBlock + 100
+	if toBlock > latestBlock {
+		toBlock = latestBlock
+	}
+
+	if _, err := s.scanBlocks(ctx, fromBlock, toBlock); err != nil {
+		return err
+	}
+
+	if err := s.paymentMarkRepo.UpdatePaymentMarkByChainId(ctx, s.chainId.Int64(), toBlock); err != nil {
+		return status.Newf(codes.Internal, "failed to update payment mark").Err()
+	}
+
+	// Invoices expire once every block mined before their expiry was scanned,
+	// so that a payment made in time is never missed.
+	if toBlock == latestBlock {
+		if _, err := s.invoiceRepo.ExpireInvoices(ctx, scanStart); err != nil {
+			return status.Newf(codes.Internal, "failed to expire invoices").Err()
+		}
+	}
+
+	return nil
+}
+
+func (s EntityService) getOrCreatePaymentMark(ctx context.Context, latestBlock int64) (*models.PaymentMark, error) {
 	paymentMark, err := s.paymentMarkRepo.GetPaymentMarkByChainId(ctx, s.chainId.Int64())
 	if err != nil && err != gorm.ErrRecordNotFound {
-		return status.Newf(codes.Internal, "failed to get payment mark").Err()
+		return nil, status.Newf(codes.Internal, "failed to get payment mark").Err()
 	}
 
 	if paymentMark == nil {
@@ -179,37 +522,34 @@ func (s EntityService) WatchTransaction(ctx context.Context) error {
 		}
 
 		if err := s.paymentMarkRepo.Create(ctx, paymentMark); err != nil {
-			return status.Newf(codes.Internal, "failed to create payment mark").Err()
+			return nil, status.Newf(codes.Internal, "failed to create payment mark").Err()
 		}
 	}
 
-	fromBlock := paymentMark.BlockNumber
-	toBlock := fromBlock + 100
-	if toBlock > latestBlock {
-		toBlock = latestBlock
-	}
+	return paymentMark, nil
+}
 
+// scanBlocks records the deposits to entity wallets found between fromBlock
+// and toBlock, both inclusive, and returns how many new deposits it recorded.
+func (s EntityService) scanBlocks(ctx context.Context, fromBlock, toBlock int64) (int, error) {
 	walletAddresses, err := s.walletAddressRepo.GetActiveWallets(ctx)
 	if err != nil {
-		return status.Newf(codes.Internal, "failed to get active wallets").Err()
+		return 0, status.Newf(codes.Internal, "failed to get active wallets").Err()
 	}
 
 	if len(walletAddresses) == 0 {
-		if err := s.paymentMarkRepo.UpdatePaymentMarkByChainId(ctx, s.chainId.Int64(), toBlock); err != nil {
-			return status.Newf(codes.Internal, "failed to update payment mark").Err()
-		}
-
-		return nil
+		return 0, nil
 	}
 
 	blockQuery := fmt.Sprintf("tx.height >= %d AND tx.height <= %d", fromBlock, toBlock)
 	page := 1
 	pageSize := 100
 	total := 0
+	recorded := 0
 	for {
 		resp, err := s.rpcClient.TxSearch(ctx, blockQuery, false, &page, &pageSize, "asc")
 		if err != nil {
-			return status.Newf(codes.Internal, "failed to get tx search").Err()
+			return recorded, status.Newf(codes.Internal, "failed to get tx search").Err()
 		}
 
 		if len(resp.Txs) == 0 {
@@ -217,9 +557,14 @@ func (s EntityService) WatchTransaction(ctx context.Context) error {
 		}
 
 		for _, tx := range resp.Txs {
-			if err := s.handleTransaction(ctx, tx, walletAddresses); err != nil {
+			created, err := s.handleTransaction(ctx, tx, walletAddresses)
+			if err != nil {
 				fmt.Println("Handle transaction error: ", err)
 			}
+
+			if created {
+				recorded++
+			}
 		}
 
 		total += len(resp.Txs)
@@ -227,22 +572,19 @@ func (s EntityService) WatchTransaction(ctx context.Context) error {
 			break
 		}
 
+		page++
 	}
 
-	if err := s.paymentMarkRepo.UpdatePaymentMarkByChainId(ctx, s.chainId.Int64(), toBlock); err != nil {
-		return status.Newf(codes.Internal, "failed to update payment mark").Err()
-	}
-
-	return nil
+	return recorded, nil
 }
 
 func (s EntityService) handleTransaction(
 	ctx context.Context,
 	tx *coretypes.ResultTx,
 	wallets []*models.Wallet,
-) error {
+) (bool, error) {
 	if tx == nil {
-		return nil
+		return false, nil
 	}
 
 	var (
@@ -279,7 +621,7 @@ func (s EntityService) handleTransaction(
 			for _, a := range event.Attributes {
 				if string(a.Key) == "txLog" {
 					if err := json.Unmarshal(a.Value, &txLog); err != nil {
-						return status.Newf(codes.Internal, "failed to unmarshal tx log").Err()
+						return false, status.Newf(codes.Internal, "failed to unmarshal tx log").Err()
 					}
 				}
 			}
@@ -296,7 +638,7 @@ func (s EntityService) handleTransaction(
 				} else {
 					bytes := common.RightPadBytes(common.FromHex(txLog.Topics[i]), 32)
 					receiverAddr = common.BytesToAddress(bytes).String()
-					if !isValidAddress(receiverAddr, wallets) && receiverAddr != s.businessWalletAddr {
+					if !isValidAddress(receiverAddr, wallets) && !s.isBusinessAddress(receiverAddr) {
 						receiverAddr = ""
 						continue
 					}
@@ -306,7 +648,7 @@ func (s EntityService) handleTransaction(
 			bigIntAmount = new(big.Int).SetBytes(common.TrimLeftZeroes(txLog.Data))
 			amount, err = decimal.NewFromString(bigIntAmount.String())
 			if err != nil {
-				return err
+				return false, err
 			}
 		}
 	}
@@ -334,7 +676,7 @@ func (s EntityService) handleTransaction(
 
 						receiverAddr = recipientAcc.String()
 						if !isValidAddress(receiverAddr, wallets) &&
-							receiverAddr != s.businessWalletAddr {
+							!s.isBusinessAddress(receiverAddr) {
 							recipientAcc = nil
 							receiverAddr = ""
 							continue
@@ -360,28 +702,66 @@ func (s EntityService) handleTransaction(
 
 	} else {
 		if senderAddr == "" || receiverAddr == "" || bigIntAmount == nil {
-			return nil
+			return false, nil
 		}
 
-		if !isValidAddress(receiverAddr, wallets) && receiverAddr != s.businessWalletAddr {
-			return nil
+		if !isValidAddress(receiverAddr, wallets) && !s.isBusinessAddress(receiverAddr) {
+			return false, nil
 		}
 
 		blockNumber = txLog.BlockNumber
 	}
 
-	entity, err := s.entityRepo.GetEntityByWalletAddress(ctx, receiverAddr)
+	// Gas top-ups are recorded when they are sent, they are not deposits. Any
+	// other transfer of the hot wallet to an entity wallet is.
+	if s.hasHotWallet() && senderAddr == s.hotWalletAddr.Hex() && ethTxHash != "" {
+		_, err := s.txRepo.GetTransactionByEvmHashAndType(ctx, ethTxHash, models.CONTRACT_GAS_TOPUP_TYPE)
+		if err == nil {
+			return false, nil
+		}
+
+		if err != gorm.ErrRecordNotFound {
+			return false, status.Newf(codes.Internal, "failed to get gas top-up").Err()
+		}
+	}
+
+	// Invoices take the payments mined before they expire.
+	header, err := s.ethClient.HeaderByNumber(ctx, big.NewInt(tx.Height))
 	if err != nil {
-		return status.Newf(codes.Internal, "failed to get entity").Err()
+		return false, status.Newf(codes.Internal, "failed to get block").Err()
+	}
+
+	paidAt := int64(header.Time)
+
+	// Payments to the shared business wallet are attributed by memo, those
+	// sent from entity wallets are sweeps recorded when they are sent.
+	var entityId uuid.UUID
+	memo := txMemo(tx.Tx)
+	if s.isBusinessAddress(receiverAddr) {
+		if isValidAddress(senderAddr, wallets) {
+			return false, nil
+		}
+
+		entityId, err = s.attributeBusinessDeposit(ctx, memo, paidAt)
+		if err != nil {
+			return false, err
+		}
+	} else {
+		entity, err := s.entityRepo.GetEntityByWalletAddress(ctx, receiverAddr)
+		if err != nil {
+			return false, status.Newf(codes.Internal, "failed to get entity").Err()
+		}
+
+		entityId = entity.Id
 	}
 
 	if contractAddr == "" {
-		contractAddr = "aioz"
+		contractAddr = models.AIOZ_CONTRACT_ADDRESS
 	}
 
 	transaction := models.Transaction{
 		Id:              uuid.New(),
-		EntityId:        entity.Id,
+		EntityId:        entityId,
 		CosmosHash:      cosmosTxHash,
 		EvmHash:         ethTxHash,
 		ContractAddress: contractAddr,
@@ -393,6 +773,7 @@ func (s EntityService) handleTransaction(
 		Denom:           denom,
 		Amount:          amount,
 		Status:          models.TX_STATUS_NEW,
+		Memo:            memo,
 		CreatedAt:       time.Now().UTC().Unix(),
 		UpdatedAt:       time.Now().UTC().Unix(),
 	}
@@ -404,16 +785,23 @@ func (s EntityService) handleTransaction(
 		receiverAddr,
 	)
 	if err != nil && err != gorm.ErrRecordNotFound {
-		return err
+		return false, err
 	}
 
 	if txExisted == nil {
-		if err := s.txRepo.Create(ctx, &transaction); err != nil {
-			return err
+		if err := s.recordDeposit(ctx, &transaction, paidAt); err != nil {
+			return false, err
 		}
+
+		if entityId == uuid.Nil {
+			s.alertSuspense(ctx, &transaction)
+		}
+
+		s.deposits.publish()
+		return true, nil
 	}
 
-	return nil
+	return false, nil
 }
 
 func (s *EntityService) NewEntityServiceWithTx(tx *gorm.DB) *EntityService {
@@ -425,10 +813,28 @@ func (s *EntityService) NewEntityServiceWithTx(tx *gorm.DB) *EntityService {
 		paymentMarkRepo:   db.MustNewPaymentMarkRepository(tx, false),
 		walletAddressRepo: db.MustNewWalletRepository(tx, false),
 		txRepo:            db.MustNewTransactionRepository(tx, false),
+		apiKeyRepo:        db.MustNewApiKeyRepository(tx, false),
+		withdrawalRepo:    db.MustNewWithdrawalRepository(tx, false),
+		invoiceRepo:       db.MustNewInvoiceRepository(tx, false),
+
+		deposits: s.deposits,
+		scanMu:   s.scanMu,
+		batchMu:  s.batchMu,
+		sweepMu:  s.sweepMu,
+		nonces:   s.nonces,
 
 		chainId:            s.chainId,
 		businessWalletAddr: s.businessWalletAddr,
 		passphrase:         s.passphrase,
+		tokenContracts:     s.tokenContracts,
+		gasCaps:            s.gasCaps,
+		sweepThresholds:    s.sweepThresholds,
+		hotWalletKey:       s.hotWalletKey,
+		hotWalletAddr:      s.hotWalletAddr,
+		treasury:           s.treasury,
+		alerts:             s.alerts,
+
+		inTx: true,
 	}
 }
 
@@ -0,0 +1,185 @@
+package services
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"github.com/vangxitrum/payment-host/internal/common/aiozcoin"
+	"github.com/vangxitrum/payment-host/internal/common/nonce"
+	"github.com/vangxitrum/payment-host/internal/models"
+)
+
+const testPassphrase = "passphrase"
+
+// testEnv is a service paying withdrawals from a hot wallet, backed by a fake
+// node and by fake repositories sharing one store.
+type testEnv struct {
+	service        *EntityService
+	backend        *fakeBackend
+	store          *fakeStore
+	entityRepo     *fakeEntityRepository
+	txRepo         *fakeTransactionRepository
+	withdrawalRepo *fakeWithdrawalRepository
+	invoiceRepo    *fakeInvoiceRepository
+	hotKey         *ecdsa.PrivateKey
+	hotAddr        common.Address
+}
+
+func newTestEnv(t *testing.T) *testEnv {
+	t.Helper()
+
+	hotKey, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store := newFakeStore()
+	env := &testEnv{
+		backend:        newFakeBackend(),
+		store:          store,
+		entityRepo:     &fakeEntityRepository{store: store},
+		txRepo:         &fakeTransactionRepository{store: store},
+		withdrawalRepo: &fakeWithdrawalRepository{store: store},
+		invoiceRepo:    &fakeInvoiceRepository{store: store},
+		hotKey:         hotKey,
+		hotAddr:        crypto.PubkeyToAddress(hotKey.PublicKey),
+	}
+
+	env.service = &EntityService{
+		ethClient: env.backend,
+
+		entityRepo:     env.entityRepo,
+		txRepo:         env.txRepo,
+		withdrawalRepo: env.withdrawalRepo,
+		invoiceRepo:    env.invoiceRepo,
+
+		deposits: newDepositBroker(),
+		scanMu:   &sync.Mutex{},
+		batchMu:  &sync.Mutex{},
+		sweepMu:  &sync.Mutex{},
+		nonces:   nonce.NewManager(env.backend),
+
+		chainId:            big.NewInt(1),
+		businessWalletAddr: env.hotAddr.Hex(),
+		passphrase:         testPassphrase,
+		sweepThresholds:    map[string]decimal.Decimal{},
+		hotWalletKey:       hotKey,
+		hotWalletAddr:      env.hotAddr,
+
+		runTx: env.runTx,
+	}
+
+	return env
+}
+
+// runTx runs fn with repositories writing in one transaction of the store,
+// which is undone when fn fails.
+func (e *testEnv) runTx(_ context.Context, fn func(*EntityService) error) error {
+	e.store.txMu.Lock()
+	defer e.store.txMu.Unlock()
+
+	tx := &fakeTx{}
+	txService := *e.service
+	txService.inTx = true
+	txService.entityRepo = &fakeEntityRepository{store: e.store, tx: tx}
+	txService.txRepo = &fakeTransactionRepository{store: e.store, tx: tx}
+	txService.withdrawalRepo = &fakeWithdrawalRepository{store: e.store, tx: tx}
+	txService.invoiceRepo = &fakeInvoiceRepository{store: e.store, tx: tx}
+
+	if err := fn(&txService); err != nil {
+		e.store.rollback(tx)
+		return err
+	}
+
+	return nil
+}
+
+func (e *testEnv) addEntity(t *testing.T, name string) *models.Entity {
+	t.Helper()
+
+	wallet, err := models.NewWallet(testPassphrase)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entity := models.NewEntity(name, wallet)
+	if err := e.entityRepo.Create(context.Background(), entity); err != nil {
+		t.Fatal(err)
+	}
+
+	return entity
+}
+
+// deposit records a deposit of amount attoaioz to the wallet of entity.
+func (e *testEnv) deposit(t *testing.T, entity *models.Entity, amount int64) *models.Transaction {
+	t.Helper()
+
+	deposit := &models.Transaction{
+		Id:              uuid.New(),
+		EntityId:        entity.Id,
+		ContractAddress: models.AIOZ_CONTRACT_ADDRESS,
+		From:            newAddress().Hex(),
+		To:              entity.WalletAddress,
+		Type:            models.CONTRACT_IN_TYPE,
+		Denom:           aiozcoin.DefaultDenom,
+		Amount:          decimal.NewFromInt(amount),
+		Status:          models.TX_STATUS_NEW,
+		CreatedAt:       time.Now().UTC().Unix(),
+		UpdatedAt:       time.Now().UTC().Unix(),
+	}
+	if err := e.txRepo.Create(context.Background(), deposit); err != nil {
+		t.Fatal(err)
+	}
+
+	return deposit
+}
+
+func (e *testEnv) ledger(t *testing.T, entity *models.Entity) int64 {
+	t.Helper()
+
+	balance, err := e.txRepo.GetLedgerBalance(context.Background(), entity.Id, models.AIOZ_CONTRACT_ADDRESS)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return balance.IntPart()
+}
+
+// newAddress returns an address no wallet of the tests uses.
+func newAddress() common.Address {
+	return common.BytesToAddress(crypto.Keccak256([]byte(uuid.NewString())))
+}
+
+// withdrawParams withdraws amount attoaioz of entity to a new address.
+func withdrawParams(entity *models.Entity, amount int64, requestedBy string) *models.WithdrawParams {
+	return &models.WithdrawParams{
+		EntityName:      entity.Name,
+		Amount:          decimal.NewFromInt(amount),
+		Denom:           aiozcoin.DefaultDenom,
+		ReceiverAddress: newAddress().Hex(),
+		RequestedBy:     requestedBy,
+	}
+}
+
+func requireReason(t *testing.T, err error, reason string) {
+	t.Helper()
+
+	var e *models.Error
+	if !errors.As(err, &e) {
+		t.Fatalf("err = %v, want a %s error", err, reason)
+	}
+
+	if e.Reason != reason {
+		t.Fatalf("reason = %s (%s), want %s", e.Reason, e.Message, reason)
+	}
+}
@@ -4,8 +4,7 @@ import (
 	"context"
 	"time"
 
-	"github.com/ethereum/go-ethereum/common"
-	"github.com/shopspring/decimal"
+	"github.com/google/uuid"
 	"github.com/vangxitrum/payment-host/internal/models"
 	internal_services "github.com/vangxitrum/payment-host/internal/services"
 	"github.com/vangxitrum/payment-host/internal/utils"
@@ -33,12 +32,236 @@ func (s *EntityLogService) Register(ctx context.Context, name string) (entity *m
 	return s.next.Register(ctx, name)
 }
 
-func (s *EntityLogService) Withdraw(ctx context.Context, entityName string, amount decimal.Decimal, receiverAddress common.Address) (txHash string, err error) {
+func (s *EntityLogService) CreateApiKey(ctx context.Context, entityName string) (apiKey *models.ApiKey, key string, err error) {
+	defer func(start time.Time) {
+		s.logFunc(start, "CreateApiKey", err)
+	}(time.Now().UTC())
+
+	return s.next.CreateApiKey(ctx, entityName)
+}
+
+func (s *EntityLogService) RevokeApiKey(ctx context.Context, id uuid.UUID) (err error) {
+	defer func(start time.Time) {
+		s.logFunc(start, "RevokeApiKey", err)
+	}(time.Now().UTC())
+
+	return s.next.RevokeApiKey(ctx, id)
+}
+
+func (s *EntityLogService) Withdraw(ctx context.Context, params *models.WithdrawParams) (request *models.WithdrawalRequest, err error) {
 	defer func(start time.Time) {
 		s.logFunc(start, "Withdraw", err)
 	}(time.Now().UTC())
 
-	return s.next.Withdraw(ctx, entityName, amount, receiverAddress)
+	return s.next.Withdraw(ctx, params)
+}
+
+func (s *EntityLogService) QuoteWithdraw(ctx context.Context, params *models.WithdrawParams) (quote *models.WithdrawalQuote, err error) {
+	defer func(start time.Time) {
+		s.logFunc(start, "QuoteWithdraw", err)
+	}(time.Now().UTC())
+
+	return s.next.QuoteWithdraw(ctx, params)
+}
+
+func (s *EntityLogService) ApproveWithdrawal(ctx context.Context, id uuid.UUID, approver string) (request *models.WithdrawalRequest, err error) {
+	defer func(start time.Time) {
+		s.logFunc(start, "ApproveWithdrawal", err)
+	}(time.Now().UTC())
+
+	return s.next.ApproveWithdrawal(ctx, id, approver)
+}
+
+func (s *EntityLogService) RejectWithdrawal(ctx context.Context, id uuid.UUID, approver string, reason string) (request *models.WithdrawalRequest, err error) {
+	defer func(start time.Time) {
+		s.logFunc(start, "RejectWithdrawal", err)
+	}(time.Now().UTC())
+
+	return s.next.RejectWithdrawal(ctx, id, approver, reason)
+}
+
+func (s *EntityLogService) GetWithdrawalRequest(ctx context.Context, entityName string, id uuid.UUID) (request *models.WithdrawalRequest, err error) {
+	defer func(start time.Time) {
+		s.logFunc(start, "GetWithdrawalRequest", err)
+	}(time.Now().UTC())
+
+	return s.next.GetWithdrawalRequest(ctx, entityName, id)
+}
+
+func (s *EntityLogService) ListWithdrawalRequests(ctx context.Context, status string, pageSize int) (requests []*models.WithdrawalRequest, err error) {
+	defer func(start time.Time) {
+		s.logFunc(start, "ListWithdrawalRequests", err)
+	}(time.Now().UTC())
+
+	return s.next.ListWithdrawalRequests(ctx, status, pageSize)
+}
+
+func (s *EntityLogService) BatchWithdraw(ctx context.Context, params *models.BatchWithdrawParams) (batch *models.WithdrawalBatch, err error) {
+	defer func(start time.Time) {
+		s.logFunc(start, "BatchWithdraw", err)
+	}(time.Now().UTC())
+
+	return s.next.BatchWithdraw(ctx, params)
+}
+
+func (s *EntityLogService) GetWithdrawalBatch(ctx context.Context, entityName string, id uuid.UUID) (batch *models.WithdrawalBatch, err error) {
+	defer func(start time.Time) {
+		s.logFunc(start, "GetWithdrawalBatch", err)
+	}(time.Now().UTC())
+
+	return s.next.GetWithdrawalBatch(ctx, entityName, id)
+}
+
+func (s *EntityLogService) ProcessWithdrawalBatches(ctx context.Context) (err error) {
+	defer func(start time.Time) {
+		s.logFunc(start, "ProcessWithdrawalBatches", err)
+	}(time.Now().UTC())
+
+	return s.next.ProcessWithdrawalBatches(ctx)
+}
+
+func (s *EntityLogService) SetWithdrawalPolicy(ctx context.Context, params *models.WithdrawalPolicyParams) (policy *models.WithdrawalPolicy, err error) {
+	defer func(start time.Time) {
+		s.logFunc(start, "SetWithdrawalPolicy", err)
+	}(time.Now().UTC())
+
+	return s.next.SetWithdrawalPolicy(ctx, params)
+}
+
+func (s *EntityLogService) AddAllowedDestination(ctx context.Context, entityName, address, label string) (destination *models.AllowedDestination, err error) {
+	defer func(start time.Time) {
+		s.logFunc(start, "AddAllowedDestination", err)
+	}(time.Now().UTC())
+
+	return s.next.AddAllowedDestination(ctx, entityName, address, label)
+}
+
+func (s *EntityLogService) RemoveAllowedDestination(ctx context.Context, entityName, address string) (err error) {
+	defer func(start time.Time) {
+		s.logFunc(start, "RemoveAllowedDestination", err)
+	}(time.Now().UTC())
+
+	return s.next.RemoveAllowedDestination(ctx, entityName, address)
+}
+
+func (s *EntityLogService) ListAllowedDestinations(ctx context.Context, entityName string) (destinations []*models.AllowedDestination, err error) {
+	defer func(start time.Time) {
+		s.logFunc(start, "ListAllowedDestinations", err)
+	}(time.Now().UTC())
+
+	return s.next.ListAllowedDestinations(ctx, entityName)
+}
+
+func (s *EntityLogService) CreateInvoice(ctx context.Context, params *models.InvoiceParams) (invoice *models.Invoice, err error) {
+	defer func(start time.Time) {
+		s.logFunc(start, "CreateInvoice", err)
+	}(time.Now().UTC())
+
+	return s.next.CreateInvoice(ctx, params)
+}
+
+func (s *EntityLogService) GetInvoice(ctx context.Context, entityName string, id uuid.UUID) (invoice *models.Invoice, err error) {
+	defer func(start time.Time) {
+		s.logFunc(start, "GetInvoice", err)
+	}(time.Now().UTC())
+
+	return s.next.GetInvoice(ctx, entityName, id)
+}
+
+func (s *EntityLogService) CancelInvoice(ctx context.Context, entityName string, id uuid.UUID) (invoice *models.Invoice, err error) {
+	defer func(start time.Time) {
+		s.logFunc(start, "CancelInvoice", err)
+	}(time.Now().UTC())
+
+	return s.next.CancelInvoice(ctx, entityName, id)
+}
+
+func (s *EntityLogService) ReconcileNonces(ctx context.Context) (err error) {
+	defer func(start time.Time) {
+		s.logFunc(start, "ReconcileNonces", err)
+	}(time.Now().UTC())
+
+	return s.next.ReconcileNonces(ctx)
+}
+
+func (s *EntityLogService) SweepDeposits(ctx context.Context) (err error) {
+	defer func(start time.Time) {
+		s.logFunc(start, "SweepDeposits", err)
+	}(time.Now().UTC())
+
+	return s.next.SweepDeposits(ctx)
+}
+
+func (s *EntityLogService) RebalanceTreasury(ctx context.Context) (err error) {
+	defer func(start time.Time) {
+		s.logFunc(start, "RebalanceTreasury", err)
+	}(time.Now().UTC())
+
+	return s.next.RebalanceTreasury(ctx)
+}
+
+func (s *EntityLogService) TrackWithdrawals(ctx context.Context) (err error) {
+	defer func(start time.Time) {
+		s.logFunc(start, "TrackWithdrawals", err)
+	}(time.Now().UTC())
+
+	return s.next.TrackWithdrawals(ctx)
+}
+
+func (s *EntityLogService) SpeedUpTransaction(ctx context.Context, id uuid.UUID) (tx *models.Transaction, err error) {
+	defer func(start time.Time) {
+		s.logFunc(start, "SpeedUpTransaction", err)
+	}(time.Now().UTC())
+
+	return s.next.SpeedUpTransaction(ctx, id)
+}
+
+func (s *EntityLogService) CancelTransaction(ctx context.Context, id uuid.UUID) (tx *models.Transaction, err error) {
+	defer func(start time.Time) {
+		s.logFunc(start, "CancelTransaction", err)
+	}(time.Now().UTC())
+
+	return s.next.CancelTransaction(ctx, id)
+}
+
+func (s *EntityLogService) ListSuspenseTransactions(ctx context.Context, cursor string, pageSize int) (txs []*models.Transaction, nextCursor string, err error) {
+	defer func(start time.Time) {
+		s.logFunc(start, "ListSuspenseTransactions", err)
+	}(time.Now().UTC())
+
+	return s.next.ListSuspenseTransactions(ctx, cursor, pageSize)
+}
+
+func (s *EntityLogService) AssignSuspenseTransaction(ctx context.Context, id uuid.UUID, entityName string) (tx *models.Transaction, err error) {
+	defer func(start time.Time) {
+		s.logFunc(start, "AssignSuspenseTransaction", err)
+	}(time.Now().UTC())
+
+	return s.next.AssignSuspenseTransaction(ctx, id, entityName)
+}
+
+func (s *EntityLogService) GetBalance(ctx context.Context, entityName string) (balance *models.WalletBalance, err error) {
+	defer func(start time.Time) {
+		s.logFunc(start, "GetBalance", err)
+	}(time.Now().UTC())
+
+	return s.next.GetBalance(ctx, entityName)
+}
+
+func (s *EntityLogService) ListTransactions(ctx context.Context, entityName string, filter models.TransactionFilter, cursor string, pageSize int) (txs []*models.Transaction, nextCursor string, err error) {
+	defer func(start time.Time) {
+		s.logFunc(start, "ListTransactions", err)
+	}(time.Now().UTC())
+
+	return s.next.ListTransactions(ctx, entityName, filter, cursor, pageSize)
+}
+
+func (s *EntityLogService) SubscribeDeposits(ctx context.Context, entityName string, contractAddress string, cursor string, handler func(*models.Transaction) error) (err error) {
+	defer func(start time.Time) {
+		s.logFunc(start, "SubscribeDeposits", err)
+	}(time.Now().UTC())
+
+	return s.next.SubscribeDeposits(ctx, entityName, contractAddress, cursor, handler)
 }
 
 func (s *EntityLogService) WatchTransaction(ctx context.Context) (err error) {
@@ -48,3 +271,35 @@ func (s *EntityLogService) WatchTransaction(ctx context.Context) (err error) {
 
 	return s.next.WatchTransaction(ctx)
 }
+
+func (s *EntityLogService) GetScannerStatus(ctx context.Context) (scannerStatus *models.ScannerStatus, err error) {
+	defer func(start time.Time) {
+		s.logFunc(start, "GetScannerStatus", err)
+	}(time.Now().UTC())
+
+	return s.next.GetScannerStatus(ctx)
+}
+
+func (s *EntityLogService) SetPaymentMark(ctx context.Context, blockNumber int64) (scannerStatus *models.ScannerStatus, err error) {
+	defer func(start time.Time) {
+		s.logFunc(start, "SetPaymentMark", err)
+	}(time.Now().UTC())
+
+	return s.next.SetPaymentMark(ctx, blockNumber)
+}
+
+func (s *EntityLogService) RescanBlocks(ctx context.Context, fromBlock, toBlock int64) (recorded int, err error) {
+	defer func(start time.Time) {
+		s.logFunc(start, "RescanBlocks", err)
+	}(time.Now().UTC())
+
+	return s.next.RescanBlocks(ctx, fromBlock, toBlock)
+}
+
+func (s *EntityLogService) SetScannerPaused(ctx context.Context, paused bool) (scannerStatus *models.ScannerStatus, err error) {
+	defer func(start time.Time) {
+		s.logFunc(start, "SetScannerPaused", err)
+	}(time.Now().UTC())
+
+	return s.next.SetScannerPaused(ctx, paused)
+}
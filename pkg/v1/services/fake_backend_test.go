@@ -0,0 +1,146 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// testGasPrice prices every transaction of the tests, a native transfer costs
+// testTransferFee.
+var (
+	testGasPrice    = big.NewInt(1)
+	testTransferFee = int64(nativeTransferGas)
+)
+
+// fakeBackend is a node without London, every call costs nativeTransferGas.
+type fakeBackend struct {
+	mu       sync.Mutex
+	balances map[common.Address]*big.Int
+	nonces   map[common.Address]uint64
+	sent     []*types.Transaction
+	sendErr  error
+	receipts map[common.Hash]*types.Receipt
+	// onEstimateGas is called by every gas estimate when set, tests use it to
+	// line up concurrent withdrawals after they were checked.
+	onEstimateGas func()
+}
+
+func newFakeBackend() *fakeBackend {
+	return &fakeBackend{
+		balances: map[common.Address]*big.Int{},
+		nonces:   map[common.Address]uint64{},
+		receipts: map[common.Hash]*types.Receipt{},
+	}
+}
+
+func (b *fakeBackend) setBalance(account common.Address, balance int64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.balances[account] = big.NewInt(balance)
+}
+
+// mine gives tx a receipt of status in block 1 that used all its gas, and
+// uses its nonce.
+func (b *fakeBackend) mine(tx *types.Transaction, status uint64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if from, err := types.Sender(types.LatestSignerForChainID(tx.ChainId()), tx); err == nil && b.nonces[from] <= tx.Nonce() {
+		b.nonces[from] = tx.Nonce() + 1
+	}
+
+	b.receipts[tx.Hash()] = &types.Receipt{
+		Status:            status,
+		TxHash:            tx.Hash(),
+		GasUsed:           tx.Gas(),
+		EffectiveGasPrice: tx.GasPrice(),
+		BlockNumber:       big.NewInt(1),
+	}
+}
+
+func (b *fakeBackend) sentTransactions() []*types.Transaction {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return append([]*types.Transaction(nil), b.sent...)
+}
+
+func (b *fakeBackend) HeaderByNumber(context.Context, *big.Int) (*types.Header, error) {
+	return &types.Header{}, nil
+}
+
+func (b *fakeBackend) SuggestGasPrice(context.Context) (*big.Int, error) {
+	return testGasPrice, nil
+}
+
+func (b *fakeBackend) SuggestGasTipCap(context.Context) (*big.Int, error) {
+	return big.NewInt(0), nil
+}
+
+func (b *fakeBackend) CallContract(context.Context, ethereum.CallMsg, *big.Int) ([]byte, error) {
+	return nil, errors.New("no contracts")
+}
+
+func (b *fakeBackend) CodeAt(context.Context, common.Address, *big.Int) ([]byte, error) {
+	return nil, nil
+}
+
+func (b *fakeBackend) TransactionReceipt(_ context.Context, hash common.Hash) (*types.Receipt, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if receipt, ok := b.receipts[hash]; ok {
+		return receipt, nil
+	}
+
+	return nil, ethereum.NotFound
+}
+
+func (b *fakeBackend) BalanceAt(_ context.Context, account common.Address, _ *big.Int) (*big.Int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if balance, ok := b.balances[account]; ok {
+		return new(big.Int).Set(balance), nil
+	}
+
+	return big.NewInt(0), nil
+}
+
+func (b *fakeBackend) NonceAt(_ context.Context, account common.Address, _ *big.Int) (uint64, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.nonces[account], nil
+}
+
+func (b *fakeBackend) PendingNonceAt(ctx context.Context, account common.Address) (uint64, error) {
+	return b.NonceAt(ctx, account, nil)
+}
+
+func (b *fakeBackend) EstimateGas(context.Context, ethereum.CallMsg) (uint64, error) {
+	if b.onEstimateGas != nil {
+		b.onEstimateGas()
+	}
+
+	return nativeTransferGas, nil
+}
+
+func (b *fakeBackend) SendTransaction(_ context.Context, tx *types.Transaction) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.sendErr != nil {
+		return b.sendErr
+	}
+
+	b.sent = append(b.sent, tx)
+	return nil
+}
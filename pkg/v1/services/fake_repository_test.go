@@ -0,0 +1,909 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+
+	"github.com/vangxitrum/payment-host/internal/models"
+)
+
+// fakeStore holds the rows of the fake repositories. Its transactions run one
+// at a time, as if each locked every row it touches, and undo their writes
+// when they fail. Writes outside a transaction are final at once.
+type fakeStore struct {
+	// txMu is held for the whole of a transaction.
+	txMu sync.Mutex
+	// mu guards the rows.
+	mu sync.Mutex
+
+	entities     map[uuid.UUID]*models.Entity
+	transactions []*models.Transaction
+	seq          int64
+	requests     map[uuid.UUID]*models.WithdrawalRequest
+	policies     map[string]*models.WithdrawalPolicy
+	destinations map[string]*models.AllowedDestination
+	quotes       map[uuid.UUID]*models.WithdrawalQuote
+	batches      map[uuid.UUID]*models.WithdrawalBatch
+	invoices     map[uuid.UUID]*models.Invoice
+
+	// createTxErr fails every insert of a transaction when set.
+	createTxErr error
+	// onWithdrawalTotals is called by every sum of withdrawals made outside a
+	// transaction when set, tests use it to line up concurrent withdrawals
+	// after their limits were checked.
+	onWithdrawalTotals func()
+}
+
+func newFakeStore() *fakeStore {
+	return &fakeStore{
+		entities:     map[uuid.UUID]*models.Entity{},
+		requests:     map[uuid.UUID]*models.WithdrawalRequest{},
+		policies:     map[string]*models.WithdrawalPolicy{},
+		destinations: map[string]*models.AllowedDestination{},
+		quotes:       map[uuid.UUID]*models.WithdrawalQuote{},
+		batches:      map[uuid.UUID]*models.WithdrawalBatch{},
+		invoices:     map[uuid.UUID]*models.Invoice{},
+	}
+}
+
+// fakeTx collects how to undo the writes of a transaction.
+type fakeTx struct {
+	undo []func()
+}
+
+// onRollback registers fn to undo a write. Writes made without a transaction
+// can not be undone, tx is nil for them.
+func (tx *fakeTx) onRollback(fn func()) {
+	if tx != nil {
+		tx.undo = append(tx.undo, fn)
+	}
+}
+
+func (s *fakeStore) rollback(tx *fakeTx) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := len(tx.undo) - 1; i >= 0; i-- {
+		tx.undo[i]()
+	}
+}
+
+// restoreRow returns the undo of an update of row.
+func restoreRow[T any](row *T) func() {
+	old := *row
+	return func() { *row = old }
+}
+
+// deleteRow returns the undo of an insert of key into rows.
+func deleteRow[K comparable, V any](rows map[K]V, key K) func() {
+	return func() { delete(rows, key) }
+}
+
+type fakeEntityRepository struct {
+	store *fakeStore
+	tx    *fakeTx
+}
+
+func (r *fakeEntityRepository) Create(_ context.Context, entity *models.Entity) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	stored := *entity
+	r.store.entities[entity.Id] = &stored
+	r.tx.onRollback(deleteRow(r.store.entities, entity.Id))
+	return nil
+}
+
+func (r *fakeEntityRepository) find(match func(*models.Entity) bool) (*models.Entity, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for _, entity := range r.store.entities {
+		if match(entity) {
+			stored := *entity
+			return &stored, nil
+		}
+	}
+
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakeEntityRepository) GetEntityById(_ context.Context, id uuid.UUID) (*models.Entity, error) {
+	return r.find(func(entity *models.Entity) bool { return entity.Id == id })
+}
+
+func (r *fakeEntityRepository) GetEntityByName(_ context.Context, name string) (*models.Entity, error) {
+	return r.find(func(entity *models.Entity) bool { return entity.Name == name })
+}
+
+func (r *fakeEntityRepository) GetEntityByWalletAddress(_ context.Context, walletAddress string) (*models.Entity, error) {
+	return r.find(func(entity *models.Entity) bool { return entity.WalletAddress == walletAddress })
+}
+
+// LockEntity fails outside a transaction, where the lock would be released as
+// soon as it is taken.
+func (r *fakeEntityRepository) LockEntity(_ context.Context, id uuid.UUID) error {
+	if r.tx == nil {
+		return errors.New("entity locked outside a transaction")
+	}
+
+	_, err := r.find(func(entity *models.Entity) bool { return entity.Id == id })
+	return err
+}
+
+func (r *fakeEntityRepository) DeleteEntityById(_ context.Context, id uuid.UUID) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if entity, ok := r.store.entities[id]; ok {
+		delete(r.store.entities, id)
+		r.tx.onRollback(func() { r.store.entities[id] = entity })
+	}
+
+	return nil
+}
+
+// fakeTransactionRepository keeps transactions in the order of their Seq.
+type fakeTransactionRepository struct {
+	store *fakeStore
+	tx    *fakeTx
+}
+
+func (r *fakeTransactionRepository) Create(_ context.Context, transaction *models.Transaction) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if r.store.createTxErr != nil {
+		return r.store.createTxErr
+	}
+
+	r.store.seq++
+	transaction.Seq = r.store.seq
+	stored := *transaction
+	r.store.transactions = append(r.store.transactions, &stored)
+	r.tx.onRollback(func() {
+		for i, existing := range r.store.transactions {
+			if existing == &stored {
+				r.store.transactions = append(r.store.transactions[:i], r.store.transactions[i+1:]...)
+				return
+			}
+		}
+	})
+	return nil
+}
+
+func (r *fakeTransactionRepository) filter(match func(*models.Transaction) bool) []*models.Transaction {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	var rs []*models.Transaction
+	for _, transaction := range r.store.transactions {
+		if match(transaction) {
+			stored := *transaction
+			rs = append(rs, &stored)
+		}
+	}
+
+	return rs
+}
+
+func (r *fakeTransactionRepository) first(match func(*models.Transaction) bool) (*models.Transaction, error) {
+	rs := r.filter(match)
+	if len(rs) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	return rs[0], nil
+}
+
+func (r *fakeTransactionRepository) update(id uuid.UUID, fn func(*models.Transaction)) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for _, transaction := range r.store.transactions {
+		if transaction.Id == id {
+			r.tx.onRollback(restoreRow(transaction))
+			fn(transaction)
+		}
+	}
+}
+
+func (r *fakeTransactionRepository) GetTransactionById(_ context.Context, id uuid.UUID) (*models.Transaction, error) {
+	return r.first(func(tx *models.Transaction) bool { return tx.Id == id })
+}
+
+func (r *fakeTransactionRepository) GetTransactionByHashIndexAndReceiverAddr(
+	_ context.Context,
+	hash string,
+	index int,
+	recvAddr string,
+) (*models.Transaction, error) {
+	return r.first(func(tx *models.Transaction) bool {
+		return tx.CosmosHash == hash && tx.Index == index && tx.To == recvAddr
+	})
+}
+
+func (r *fakeTransactionRepository) GetTransactionByEvmHashAndType(
+	_ context.Context,
+	hash string,
+	txType string,
+) (*models.Transaction, error) {
+	return r.first(func(tx *models.Transaction) bool {
+		return strings.EqualFold(tx.EvmHash, hash) && tx.Type == txType
+	})
+}
+
+func (r *fakeTransactionRepository) ListTransactions(
+	context.Context,
+	models.TransactionFilter,
+	int64,
+	int,
+) ([]*models.Transaction, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (r *fakeTransactionRepository) ListTransactionsAfter(
+	context.Context,
+	models.TransactionFilter,
+	int64,
+	int,
+) ([]*models.Transaction, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (r *fakeTransactionRepository) GetLatestTransactionSeq(context.Context) (int64, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	return r.store.seq, nil
+}
+
+// LockDepositSequence fails outside a transaction like LockEntity.
+func (r *fakeTransactionRepository) LockDepositSequence(context.Context) error {
+	if r.tx == nil {
+		return errors.New("deposit sequence locked outside a transaction")
+	}
+
+	return nil
+}
+
+func (r *fakeTransactionRepository) ListUnsweptDeposits(
+	_ context.Context,
+	cursor int64,
+	limit int,
+) ([]*models.Transaction, error) {
+	dropped := map[uuid.UUID]bool{}
+	for _, sweep := range r.filter(func(tx *models.Transaction) bool {
+		return tx.Type == models.CONTRACT_SWEEP_TYPE &&
+			(tx.Status == models.TX_STATUS_FAILED || tx.Status == models.TX_STATUS_REPLACED)
+	}) {
+		dropped[sweep.Id] = true
+	}
+
+	rs := r.filter(func(tx *models.Transaction) bool {
+		return tx.Type == models.CONTRACT_IN_TYPE &&
+			tx.EntityId != uuid.Nil &&
+			(tx.SweepId == uuid.Nil || dropped[tx.SweepId]) &&
+			tx.Seq > cursor
+	})
+	if len(rs) > limit {
+		rs = rs[:limit]
+	}
+
+	return rs, nil
+}
+
+// isCountedWithdrawal reports whether tx counts against the ledger and the
+// limits of its entity, like the queries of the database repository.
+func isCountedWithdrawal(tx *models.Transaction, entityId uuid.UUID, contractAddress string) bool {
+	return tx.EntityId == entityId &&
+		tx.Type == models.CONTRACT_OUT_TYPE &&
+		strings.EqualFold(tx.ContractAddress, contractAddress) &&
+		(tx.Status == models.TX_STATUS_PENDING || tx.Status == models.TX_STATUS_CONFIRMED) &&
+		(tx.ReplacesId == uuid.Nil || tx.Status == models.TX_STATUS_CONFIRMED)
+}
+
+func (r *fakeTransactionRepository) GetWithdrawalTotals(
+	_ context.Context,
+	entityId uuid.UUID,
+	contractAddress string,
+	since int64,
+) (decimal.Decimal, int64, error) {
+	if r.tx == nil && r.store.onWithdrawalTotals != nil {
+		defer r.store.onWithdrawalTotals()
+	}
+
+	rs := r.filter(func(tx *models.Transaction) bool {
+		return isCountedWithdrawal(tx, entityId, contractAddress) && tx.CreatedAt >= since
+	})
+
+	total := decimal.Zero
+	for _, tx := range rs {
+		total = total.Add(tx.Amount)
+	}
+
+	return total, int64(len(rs)), nil
+}
+
+func (r *fakeTransactionRepository) GetLedgerBalance(
+	_ context.Context,
+	entityId uuid.UUID,
+	contractAddress string,
+) (decimal.Decimal, error) {
+	total := decimal.Zero
+	for _, tx := range r.filter(func(tx *models.Transaction) bool { return true }) {
+		switch {
+		case tx.Type == models.CONTRACT_IN_TYPE:
+			if tx.EntityId == entityId && strings.EqualFold(tx.ContractAddress, contractAddress) {
+				total = total.Add(tx.Amount)
+			}
+		case isCountedWithdrawal(tx, entityId, contractAddress):
+			total = total.Sub(tx.Amount)
+			if tx.ContractAddress == models.AIOZ_CONTRACT_ADDRESS {
+				total = total.Sub(tx.Fee)
+			}
+		case tx.EntityId == entityId &&
+			tx.Type == models.CONTRACT_OUT_TYPE &&
+			tx.Status == models.TX_STATUS_FAILED &&
+			tx.BlockNumber > 0 &&
+			tx.ContractAddress == models.AIOZ_CONTRACT_ADDRESS &&
+			contractAddress == models.AIOZ_CONTRACT_ADDRESS:
+			total = total.Sub(tx.Fee)
+		}
+	}
+
+	return total, nil
+}
+
+func (r *fakeTransactionRepository) UpdateTransactionStatus(_ context.Context, id uuid.UUID, status string) error {
+	r.update(id, func(tx *models.Transaction) { tx.Status = status })
+	return nil
+}
+
+func (r *fakeTransactionRepository) UpdateTransactionReceipt(
+	_ context.Context,
+	id uuid.UUID,
+	status string,
+	blockNumber uint64,
+	fee decimal.Decimal,
+) error {
+	r.update(id, func(tx *models.Transaction) {
+		tx.Status = status
+		tx.BlockNumber = blockNumber
+		tx.Fee = fee
+	})
+	return nil
+}
+
+func (r *fakeTransactionRepository) SetTransactionsSweepId(_ context.Context, ids []uuid.UUID, sweepId uuid.UUID) error {
+	for _, id := range ids {
+		r.update(id, func(tx *models.Transaction) { tx.SweepId = sweepId })
+	}
+
+	return nil
+}
+
+func (r *fakeTransactionRepository) AssignTransaction(_ context.Context, id uuid.UUID, entityId uuid.UUID) (bool, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for _, tx := range r.store.transactions {
+		if tx.Id == id && tx.Type == models.CONTRACT_IN_TYPE && tx.EntityId == uuid.Nil {
+			r.tx.onRollback(restoreRow(tx))
+			r.store.seq++
+			tx.EntityId = entityId
+			tx.Seq = r.store.seq
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+type fakeWithdrawalRepository struct {
+	store *fakeStore
+	tx    *fakeTx
+}
+
+func policyKey(entityId uuid.UUID, contractAddress string) string {
+	return entityId.String() + "/" + contractAddress
+}
+
+func (r *fakeWithdrawalRepository) CreateWithdrawalRequest(_ context.Context, request *models.WithdrawalRequest) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	stored := *request
+	r.store.requests[request.Id] = &stored
+	r.tx.onRollback(deleteRow(r.store.requests, request.Id))
+	return nil
+}
+
+func (r *fakeWithdrawalRepository) GetWithdrawalRequestById(_ context.Context, id uuid.UUID) (*models.WithdrawalRequest, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	request, ok := r.store.requests[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	stored := *request
+	stored.Approvals = append([]*models.WithdrawalApproval(nil), request.Approvals...)
+	return &stored, nil
+}
+
+func (r *fakeWithdrawalRepository) ListWithdrawalRequests(
+	_ context.Context,
+	status string,
+	limit int,
+) ([]*models.WithdrawalRequest, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	var rs []*models.WithdrawalRequest
+	for _, request := range r.store.requests {
+		if status == "" || request.Status == status {
+			stored := *request
+			rs = append(rs, &stored)
+		}
+	}
+
+	sort.Slice(rs, func(i, j int) bool { return rs[i].CreatedAt > rs[j].CreatedAt })
+	if len(rs) > limit {
+		rs = rs[:limit]
+	}
+
+	return rs, nil
+}
+
+func (r *fakeWithdrawalRepository) UpdateWithdrawalRequestStatus(
+	_ context.Context,
+	id uuid.UUID,
+	fromStatus, toStatus string,
+) (bool, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	request, ok := r.store.requests[id]
+	if !ok || request.Status != fromStatus {
+		return false, nil
+	}
+
+	r.tx.onRollback(restoreRow(request))
+	request.Status = toStatus
+	return true, nil
+}
+
+func (r *fakeWithdrawalRepository) CompleteWithdrawalRequest(
+	_ context.Context,
+	id uuid.UUID,
+	transactionId *uuid.UUID,
+	status, failureReason string,
+) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if request, ok := r.store.requests[id]; ok {
+		r.tx.onRollback(restoreRow(request))
+		request.TransactionId = transactionId
+		request.Status = status
+		request.FailureReason = failureReason
+	}
+
+	return nil
+}
+
+func (r *fakeWithdrawalRepository) GetPendingWithdrawalTotals(context.Context) (map[string]decimal.Decimal, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	totals := map[string]decimal.Decimal{}
+	for _, request := range r.store.requests {
+		if request.Status == models.WITHDRAWAL_STATUS_REQUESTED || request.Status == models.WITHDRAWAL_STATUS_APPROVED {
+			totals[request.ContractAddress] = totals[request.ContractAddress].Add(request.Amount)
+		}
+	}
+
+	return totals, nil
+}
+
+func (r *fakeWithdrawalRepository) CreateWithdrawalApproval(_ context.Context, approval *models.WithdrawalApproval) (bool, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	request, ok := r.store.requests[approval.RequestId]
+	if !ok {
+		return false, gorm.ErrRecordNotFound
+	}
+
+	for _, existing := range request.Approvals {
+		if existing.Approver == approval.Approver {
+			return false, nil
+		}
+	}
+
+	stored := *approval
+	r.tx.onRollback(restoreRow(request))
+	request.Approvals = append(request.Approvals, &stored)
+	return true, nil
+}
+
+func (r *fakeWithdrawalRepository) GetWithdrawalPolicy(
+	_ context.Context,
+	entityId uuid.UUID,
+	contractAddress string,
+) (*models.WithdrawalPolicy, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	policy, ok := r.store.policies[policyKey(entityId, contractAddress)]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	stored := *policy
+	return &stored, nil
+}
+
+func (r *fakeWithdrawalRepository) UpsertWithdrawalPolicy(_ context.Context, policy *models.WithdrawalPolicy) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	key := policyKey(policy.EntityId, policy.ContractAddress)
+	if existing, ok := r.store.policies[key]; ok {
+		r.tx.onRollback(func() { r.store.policies[key] = existing })
+	} else {
+		r.tx.onRollback(deleteRow(r.store.policies, key))
+	}
+
+	stored := *policy
+	r.store.policies[key] = &stored
+	return nil
+}
+
+func (r *fakeWithdrawalRepository) CreateAllowedDestination(
+	_ context.Context,
+	destination *models.AllowedDestination,
+) (bool, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	key := policyKey(destination.EntityId, destination.Address)
+	if _, ok := r.store.destinations[key]; ok {
+		return false, nil
+	}
+
+	stored := *destination
+	r.store.destinations[key] = &stored
+	r.tx.onRollback(deleteRow(r.store.destinations, key))
+	return true, nil
+}
+
+func (r *fakeWithdrawalRepository) GetAllowedDestination(
+	_ context.Context,
+	entityId uuid.UUID,
+	address string,
+) (*models.AllowedDestination, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	destination, ok := r.store.destinations[policyKey(entityId, address)]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	stored := *destination
+	return &stored, nil
+}
+
+func (r *fakeWithdrawalRepository) ListAllowedDestinations(
+	_ context.Context,
+	entityId uuid.UUID,
+) ([]*models.AllowedDestination, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	var rs []*models.AllowedDestination
+	for _, destination := range r.store.destinations {
+		if destination.EntityId == entityId {
+			stored := *destination
+			rs = append(rs, &stored)
+		}
+	}
+
+	return rs, nil
+}
+
+func (r *fakeWithdrawalRepository) DeleteAllowedDestination(_ context.Context, entityId uuid.UUID, address string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	key := policyKey(entityId, address)
+	destination, ok := r.store.destinations[key]
+	if !ok {
+		return gorm.ErrRecordNotFound
+	}
+
+	delete(r.store.destinations, key)
+	r.tx.onRollback(func() { r.store.destinations[key] = destination })
+	return nil
+}
+
+func (r *fakeWithdrawalRepository) CreateWithdrawalQuote(_ context.Context, quote *models.WithdrawalQuote) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	stored := *quote
+	r.store.quotes[quote.Id] = &stored
+	r.tx.onRollback(deleteRow(r.store.quotes, quote.Id))
+	return nil
+}
+
+func (r *fakeWithdrawalRepository) GetWithdrawalQuoteById(_ context.Context, id uuid.UUID) (*models.WithdrawalQuote, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	quote, ok := r.store.quotes[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	stored := *quote
+	return &stored, nil
+}
+
+func (r *fakeWithdrawalRepository) UseWithdrawalQuote(_ context.Context, id uuid.UUID, now int64) (bool, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	quote, ok := r.store.quotes[id]
+	if !ok || quote.UsedAt != 0 || quote.ExpiresAt <= now {
+		return false, nil
+	}
+
+	r.tx.onRollback(restoreRow(quote))
+	quote.UsedAt = now
+	return true, nil
+}
+
+func copyBatch(batch *models.WithdrawalBatch) *models.WithdrawalBatch {
+	stored := *batch
+	stored.Items = make([]*models.WithdrawalBatchItem, 0, len(batch.Items))
+	for _, item := range batch.Items {
+		storedItem := *item
+		stored.Items = append(stored.Items, &storedItem)
+	}
+
+	return &stored
+}
+
+// restoreBatch returns the undo of an update of batch or of its items.
+func restoreBatch(batch *models.WithdrawalBatch) func() {
+	old := copyBatch(batch)
+	return func() { *batch = *old }
+}
+
+func (r *fakeWithdrawalRepository) CreateWithdrawalBatch(_ context.Context, batch *models.WithdrawalBatch) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	r.store.batches[batch.Id] = copyBatch(batch)
+	r.tx.onRollback(deleteRow(r.store.batches, batch.Id))
+	return nil
+}
+
+func (r *fakeWithdrawalRepository) GetWithdrawalBatchById(_ context.Context, id uuid.UUID) (*models.WithdrawalBatch, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	batch, ok := r.store.batches[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	return copyBatch(batch), nil
+}
+
+func (r *fakeWithdrawalRepository) ListWithdrawalBatches(
+	_ context.Context,
+	status string,
+	limit int,
+) ([]*models.WithdrawalBatch, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	var rs []*models.WithdrawalBatch
+	for _, batch := range r.store.batches {
+		if batch.Status == status {
+			rs = append(rs, copyBatch(batch))
+		}
+	}
+
+	sort.Slice(rs, func(i, j int) bool { return rs[i].CreatedAt < rs[j].CreatedAt })
+	if len(rs) > limit {
+		rs = rs[:limit]
+	}
+
+	return rs, nil
+}
+
+func (r *fakeWithdrawalRepository) UpdateWithdrawalBatchStatus(_ context.Context, id uuid.UUID, status string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if batch, ok := r.store.batches[id]; ok {
+		r.tx.onRollback(restoreBatch(batch))
+		batch.Status = status
+	}
+
+	return nil
+}
+
+func (r *fakeWithdrawalRepository) ClaimWithdrawalBatchItem(_ context.Context, item *models.WithdrawalBatchItem) (bool, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	batch, ok := r.store.batches[item.BatchId]
+	if !ok {
+		return false, nil
+	}
+
+	for _, existing := range batch.Items {
+		if existing.Id == item.Id && existing.Status == models.BATCH_ITEM_STATUS_QUEUED {
+			r.tx.onRollback(restoreBatch(batch))
+			existing.Status = models.BATCH_ITEM_STATUS_SENDING
+			item.Status = existing.Status
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (r *fakeWithdrawalRepository) UpdateWithdrawalBatchItem(_ context.Context, item *models.WithdrawalBatchItem) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	batch, ok := r.store.batches[item.BatchId]
+	if !ok {
+		return gorm.ErrRecordNotFound
+	}
+
+	r.tx.onRollback(restoreBatch(batch))
+	for i, existing := range batch.Items {
+		if existing.Id == item.Id {
+			stored := *item
+			batch.Items[i] = &stored
+		}
+	}
+
+	return nil
+}
+
+type fakeInvoiceRepository struct {
+	store *fakeStore
+	tx    *fakeTx
+}
+
+func (r *fakeInvoiceRepository) Create(_ context.Context, invoice *models.Invoice) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	stored := *invoice
+	r.store.invoices[invoice.Id] = &stored
+	r.tx.onRollback(deleteRow(r.store.invoices, invoice.Id))
+	return nil
+}
+
+func (r *fakeInvoiceRepository) GetInvoiceById(_ context.Context, id uuid.UUID) (*models.Invoice, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	invoice, ok := r.store.invoices[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	stored := *invoice
+	return &stored, nil
+}
+
+func (r *fakeInvoiceRepository) FindOpenInvoice(
+	_ context.Context,
+	address string,
+	contractAddress string,
+) (*models.Invoice, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	var found *models.Invoice
+	for _, invoice := range r.store.invoices {
+		if invoice.Address != address ||
+			!strings.EqualFold(invoice.ContractAddress, contractAddress) ||
+			invoice.PayByMemo ||
+			(invoice.Status != models.INVOICE_STATUS_OPEN && invoice.Status != models.INVOICE_STATUS_PARTIALLY_PAID) {
+			continue
+		}
+
+		if found == nil || invoice.CreatedAt < found.CreatedAt {
+			found = invoice
+		}
+	}
+
+	if found == nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	stored := *found
+	return &stored, nil
+}
+
+func (r *fakeInvoiceRepository) ApplyInvoicePayment(
+	_ context.Context,
+	id uuid.UUID,
+	amount decimal.Decimal,
+	paidAt int64,
+) (bool, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	invoice, ok := r.store.invoices[id]
+	if !ok ||
+		invoice.ExpiresAt <= paidAt ||
+		invoice.Status == models.INVOICE_STATUS_EXPIRED ||
+		invoice.Status == models.INVOICE_STATUS_CANCELED {
+		return false, nil
+	}
+
+	r.tx.onRollback(restoreRow(invoice))
+	invoice.PaidAmount = invoice.PaidAmount.Add(amount)
+	switch invoice.PaidAmount.Cmp(invoice.Amount) {
+	case -1:
+		invoice.Status = models.INVOICE_STATUS_PARTIALLY_PAID
+	case 0:
+		invoice.Status = models.INVOICE_STATUS_PAID
+	default:
+		invoice.Status = models.INVOICE_STATUS_OVERPAID
+	}
+
+	return true, nil
+}
+
+func (r *fakeInvoiceRepository) CancelInvoice(_ context.Context, id uuid.UUID) (bool, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	invoice, ok := r.store.invoices[id]
+	if !ok || invoice.Status != models.INVOICE_STATUS_OPEN {
+		return false, nil
+	}
+
+	r.tx.onRollback(restoreRow(invoice))
+	invoice.Status = models.INVOICE_STATUS_CANCELED
+	return true, nil
+}
+
+func (r *fakeInvoiceRepository) ExpireInvoices(_ context.Context, now int64) (int64, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	var count int64
+	for _, invoice := range r.store.invoices {
+		if invoice.ExpiresAt <= now &&
+			(invoice.Status == models.INVOICE_STATUS_OPEN || invoice.Status == models.INVOICE_STATUS_PARTIALLY_PAID) {
+			r.tx.onRollback(restoreRow(invoice))
+			invoice.Status = models.INVOICE_STATUS_EXPIRED
+			count++
+		}
+	}
+
+	return count, nil
+}
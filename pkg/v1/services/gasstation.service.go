@@ -0,0 +1,161 @@
+package services
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"log"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/vangxitrum/payment-host/internal/common/aiozcoin"
+	"github.com/vangxitrum/payment-host/internal/common/gasprice"
+	"github.com/vangxitrum/payment-host/internal/models"
+)
+
+// gasTopUpTimeout bounds the wait for a gas top-up to be mined.
+const gasTopUpTimeout = 2 * time.Minute
+
+// parseHotWalletKey parses the hex private key of the business wallet, which
+// pays withdrawals and gas top-ups. An empty key disables both.
+func parseHotWalletKey(hexKey string) (*ecdsa.PrivateKey, error) {
+	if hexKey == "" {
+		return nil, nil
+	}
+
+	key, err := crypto.HexToECDSA(strings.TrimPrefix(hexKey, "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid hot wallet private key")
+	}
+
+	return key, nil
+}
+
+// hasHotWallet reports whether withdrawals are paid from the business wallet.
+func (s *EntityService) hasHotWallet() bool {
+	return s.hotWalletKey != nil
+}
+
+// topUpGas sends the wallet of w the AIOZ it lacks to pay the fee of w from
+// the hot wallet, and waits for the transfer to be mined. Nothing is sent when
+// the wallet can pay already. It must be called without holding the lease of
+// the wallet of w.
+func (s *EntityService) topUpGas(ctx context.Context, w *withdrawal) error {
+	balance, err := s.ethClient.BalanceAt(ctx, w.from, nil)
+	if err != nil {
+		return status.Newf(codes.Internal, "failed to get balance").Err()
+	}
+
+	if balance.Cmp(w.fee) >= 0 {
+		return nil
+	}
+
+	// The hot wallet can not top itself up.
+	if !s.hasHotWallet() || w.from == s.hotWalletAddr {
+		return s.insufficientPayoutError(
+			w.from,
+			models.AIOZ_CONTRACT_ADDRESS,
+			decimal.NewFromBigInt(w.fee, 0),
+			decimal.NewFromBigInt(balance, 0),
+		)
+	}
+
+	pricing, err := gasprice.Suggest(ctx, s.ethClient, s.gasCaps)
+	if err != nil {
+		return gasPriceError(err)
+	}
+
+	lease, err := s.nonces.Acquire(ctx, s.hotWalletAddr)
+	if err != nil {
+		return status.Newf(codes.Internal, "failed to get nonce").Err()
+	}
+
+	needed := new(big.Int).Sub(w.fee, balance)
+	tx := pricing.NewTx(s.chainId, lease.Nonce, w.from, needed, nativeTransferGas, nil)
+	signedTx, err := types.SignTx(tx, types.LatestSignerForChainID(s.chainId), s.hotWalletKey)
+	if err != nil {
+		lease.Release(nil)
+		return status.Newf(codes.Internal, "failed to sign gas top-up").Err()
+	}
+
+	// The top-up is recorded against the entity it was sent for, it is a cost
+	// of the business and is not charged to the entity.
+	transaction := &models.Transaction{
+		Id:              uuid.New(),
+		EntityId:        w.entity.Id,
+		EvmHash:         signedTx.Hash().Hex(),
+		ContractAddress: models.AIOZ_CONTRACT_ADDRESS,
+		From:            s.hotWalletAddr.Hex(),
+		To:              w.from.Hex(),
+		Type:            models.CONTRACT_GAS_TOPUP_TYPE,
+		Denom:           aiozcoin.DefaultDenom,
+		Amount:          decimal.NewFromBigInt(needed, 0),
+		Fee:             decimal.NewFromBigInt(new(big.Int).Mul(pricing.MaxFeePerGas(), new(big.Int).SetUint64(nativeTransferGas)), 0),
+		Status:          models.TX_STATUS_PENDING,
+		Nonce:           signedTx.Nonce(),
+		GasLimit:        signedTx.Gas(),
+		GasFeeCap:       decimal.NewFromBigInt(signedTx.GasFeeCap(), 0),
+		GasTipCap:       decimal.NewFromBigInt(signedTx.GasTipCap(), 0),
+		CreatedAt:       time.Now().UTC().Unix(),
+		UpdatedAt:       time.Now().UTC().Unix(),
+	}
+	if err := s.txRepo.Create(ctx, transaction); err != nil {
+		lease.Release(nil)
+		return status.Newf(codes.Internal, "failed to save gas top-up").Err()
+	}
+
+	if err := s.ethClient.SendTransaction(ctx, signedTx); err != nil {
+		lease.Release(err)
+		if err := s.txRepo.UpdateTransactionStatus(ctx, transaction.Id, models.TX_STATUS_FAILED); err != nil {
+			log.Println("UpdateTransactionStatus error ", err)
+		}
+
+		return status.Newf(codes.Internal, "failed to send gas top-up").Err()
+	}
+
+	lease.Commit()
+
+	// A top-up that is not mined in time stays pending, the tracker settles
+	// it and the transfer of w is retried by its caller.
+	waitCtx, cancel := context.WithTimeout(ctx, gasTopUpTimeout)
+	defer cancel()
+	receipt, err := bind.WaitMined(waitCtx, s.ethClient, signedTx)
+	if err != nil {
+		return status.Newf(codes.Internal, "gas top-up was not mined in time, it is left pending").Err()
+	}
+
+	if _, err := s.applyReceipt(ctx, transaction); err != nil {
+		log.Println("applyReceipt error ", err)
+	}
+
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		return status.Newf(codes.Internal, "gas top-up failed").Err()
+	}
+
+	return nil
+}
+
+// getSigningKey returns the key of a wallet withdrawals or top-ups are sent
+// from.
+func (s *EntityService) getSigningKey(ctx context.Context, account common.Address) (*ecdsa.PrivateKey, error) {
+	if s.hasHotWallet() && account == s.hotWalletAddr {
+		return s.hotWalletKey, nil
+	}
+
+	entity, err := s.entityRepo.GetEntityByWalletAddress(ctx, account.Hex())
+	if err != nil {
+		return nil, err
+	}
+
+	return s.getWalletPrivateKey(entity.Wallet)
+}
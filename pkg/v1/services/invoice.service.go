@@ -0,0 +1,191 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+
+	"github.com/vangxitrum/payment-host/internal/models"
+)
+
+// CreateInvoice records a request for a payment to the entity wallet, or to
+// the business wallet with the invoice memo, and returns it open.
+func (s *EntityService) CreateInvoice(ctx context.Context, params *models.InvoiceParams) (*models.Invoice, error) {
+	if params.ExpiresAt <= time.Now().UTC().Unix() {
+		return nil, models.NewInvalidArgumentError(models.REASON_INVALID_ARGUMENT, "ExpiresAt", "expiry must be in the future")
+	}
+
+	entity, err := s.getEntityByName(ctx, params.EntityName)
+	if err != nil {
+		return nil, err
+	}
+
+	contractAddr, amount, err := s.resolveAmount(ctx, params.Amount, params.Denom)
+	if err != nil {
+		return nil, err
+	}
+
+	if !amount.IsPositive() {
+		return nil, models.NewInvalidArgumentError(models.REASON_INVALID_AMOUNT, "Amount", "amount is below the smallest unit of the denom")
+	}
+
+	address := entity.WalletAddress
+	if params.PayByMemo {
+		if !s.hasHotWallet() {
+			return nil, errMemoPaymentsDisabled("PayByMemo")
+		}
+
+		business, _, ok := parseAddress(s.businessWalletAddr)
+		if !ok {
+			return nil, status.Newf(codes.Internal, "invalid business address").Err()
+		}
+
+		address = business.Hex()
+	}
+
+	invoice := models.NewInvoice(entity, params, contractAddr, amount, address)
+	if err := s.invoiceRepo.Create(ctx, invoice); err != nil {
+		return nil, status.Newf(codes.Internal, "failed to create invoice").Err()
+	}
+
+	return invoice, nil
+}
+
+func (s *EntityService) GetInvoice(ctx context.Context, entityName string, id uuid.UUID) (*models.Invoice, error) {
+	invoice, err := s.invoiceRepo.GetInvoiceById(ctx, id)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, models.NewNotFoundError(models.REASON_INVOICE_NOT_FOUND, "invoice", id.String())
+		}
+
+		return nil, status.Newf(codes.Internal, "failed to get invoice").Err()
+	}
+
+	if invoice.EntityName != entityName {
+		return nil, models.NewNotFoundError(models.REASON_INVOICE_NOT_FOUND, "invoice", id.String())
+	}
+
+	return invoice, nil
+}
+
+// CancelInvoice cancels an invoice nothing was paid to yet. Deposits made to a
+// canceled invoice are recorded as plain deposits.
+func (s *EntityService) CancelInvoice(ctx context.Context, entityName string, id uuid.UUID) (*models.Invoice, error) {
+	if _, err := s.GetInvoice(ctx, entityName, id); err != nil {
+		return nil, err
+	}
+
+	canceled, err := s.invoiceRepo.CancelInvoice(ctx, id)
+	if err != nil {
+		return nil, status.Newf(codes.Internal, "failed to cancel invoice").Err()
+	}
+
+	if !canceled {
+		return nil, models.NewFailedPreconditionError(
+			models.REASON_INVOICE_NOT_OPEN,
+			"invoice",
+			"only open invoices can be canceled",
+		)
+	}
+
+	return s.GetInvoice(ctx, entityName, id)
+}
+
+// getInvoiceByMemo returns the invoice memo is the memo of, or nil.
+func (s *EntityService) getInvoiceByMemo(ctx context.Context, memo string) (*models.Invoice, error) {
+	id, err := uuid.Parse(memo)
+	if err != nil {
+		return nil, nil
+	}
+
+	invoice, err := s.invoiceRepo.GetInvoiceById(ctx, id)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+
+		return nil, status.Newf(codes.Internal, "failed to get invoice").Err()
+	}
+
+	return invoice, nil
+}
+
+// matchInvoice returns the invoice a deposit pays, or nil. A deposit carrying
+// the memo of an invoice pays it, any other deposit to an entity wallet pays
+// the oldest invoice of the wallet still waiting for its asset.
+func (s *EntityService) matchInvoice(ctx context.Context, deposit *models.Transaction) (*models.Invoice, error) {
+	if deposit.EntityId == uuid.Nil {
+		return nil, nil
+	}
+
+	contractAddr := deposit.ContractAddress
+	if contractAddr != models.AIOZ_CONTRACT_ADDRESS {
+		contractAddr = common.HexToAddress(contractAddr).Hex()
+	}
+
+	invoice, err := s.getInvoiceByMemo(ctx, deposit.Memo)
+	if err != nil {
+		return nil, err
+	}
+
+	if invoice != nil &&
+		invoice.EntityId == deposit.EntityId &&
+		invoice.Address == deposit.To &&
+		invoice.ContractAddress == contractAddr {
+		return invoice, nil
+	}
+
+	if s.isBusinessAddress(deposit.To) {
+		return nil, nil
+	}
+
+	invoice, err = s.invoiceRepo.FindOpenInvoice(ctx, deposit.To, contractAddr)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+
+		return nil, status.Newf(codes.Internal, "failed to find invoice").Err()
+	}
+
+	return invoice, nil
+}
+
+// recordDeposit stores a deposit mined at paidAt along with the payment of the
+// invoice it pays, if any. Deposits are stored one at a time, so that they are
+// committed in the order of their Seq.
+func (s *EntityService) recordDeposit(ctx context.Context, deposit *models.Transaction, paidAt int64) error {
+	invoice, err := s.matchInvoice(ctx, deposit)
+	if err != nil {
+		return err
+	}
+
+	return s.transact(ctx, func(txService *EntityService) error {
+		if err := txService.txRepo.LockDepositSequence(ctx); err != nil {
+			return err
+		}
+
+		if invoice == nil {
+			return txService.txRepo.Create(ctx, deposit)
+		}
+
+		// An invoice that expired before the deposit was mined, or was
+		// canceled since it was matched, takes no more payments. The deposit
+		// is stored on its own.
+		paid, err := txService.invoiceRepo.ApplyInvoicePayment(ctx, invoice.Id, deposit.Amount, paidAt)
+		if err != nil {
+			return err
+		}
+
+		if paid {
+			deposit.InvoiceId = invoice.Id
+		}
+
+		return txService.txRepo.Create(ctx, deposit)
+	})
+}
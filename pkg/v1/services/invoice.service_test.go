@@ -0,0 +1,193 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"github.com/vangxitrum/payment-host/internal/common/aiozcoin"
+	"github.com/vangxitrum/payment-host/internal/models"
+)
+
+func newInvoiceParams(entity *models.Entity, amount int64, payByMemo bool) *models.InvoiceParams {
+	return &models.InvoiceParams{
+		EntityName: entity.Name,
+		Amount:     decimal.NewFromInt(amount),
+		Denom:      aiozcoin.DefaultDenom,
+		ExpiresAt:  time.Now().Add(time.Hour).Unix(),
+		PayByMemo:  payByMemo,
+	}
+}
+
+// newDeposit is a deposit of amount attoaioz to address as the scanner finds
+// it, before it is recorded.
+func newDeposit(entityId uuid.UUID, address string, amount int64, memo string) *models.Transaction {
+	return &models.Transaction{
+		Id:              uuid.New(),
+		EntityId:        entityId,
+		ContractAddress: models.AIOZ_CONTRACT_ADDRESS,
+		From:            newAddress().Hex(),
+		To:              address,
+		Type:            models.CONTRACT_IN_TYPE,
+		Denom:           aiozcoin.DefaultDenom,
+		Amount:          decimal.NewFromInt(amount),
+		Status:          models.TX_STATUS_NEW,
+		Memo:            memo,
+		CreatedAt:       time.Now().UTC().Unix(),
+		UpdatedAt:       time.Now().UTC().Unix(),
+	}
+}
+
+func TestRecordDepositPaysOldestInvoice(t *testing.T) {
+	ctx := context.Background()
+	env := newTestEnv(t)
+	entity := env.addEntity(t, "shop")
+
+	invoice, err := env.service.CreateInvoice(ctx, newInvoiceParams(entity, 1000, false))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, step := range []struct {
+		amount int64
+		status string
+	}{
+		{600, models.INVOICE_STATUS_PARTIALLY_PAID},
+		{400, models.INVOICE_STATUS_PAID},
+	} {
+		deposit := newDeposit(entity.Id, entity.WalletAddress, step.amount, "")
+		if err := env.service.recordDeposit(ctx, deposit, time.Now().Unix()); err != nil {
+			t.Fatal(err)
+		}
+
+		if deposit.InvoiceId != invoice.Id {
+			t.Fatalf("deposit %d is not linked to the invoice", i)
+		}
+
+		stored, err := env.service.GetInvoice(ctx, entity.Name, invoice.Id)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if stored.Status != step.status {
+			t.Fatalf("after deposit %d invoice is %s, want %s", i, stored.Status, step.status)
+		}
+	}
+
+	// A paid invoice takes no more deposits.
+	deposit := newDeposit(entity.Id, entity.WalletAddress, 100, "")
+	if err := env.service.recordDeposit(ctx, deposit, time.Now().Unix()); err != nil {
+		t.Fatal(err)
+	}
+
+	if deposit.InvoiceId != uuid.Nil {
+		t.Fatal("deposit after payment was linked to the paid invoice")
+	}
+}
+
+func TestRecordDepositByMemo(t *testing.T) {
+	ctx := context.Background()
+	env := newTestEnv(t)
+	entity := env.addEntity(t, "shop")
+	other := env.addEntity(t, "other")
+
+	invoice, err := env.service.CreateInvoice(ctx, newInvoiceParams(entity, 1000, true))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if invoice.Address != env.hotAddr.Hex() {
+		t.Fatalf("invoice is paid to %s, want the business wallet", invoice.Address)
+	}
+
+	entityId, err := env.service.attributeBusinessDeposit(ctx, invoice.Memo, time.Now().Unix())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if entityId != entity.Id {
+		t.Fatalf("invoice memo attributed to %s, want %s", entityId, entity.Id)
+	}
+
+	deposit := newDeposit(entityId, env.hotAddr.Hex(), 1000, invoice.Memo)
+	if err := env.service.recordDeposit(ctx, deposit, time.Now().Unix()); err != nil {
+		t.Fatal(err)
+	}
+
+	stored, err := env.service.GetInvoice(ctx, entity.Name, invoice.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if deposit.InvoiceId != invoice.Id || stored.Status != models.INVOICE_STATUS_PAID {
+		t.Fatalf("invoice is %s, want paid by the deposit", stored.Status)
+	}
+
+	if entityId, err = env.service.attributeBusinessDeposit(ctx, other.Name, time.Now().Unix()); err != nil || entityId != other.Id {
+		t.Fatalf("entity name memo attributed to %s (%v), want %s", entityId, err, other.Id)
+	}
+
+	if entityId, err = env.service.attributeBusinessDeposit(ctx, "unknown", time.Now().Unix()); err != nil || entityId != uuid.Nil {
+		t.Fatalf("unknown memo attributed to %s (%v), want none", entityId, err)
+	}
+}
+
+func TestMemoPaymentsNeedHotWallet(t *testing.T) {
+	ctx := context.Background()
+	env := newTestEnv(t)
+	env.service.hotWalletKey = nil
+	entity := env.addEntity(t, "shop")
+
+	_, err := env.service.CreateInvoice(ctx, newInvoiceParams(entity, 1000, true))
+	requireReason(t, err, models.REASON_MEMO_DISABLED)
+
+	entityId, err := env.service.attributeBusinessDeposit(ctx, entity.Name, time.Now().Unix())
+	if err != nil || entityId != uuid.Nil {
+		t.Fatalf("memo attributed to %s (%v) without the hot wallet, want none", entityId, err)
+	}
+}
+
+func TestLatePaymentsSkipInvoices(t *testing.T) {
+	ctx := context.Background()
+	env := newTestEnv(t)
+	entity := env.addEntity(t, "shop")
+
+	invoice, err := env.service.CreateInvoice(ctx, newInvoiceParams(entity, 1000, false))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The scanner records deposits some time after they were mined, the
+	// invoice has not been expired yet.
+	deposit := newDeposit(entity.Id, entity.WalletAddress, 1000, "")
+	if err := env.service.recordDeposit(ctx, deposit, invoice.ExpiresAt); err != nil {
+		t.Fatal(err)
+	}
+
+	if deposit.InvoiceId != uuid.Nil {
+		t.Fatal("deposit mined after the expiry was linked to the invoice")
+	}
+
+	stored, err := env.service.GetInvoice(ctx, entity.Name, invoice.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if stored.Status != models.INVOICE_STATUS_OPEN || !stored.PaidAmount.IsZero() {
+		t.Fatalf("invoice is %s with %s paid, want open and unpaid", stored.Status, stored.PaidAmount)
+	}
+
+	// A late payment to the business wallet waits in the suspense queue.
+	memoInvoice, err := env.service.CreateInvoice(ctx, newInvoiceParams(entity, 1000, true))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entityId, err := env.service.attributeBusinessDeposit(ctx, memoInvoice.Memo, memoInvoice.ExpiresAt)
+	if err != nil || entityId != uuid.Nil {
+		t.Fatalf("late memo payment attributed to %s (%v), want none", entityId, err)
+	}
+}
@@ -0,0 +1,227 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+
+	"github.com/vangxitrum/payment-host/internal/models"
+)
+
+const (
+	limitDay  = 24 * time.Hour
+	limitWeek = 7 * limitDay
+)
+
+// SetWithdrawalPolicy sets the approval rules and limits for withdrawing
+// params.Denom, for params.EntityName or for every entity when it is empty.
+func (s *EntityService) SetWithdrawalPolicy(
+	ctx context.Context,
+	params *models.WithdrawalPolicyParams,
+) (*models.WithdrawalPolicy, error) {
+	contractAddr, threshold, err := s.resolveAmount(ctx, params.ApprovalThreshold, params.Denom)
+	if err != nil {
+		return nil, err
+	}
+
+	entityId := uuid.Nil
+	if params.EntityName != "" {
+		entity, err := s.getEntityByName(ctx, params.EntityName)
+		if err != nil {
+			return nil, err
+		}
+
+		entityId = entity.Id
+	}
+
+	policy := models.NewWithdrawalPolicy(entityId, contractAddr)
+	policy.ApprovalThreshold = threshold
+	policy.RequiredApprovals = params.RequiredApprovals
+	policy.MaxDailyCount = params.MaxDailyCount
+	policy.AllowlistEnabled = params.AllowlistEnabled
+	policy.AllowlistCooldown = params.AllowlistCooldown
+	for _, limit := range []struct {
+		amount decimal.Decimal
+		base   *decimal.Decimal
+	}{
+		{params.MaxPerTx, &policy.MaxPerTx},
+		{params.DailyCap, &policy.DailyCap},
+		{params.WeeklyCap, &policy.WeeklyCap},
+	} {
+		if _, *limit.base, err = s.resolveAmount(ctx, limit.amount, params.Denom); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.withdrawalRepo.UpsertWithdrawalPolicy(ctx, policy); err != nil {
+		return nil, status.Newf(codes.Internal, "failed to save withdrawal policy").Err()
+	}
+
+	policy, err = s.withdrawalRepo.GetWithdrawalPolicy(ctx, entityId, contractAddr)
+	if err != nil {
+		return nil, status.Newf(codes.Internal, "failed to get withdrawal policy").Err()
+	}
+
+	return policy, nil
+}
+
+// getWithdrawalPolicy returns the policy of entityId for contractAddr, or the
+// global one when the entity has none. It returns nil without any policy.
+func (s *EntityService) getWithdrawalPolicy(
+	ctx context.Context,
+	entityId uuid.UUID,
+	contractAddr string,
+) (*models.WithdrawalPolicy, error) {
+	for _, id := range []uuid.UUID{entityId, uuid.Nil} {
+		policy, err := s.withdrawalRepo.GetWithdrawalPolicy(ctx, id, contractAddr)
+		if err == nil {
+			return policy, nil
+		}
+
+		if err != gorm.ErrRecordNotFound {
+			return nil, status.Newf(codes.Internal, "failed to get withdrawal policy").Err()
+		}
+	}
+
+	return nil, nil
+}
+
+// checkWithdrawalLimits enforces the limits and the allowlist of policy on w.
+// The totals only hold under the entity lock taken by reserveWithdrawal, which
+// checks them again before w is recorded.
+func (s *EntityService) checkWithdrawalLimits(
+	ctx context.Context,
+	policy *models.WithdrawalPolicy,
+	w *withdrawal,
+) error {
+	if policy == nil {
+		return nil
+	}
+
+	if policy.MaxPerTx.IsPositive() && w.amount.GreaterThan(policy.MaxPerTx) {
+		return models.NewLimitExceededError("max_per_tx", w.amount, policy.MaxPerTx)
+	}
+
+	now := time.Now().UTC()
+	if policy.DailyCap.IsPositive() || policy.MaxDailyCount > 0 {
+		total, count, err := s.txRepo.GetWithdrawalTotals(ctx, w.entity.Id, w.call.contractAddr, now.Add(-limitDay).Unix())
+		if err != nil {
+			return status.Newf(codes.Internal, "failed to get withdrawal totals").Err()
+		}
+
+		if policy.DailyCap.IsPositive() && total.Add(w.amount).GreaterThan(policy.DailyCap) {
+			return models.NewLimitExceededError("daily_cap", total.Add(w.amount), policy.DailyCap)
+		}
+
+		if policy.MaxDailyCount > 0 && count >= int64(policy.MaxDailyCount) {
+			return models.NewLimitExceededError(
+				"max_daily_count",
+				decimal.NewFromInt(count+1),
+				decimal.NewFromInt(int64(policy.MaxDailyCount)),
+			)
+		}
+	}
+
+	if policy.WeeklyCap.IsPositive() {
+		total, _, err := s.txRepo.GetWithdrawalTotals(ctx, w.entity.Id, w.call.contractAddr, now.Add(-limitWeek).Unix())
+		if err != nil {
+			return status.Newf(codes.Internal, "failed to get withdrawal totals").Err()
+		}
+
+		if total.Add(w.amount).GreaterThan(policy.WeeklyCap) {
+			return models.NewLimitExceededError("weekly_cap", total.Add(w.amount), policy.WeeklyCap)
+		}
+	}
+
+	if !policy.AllowlistEnabled {
+		return nil
+	}
+
+	destination, err := s.withdrawalRepo.GetAllowedDestination(ctx, w.entity.Id, w.receiver.Hex())
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return models.NewDestinationNotAllowedError(w.receiver.Hex())
+		}
+
+		return status.Newf(codes.Internal, "failed to get allowed destination").Err()
+	}
+
+	if usableAt := destination.CreatedAt + policy.AllowlistCooldown; now.Unix() < usableAt {
+		return models.NewDestinationCoolingDownError(w.receiver.Hex(), usableAt)
+	}
+
+	return nil
+}
+
+// AddAllowedDestination adds address to the allowlist of entityName. It can
+// only be used once the cool-down of the policy has passed.
+func (s *EntityService) AddAllowedDestination(
+	ctx context.Context,
+	entityName, address, label string,
+) (*models.AllowedDestination, error) {
+	addr, _, ok := parseAddress(address)
+	if !ok {
+		return nil, models.NewInvalidArgumentError(models.REASON_INVALID_ADDRESS, "Address", "invalid wallet address")
+	}
+
+	entity, err := s.getEntityByName(ctx, entityName)
+	if err != nil {
+		return nil, err
+	}
+
+	// Destinations are kept in the 0x form, which also covers the aioz1 form
+	// of the same account.
+	destination := models.NewAllowedDestination(entity.Id, addr.Hex(), label)
+	if _, err := s.withdrawalRepo.CreateAllowedDestination(ctx, destination); err != nil {
+		return nil, status.Newf(codes.Internal, "failed to save allowed destination").Err()
+	}
+
+	// Adding an address again keeps its original cool-down.
+	destination, err = s.withdrawalRepo.GetAllowedDestination(ctx, entity.Id, destination.Address)
+	if err != nil {
+		return nil, status.Newf(codes.Internal, "failed to get allowed destination").Err()
+	}
+
+	return destination, nil
+}
+
+func (s *EntityService) RemoveAllowedDestination(ctx context.Context, entityName, address string) error {
+	addr, _, ok := parseAddress(address)
+	if !ok {
+		return models.NewInvalidArgumentError(models.REASON_INVALID_ADDRESS, "Address", "invalid wallet address")
+	}
+
+	entity, err := s.getEntityByName(ctx, entityName)
+	if err != nil {
+		return err
+	}
+
+	if err := s.withdrawalRepo.DeleteAllowedDestination(ctx, entity.Id, addr.Hex()); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return models.NewNotFoundError(models.REASON_DESTINATION_NOT_FOUND, "allowed destination", address)
+		}
+
+		return status.Newf(codes.Internal, "failed to remove allowed destination").Err()
+	}
+
+	return nil
+}
+
+func (s *EntityService) ListAllowedDestinations(ctx context.Context, entityName string) ([]*models.AllowedDestination, error) {
+	entity, err := s.getEntityByName(ctx, entityName)
+	if err != nil {
+		return nil, err
+	}
+
+	destinations, err := s.withdrawalRepo.ListAllowedDestinations(ctx, entity.Id)
+	if err != nil {
+		return nil, status.Newf(codes.Internal, "failed to list allowed destinations").Err()
+	}
+
+	return destinations, nil
+}
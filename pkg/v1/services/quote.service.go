@@ -0,0 +1,102 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+
+	"github.com/vangxitrum/payment-host/internal/models"
+)
+
+// QuoteWithdraw runs the checks of Withdraw on params and prices the transfer
+// without sending it. The quote can be executed by Withdraw until it expires.
+func (s *EntityService) QuoteWithdraw(
+	ctx context.Context,
+	params *models.WithdrawParams,
+) (*models.WithdrawalQuote, error) {
+	w, requiredApprovals, err := s.checkWithdrawal(ctx, params, s.gasCaps)
+	if err != nil {
+		return nil, err
+	}
+
+	quote := models.NewWithdrawalQuote(
+		w.entity,
+		params,
+		w.call.contractAddr,
+		w.amount,
+		decimal.NewFromBigInt(w.fee, 0),
+		w.gasLimit,
+		requiredApprovals,
+	)
+	if err := s.withdrawalRepo.CreateWithdrawalQuote(ctx, quote); err != nil {
+		return nil, status.Newf(codes.Internal, "failed to save withdrawal quote").Err()
+	}
+
+	return quote, nil
+}
+
+// getWithdrawalQuote returns the quote of entityName, failing when it expired
+// or was used already.
+func (s *EntityService) getWithdrawalQuote(
+	ctx context.Context,
+	entityName string,
+	id uuid.UUID,
+) (*models.WithdrawalQuote, error) {
+	quote, err := s.withdrawalRepo.GetWithdrawalQuoteById(ctx, id)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, models.NewNotFoundError(models.REASON_QUOTE_NOT_FOUND, "withdrawal quote", id.String())
+		}
+
+		return nil, status.Newf(codes.Internal, "failed to get withdrawal quote").Err()
+	}
+
+	if quote.EntityName != entityName {
+		return nil, models.NewNotFoundError(models.REASON_QUOTE_NOT_FOUND, "withdrawal quote", id.String())
+	}
+
+	if quote.UsedAt != 0 {
+		return nil, errQuoteUsed()
+	}
+
+	if quote.ExpiresAt <= time.Now().UTC().Unix() {
+		return nil, errQuoteExpired()
+	}
+
+	return quote, nil
+}
+
+// useWithdrawalQuote marks quote as used. It runs in the database transaction
+// recording the withdrawal, so that a quote is only used up by a withdrawal
+// that was recorded.
+func (s *EntityService) useWithdrawalQuote(ctx context.Context, quote *models.WithdrawalQuote) error {
+	// Executions of the same quote can race, only one of them uses it.
+	now := time.Now().UTC().Unix()
+	used, err := s.withdrawalRepo.UseWithdrawalQuote(ctx, quote.Id, now)
+	if err != nil {
+		return status.Newf(codes.Internal, "failed to use withdrawal quote").Err()
+	}
+
+	if used {
+		return nil
+	}
+
+	if quote.ExpiresAt <= now {
+		return errQuoteExpired()
+	}
+
+	return errQuoteUsed()
+}
+
+func errQuoteExpired() error {
+	return models.NewFailedPreconditionError(models.REASON_QUOTE_EXPIRED, "QuoteId", "quote expired")
+}
+
+func errQuoteUsed() error {
+	return models.NewFailedPreconditionError(models.REASON_QUOTE_USED, "QuoteId", "quote was already executed")
+}
@@ -0,0 +1,124 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/vangxitrum/payment-host/internal/models"
+)
+
+// maxRescanBlocks bounds a single rescan so that it finishes within a request.
+const maxRescanBlocks = 10000
+
+func (s *EntityService) GetScannerStatus(ctx context.Context) (*models.ScannerStatus, error) {
+	latestBlock, err := s.getLatestBlock(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	paymentMark, err := s.getOrCreatePaymentMark(ctx, latestBlock)
+	if err != nil {
+		return nil, err
+	}
+
+	return newScannerStatus(paymentMark, latestBlock), nil
+}
+
+// SetPaymentMark moves the scanner cursor, rewinding it makes the scanner
+// process those blocks again. Deposits that were already recorded are not
+// duplicated.
+func (s *EntityService) SetPaymentMark(ctx context.Context, blockNumber int64) (*models.ScannerStatus, error) {
+	s.scanMu.Lock()
+	defer s.scanMu.Unlock()
+
+	latestBlock, err := s.getLatestBlock(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if blockNumber < 0 || blockNumber > latestBlock {
+		return nil, models.NewInvalidArgumentError(models.REASON_INVALID_ARGUMENT, "BlockNumber", fmt.Sprintf("block number must be between 0 and %d", latestBlock))
+	}
+
+	paymentMark, err := s.getOrCreatePaymentMark(ctx, latestBlock)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.paymentMarkRepo.UpdatePaymentMarkByChainId(ctx, s.chainId.Int64(), blockNumber); err != nil {
+		return nil, status.Newf(codes.Internal, "failed to update payment mark").Err()
+	}
+
+	paymentMark.BlockNumber = blockNumber
+	return newScannerStatus(paymentMark, latestBlock), nil
+}
+
+// RescanBlocks scans an explicit block range without moving the scanner
+// cursor and returns how many new deposits were recorded.
+func (s *EntityService) RescanBlocks(ctx context.Context, fromBlock, toBlock int64) (int, error) {
+	if fromBlock < 0 || fromBlock > toBlock {
+		return 0, models.NewInvalidArgumentError(models.REASON_INVALID_ARGUMENT, "FromBlock", "invalid block range")
+	}
+
+	if toBlock-fromBlock >= maxRescanBlocks {
+		return 0, models.NewInvalidArgumentError(models.REASON_INVALID_ARGUMENT, "ToBlock", fmt.Sprintf("block range must not exceed %d blocks", maxRescanBlocks))
+	}
+
+	latestBlock, err := s.getLatestBlock(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	if toBlock > latestBlock {
+		return 0, models.NewInvalidArgumentError(models.REASON_INVALID_ARGUMENT, "ToBlock", fmt.Sprintf("to block must not exceed latest block %d", latestBlock))
+	}
+
+	s.scanMu.Lock()
+	defer s.scanMu.Unlock()
+
+	return s.scanBlocks(ctx, fromBlock, toBlock)
+}
+
+func (s *EntityService) SetScannerPaused(ctx context.Context, paused bool) (*models.ScannerStatus, error) {
+	s.scanMu.Lock()
+	defer s.scanMu.Unlock()
+
+	latestBlock, err := s.getLatestBlock(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	paymentMark, err := s.getOrCreatePaymentMark(ctx, latestBlock)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.paymentMarkRepo.UpdatePaymentMarkPausedByChainId(ctx, s.chainId.Int64(), paused); err != nil {
+		return nil, status.Newf(codes.Internal, "failed to update payment mark").Err()
+	}
+
+	paymentMark.Paused = paused
+	return newScannerStatus(paymentMark, latestBlock), nil
+}
+
+func (s *EntityService) getLatestBlock(ctx context.Context) (int64, error) {
+	chainStatus, err := s.rpcClient.Status(ctx)
+	if err != nil {
+		return 0, status.Newf(codes.Internal, "failed to get status").Err()
+	}
+
+	return chainStatus.SyncInfo.LatestBlockHeight, nil
+}
+
+func newScannerStatus(paymentMark *models.PaymentMark, latestBlock int64) *models.ScannerStatus {
+	return &models.ScannerStatus{
+		ChainId:           paymentMark.ChainId,
+		BlockNumber:       paymentMark.BlockNumber,
+		LatestBlockNumber: latestBlock,
+		Lag:               latestBlock - paymentMark.BlockNumber,
+		Paused:            paymentMark.Paused,
+	}
+}
@@ -0,0 +1,171 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/google/uuid"
+	tmtypes "github.com/tendermint/tendermint/types"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+
+	"github.com/vangxitrum/payment-host/internal/common/cosmostx"
+	"github.com/vangxitrum/payment-host/internal/models"
+)
+
+// isBusinessAddress reports whether addr, in the hex form of the scanner, is
+// the shared business wallet.
+func (s *EntityService) isBusinessAddress(addr string) bool {
+	business, _, ok := parseAddress(s.businessWalletAddr)
+	return ok && addr == business.Hex()
+}
+
+// txMemo returns the memo of a scanned transaction, EVM transactions have
+// none.
+func txMemo(tx tmtypes.Tx) string {
+	memo, err := cosmostx.Memo(tx)
+	if err != nil {
+		log.Println("Memo error ", err)
+	}
+
+	return strings.TrimSpace(memo)
+}
+
+// attributeBusinessDeposit returns the entity a deposit to the business wallet
+// mined at paidAt is for, named by memo or owning the invoice memo is the memo
+// of. Deposits without such a memo, or paying an invoice that expired before
+// paidAt, are left to the suspense queue and returned with a nil id, so are
+// all of them when withdrawals are not paid from the business wallet, since
+// entities could not withdraw them.
+func (s *EntityService) attributeBusinessDeposit(ctx context.Context, memo string, paidAt int64) (uuid.UUID, error) {
+	if memo == "" || !s.hasHotWallet() {
+		return uuid.Nil, nil
+	}
+
+	invoice, err := s.getInvoiceByMemo(ctx, memo)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	if invoice != nil && invoice.PayByMemo {
+		if invoice.ExpiresAt <= paidAt {
+			return uuid.Nil, nil
+		}
+
+		return invoice.EntityId, nil
+	}
+
+	entity, err := s.entityRepo.GetEntityByName(ctx, memo)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return uuid.Nil, nil
+		}
+
+		return uuid.Nil, status.Newf(codes.Internal, "failed to get entity").Err()
+	}
+
+	return entity.Id, nil
+}
+
+// errMemoPaymentsDisabled rejects crediting entities with deposits to the
+// business wallet, they could not withdraw them without its key.
+func errMemoPaymentsDisabled(subject string) error {
+	return models.NewFailedPreconditionError(
+		models.REASON_MEMO_DISABLED,
+		subject,
+		"deposits to the business wallet can not be credited to entities without the hot wallet key",
+	)
+}
+
+// alertSuspense reports a deposit put in the suspense queue.
+func (s *EntityService) alertSuspense(ctx context.Context, transaction *models.Transaction) {
+	text := fmt.Sprintf(
+		"Deposit %s of %s %s from %s to the business wallet was not attributed to an entity (memo %q), it waits in the suspense queue",
+		transaction.Id,
+		transaction.Amount,
+		transaction.ContractAddress,
+		transaction.From,
+		transaction.Memo,
+	)
+	log.Println(text)
+	if err := s.alerts.PostMessage(ctx, text); err != nil {
+		log.Println("PostMessage error ", err)
+	}
+}
+
+// ListSuspenseTransactions returns the deposits to the business wallet no
+// entity could be found for, newest first.
+func (s *EntityService) ListSuspenseTransactions(
+	ctx context.Context,
+	cursor string,
+	pageSize int,
+) ([]*models.Transaction, string, error) {
+	filter := models.TransactionFilter{
+		Unattributed: true,
+		Type:         models.CONTRACT_IN_TYPE,
+	}
+
+	return s.ListTransactions(ctx, "", filter, cursor, pageSize)
+}
+
+// AssignSuspenseTransaction attributes a deposit of the suspense queue to an
+// entity, which can then withdraw it from the business wallet. Subscribers of
+// deposits receive the deposit again, now attributed.
+func (s *EntityService) AssignSuspenseTransaction(
+	ctx context.Context,
+	id uuid.UUID,
+	entityName string,
+) (*models.Transaction, error) {
+	if !s.hasHotWallet() {
+		return nil, errMemoPaymentsDisabled("transaction")
+	}
+
+	entity, err := s.getEntityByName(ctx, entityName)
+	if err != nil {
+		return nil, err
+	}
+
+	var assigned bool
+	if err := s.transact(ctx, func(txService *EntityService) error {
+		if err := txService.txRepo.LockDepositSequence(ctx); err != nil {
+			return status.Newf(codes.Internal, "failed to assign transaction").Err()
+		}
+
+		assigned, err = txService.txRepo.AssignTransaction(ctx, id, entity.Id)
+		if err != nil {
+			return status.Newf(codes.Internal, "failed to assign transaction").Err()
+		}
+
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	if !assigned {
+		if _, err := s.txRepo.GetTransactionById(ctx, id); err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return nil, models.NewNotFoundError(models.REASON_TX_NOT_FOUND, "transaction", id.String())
+			}
+
+			return nil, status.Newf(codes.Internal, "failed to get transaction").Err()
+		}
+
+		return nil, models.NewFailedPreconditionError(
+			models.REASON_TX_ATTRIBUTED,
+			"transaction",
+			"only deposits in the suspense queue can be assigned",
+		)
+	}
+
+	s.deposits.publish()
+
+	transaction, err := s.txRepo.GetTransactionById(ctx, id)
+	if err != nil {
+		return nil, status.Newf(codes.Internal, "failed to get transaction").Err()
+	}
+
+	return transaction, nil
+}
@@ -0,0 +1,237 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/vangxitrum/payment-host/internal/common/erc20"
+	"github.com/vangxitrum/payment-host/internal/common/gasprice"
+	"github.com/vangxitrum/payment-host/internal/models"
+)
+
+const sweepPageSize = 500
+
+// parseSweepThresholds parses asset:amount entries, amounts are in base
+// units. The asset is aioz or the address of a tracked token contract.
+func parseSweepThresholds(entries []string, tokenContracts []common.Address) (map[string]decimal.Decimal, error) {
+	thresholds := make(map[string]decimal.Decimal, len(entries))
+	for _, entry := range entries {
+		asset, value, ok := strings.Cut(strings.TrimSpace(entry), ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid sweep threshold %q", entry)
+		}
+
+		threshold, err := decimal.NewFromString(value)
+		if err != nil || !threshold.IsPositive() {
+			return nil, fmt.Errorf("invalid sweep threshold %q", entry)
+		}
+
+		if asset != models.AIOZ_CONTRACT_ADDRESS {
+			contract, ok := findTokenContract(asset, tokenContracts)
+			if !ok {
+				return nil, fmt.Errorf("sweep threshold for untracked token %q", asset)
+			}
+
+			asset = contract.Hex()
+		}
+
+		thresholds[asset] = threshold
+	}
+
+	return thresholds, nil
+}
+
+// SweepDeposits moves the deposits of each entity wallet to the business
+// wallet once those of an asset add up to its sweep threshold. Assets without
+// a threshold are left alone. Runs never overlap.
+func (s *EntityService) SweepDeposits(ctx context.Context) error {
+	if len(s.sweepThresholds) == 0 || !s.sweepMu.TryLock() {
+		return nil
+	}
+	defer s.sweepMu.Unlock()
+
+	type sweepKey struct {
+		entityId     uuid.UUID
+		contractAddr string
+	}
+
+	groups := make(map[sweepKey][]*models.Transaction)
+	var cursor int64
+	for {
+		deposits, err := s.txRepo.ListUnsweptDeposits(ctx, cursor, sweepPageSize)
+		if err != nil {
+			return status.Newf(codes.Internal, "failed to list unswept deposits").Err()
+		}
+
+		for _, deposit := range deposits {
+			contractAddr := deposit.ContractAddress
+			if contractAddr != models.AIOZ_CONTRACT_ADDRESS {
+				contractAddr = common.HexToAddress(contractAddr).Hex()
+			}
+
+			if _, ok := s.sweepThresholds[contractAddr]; !ok {
+				continue
+			}
+
+			// Deposits attributed by memo are in the business wallet already.
+			if s.isBusinessAddress(deposit.To) {
+				continue
+			}
+
+			key := sweepKey{entityId: deposit.EntityId, contractAddr: contractAddr}
+			groups[key] = append(groups[key], deposit)
+		}
+
+		if len(deposits) < sweepPageSize {
+			break
+		}
+
+		cursor = deposits[len(deposits)-1].Seq
+	}
+
+	for key, deposits := range groups {
+		total := decimal.Zero
+		for _, deposit := range deposits {
+			total = total.Add(deposit.Amount)
+		}
+
+		if total.LessThan(s.sweepThresholds[key.contractAddr]) {
+			continue
+		}
+
+		if err := s.sweep(ctx, key.entityId, key.contractAddr, deposits); err != nil {
+			log.Println("sweep error ", key.entityId, key.contractAddr, err)
+		}
+	}
+
+	return nil
+}
+
+// sweep sends the deposits of contractAddr the entity wallet still holds to
+// the business wallet and links them to the sweep. Deposits are taken in
+// order for as long as the wallet holds all of them, the others are left for
+// a later run. The fee of an AIOZ sweep is taken from the deposits when the
+// wallet holds nothing else, so the linked deposits add up to at most the
+// amount and the fee of the sweep. Nothing is sent when the wallet can not pay
+// the fee and there is no hot wallet.
+func (s *EntityService) sweep(
+	ctx context.Context,
+	entityId uuid.UUID,
+	contractAddr string,
+	deposits []*models.Transaction,
+) error {
+	businessAddr, _, ok := parseAddress(s.businessWalletAddr)
+	if !ok {
+		return fmt.Errorf("invalid business address %q", s.businessWalletAddr)
+	}
+
+	entity, err := s.entityRepo.GetEntityById(ctx, entityId)
+	if err != nil {
+		return err
+	}
+
+	privateKey, err := s.getWalletPrivateKey(entity.Wallet)
+	if err != nil {
+		return err
+	}
+
+	from := common.HexToAddress(entity.WalletAddress)
+	balance, err := s.ethClient.BalanceAt(ctx, from, nil)
+	if err != nil {
+		return err
+	}
+
+	pricing, err := gasprice.Suggest(ctx, s.ethClient, s.gasCaps)
+	if err != nil {
+		return err
+	}
+
+	ids, amount := coveredDeposits(deposits, decimal.NewFromBigInt(balance, 0))
+	gasLimit := nativeTransferGas
+	if contractAddr != models.AIOZ_CONTRACT_ADDRESS {
+		tokenBalance, err := erc20.BalanceOf(ctx, s.ethClient, common.HexToAddress(contractAddr), from)
+		if err != nil {
+			return err
+		}
+
+		ids, amount = coveredDeposits(deposits, decimal.NewFromBigInt(tokenBalance, 0))
+		if !amount.IsPositive() {
+			return nil
+		}
+
+		call, err := newTransferCall(contractAddr, businessAddr, amount.BigInt())
+		if err != nil {
+			return err
+		}
+
+		gasLimit, err = s.ethClient.EstimateGas(ctx, ethereum.CallMsg{
+			From:  from,
+			To:    &call.to,
+			Value: call.value,
+			Data:  call.data,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	fee := new(big.Int).Mul(pricing.MaxFeePerGas(), new(big.Int).SetUint64(gasLimit))
+	if contractAddr == models.AIOZ_CONTRACT_ADDRESS {
+		amount = decimal.Min(amount, decimal.NewFromBigInt(new(big.Int).Sub(balance, fee), 0))
+		if !amount.IsPositive() {
+			return nil
+		}
+	} else if balance.Cmp(fee) < 0 && !s.hasHotWallet() {
+		log.Printf("not enough gas to sweep %s from %s", contractAddr, from.Hex())
+		return nil
+	}
+
+	call, err := newTransferCall(contractAddr, businessAddr, amount.BigInt())
+	if err != nil {
+		return err
+	}
+
+	// A sweep that fails to broadcast is marked failed, its deposits are then
+	// swept again.
+	_, err = s.sendWithdrawal(ctx, &withdrawal{
+		txType:     models.CONTRACT_SWEEP_TYPE,
+		entity:     entity,
+		privateKey: privateKey,
+		from:       from,
+		receiver:   businessAddr,
+		call:       call,
+		amount:     amount,
+		gasLimit:   gasLimit,
+		pricing:    pricing,
+		fee:        fee,
+		deposits:   ids,
+	})
+	return err
+}
+
+// coveredDeposits returns the leading deposits that add up to at most
+// balance, along with their sum.
+func coveredDeposits(deposits []*models.Transaction, balance decimal.Decimal) ([]uuid.UUID, decimal.Decimal) {
+	ids := make([]uuid.UUID, 0, len(deposits))
+	covered := decimal.Zero
+	for _, deposit := range deposits {
+		if covered.Add(deposit.Amount).GreaterThan(balance) {
+			break
+		}
+
+		ids = append(ids, deposit.Id)
+		covered = covered.Add(deposit.Amount)
+	}
+
+	return ids, covered
+}
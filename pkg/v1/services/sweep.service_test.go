@@ -0,0 +1,144 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"github.com/vangxitrum/payment-host/internal/models"
+)
+
+func TestSweepDeposits(t *testing.T) {
+	ctx := context.Background()
+	env := newTestEnv(t)
+	env.service.sweepThresholds[models.AIOZ_CONTRACT_ADDRESS] = decimal.NewFromInt(400000)
+	entity := env.addEntity(t, "shop")
+	first := env.deposit(t, entity, 300000)
+	second := env.deposit(t, entity, 200000)
+	env.backend.setBalance(common.HexToAddress(entity.WalletAddress), 500000)
+
+	if err := env.service.SweepDeposits(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	sent := env.backend.sentTransactions()
+	if len(sent) != 1 || *sent[0].To() != env.hotAddr {
+		t.Fatalf("sent %v, want one sweep to the business wallet", sent)
+	}
+
+	// The wallet pays the fee of the sweep out of the deposits.
+	if got, want := sent[0].Value().Int64(), int64(500000-testTransferFee); got != want {
+		t.Fatalf("swept %d, want %d", got, want)
+	}
+
+	sweep, err := env.txRepo.GetTransactionByEvmHashAndType(ctx, sent[0].Hash().Hex(), models.CONTRACT_SWEEP_TYPE)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, deposit := range []*models.Transaction{first, second} {
+		stored, err := env.txRepo.GetTransactionById(ctx, deposit.Id)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if stored.SweepId != sweep.Id {
+			t.Fatalf("deposit %s is not linked to the sweep", deposit.Id)
+		}
+	}
+
+	// Sweeps move funds within the business, the entity holds as much.
+	if got := env.ledger(t, entity); got != 500000 {
+		t.Fatalf("ledger = %d, want 500000", got)
+	}
+
+	unswept, err := env.txRepo.ListUnsweptDeposits(ctx, 0, sweepPageSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(unswept) != 0 {
+		t.Fatalf("%d deposits left unswept, want none", len(unswept))
+	}
+}
+
+func TestSweepBroadcastFailure(t *testing.T) {
+	ctx := context.Background()
+	env := newTestEnv(t)
+	env.service.sweepThresholds[models.AIOZ_CONTRACT_ADDRESS] = decimal.NewFromInt(100000)
+	entity := env.addEntity(t, "shop")
+	deposit := env.deposit(t, entity, 300000)
+	env.backend.setBalance(common.HexToAddress(entity.WalletAddress), 300000)
+	env.backend.sendErr = errors.New("node unavailable")
+
+	if err := env.service.SweepDeposits(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	stored, err := env.txRepo.GetTransactionById(ctx, deposit.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sweep, err := env.txRepo.GetTransactionById(ctx, stored.SweepId)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if sweep.Status != models.TX_STATUS_FAILED {
+		t.Fatalf("sweep status = %s, want %s", sweep.Status, models.TX_STATUS_FAILED)
+	}
+
+	// The deposits of a failed sweep are swept again.
+	unswept, err := env.txRepo.ListUnsweptDeposits(ctx, 0, sweepPageSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(unswept) != 1 || unswept[0].Id != deposit.Id {
+		t.Fatalf("unswept = %v, want the deposit again", unswept)
+	}
+}
+
+func TestSweepLeavesUncoveredDeposits(t *testing.T) {
+	ctx := context.Background()
+	env := newTestEnv(t)
+	env.service.sweepThresholds[models.AIOZ_CONTRACT_ADDRESS] = decimal.NewFromInt(400000)
+	entity := env.addEntity(t, "shop")
+	first := env.deposit(t, entity, 300000)
+	second := env.deposit(t, entity, 200000)
+	// Part of the deposits left the wallet already.
+	env.backend.setBalance(common.HexToAddress(entity.WalletAddress), 400000)
+
+	if err := env.service.SweepDeposits(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	sent := env.backend.sentTransactions()
+	if len(sent) != 1 || sent[0].Value().Int64() != 300000 {
+		t.Fatalf("sent %v, want one sweep of the first deposit", sent)
+	}
+
+	stored, err := env.txRepo.GetTransactionById(ctx, first.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if stored.SweepId == uuid.Nil {
+		t.Fatal("swept deposit is not linked to the sweep")
+	}
+
+	// The wallet does not hold the second deposit, it waits for a later run.
+	unswept, err := env.txRepo.ListUnsweptDeposits(ctx, 0, sweepPageSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(unswept) != 1 || unswept[0].Id != second.Id {
+		t.Fatalf("unswept = %v, want the second deposit", unswept)
+	}
+}
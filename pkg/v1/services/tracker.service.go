@@ -0,0 +1,366 @@
+package services
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"log"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+
+	"github.com/vangxitrum/payment-host/internal/common/aiozcoin"
+	"github.com/vangxitrum/payment-host/internal/common/gasprice"
+	"github.com/vangxitrum/payment-host/internal/models"
+)
+
+const trackerBatchSize = 100
+
+// trackedTypes are the types of the transactions the service sends.
+var trackedTypes = []string{
+	models.CONTRACT_OUT_TYPE,
+	models.CONTRACT_SWEEP_TYPE,
+	models.CONTRACT_GAS_TOPUP_TYPE,
+	models.CONTRACT_REBALANCE_TYPE,
+}
+
+// TrackWithdrawals moves pending outbound transactions to confirmed or failed
+// once their receipt is available, or to replaced once their nonce was used
+// by another mined transaction.
+func (s *EntityService) TrackWithdrawals(ctx context.Context) error {
+	for _, txType := range trackedTypes {
+		if err := s.trackTransactions(ctx, txType); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *EntityService) trackTransactions(ctx context.Context, txType string) error {
+	filter := models.TransactionFilter{
+		Type:   txType,
+		Status: models.TX_STATUS_PENDING,
+	}
+
+	var cursor int64
+	for {
+		txs, err := s.txRepo.ListTransactionsAfter(ctx, filter, cursor, trackerBatchSize)
+		if err != nil {
+			return status.Newf(codes.Internal, "failed to list pending transactions").Err()
+		}
+
+		for _, tx := range txs {
+			if err := s.trackTransaction(ctx, tx); err != nil {
+				log.Println("trackTransaction error ", tx.Id, err)
+			}
+		}
+
+		if len(txs) < trackerBatchSize {
+			return nil
+		}
+
+		cursor = txs[len(txs)-1].Seq
+	}
+}
+
+func (s *EntityService) trackTransaction(ctx context.Context, tx *models.Transaction) error {
+	applyResult := s.applyReceipt
+	if tx.EvmHash == "" {
+		if tx.CosmosHash == "" {
+			return nil
+		}
+
+		applyResult = s.applyCosmosResult
+	}
+
+	mined, err := applyResult(ctx, tx)
+	if err != nil || mined {
+		return err
+	}
+
+	// Transactions recorded before nonces were kept cannot be told apart
+	// from a replaced one, they wait for their receipt.
+	if tx.GasLimit == 0 {
+		return nil
+	}
+
+	minedNonce, err := s.ethClient.NonceAt(ctx, common.HexToAddress(tx.From), nil)
+	if err != nil {
+		return err
+	}
+
+	if minedNonce <= tx.Nonce {
+		return nil
+	}
+
+	// The nonce is used. Look at the receipt again in case the transaction
+	// itself was mined after the first lookup.
+	mined, err = applyResult(ctx, tx)
+	if err != nil || mined {
+		return err
+	}
+
+	return s.txRepo.UpdateTransactionStatus(ctx, tx.Id, models.TX_STATUS_REPLACED)
+}
+
+// applyReceipt records the outcome of tx when it was mined.
+func (s *EntityService) applyReceipt(ctx context.Context, tx *models.Transaction) (bool, error) {
+	receipt, err := s.ethClient.TransactionReceipt(ctx, common.HexToHash(tx.EvmHash))
+	if errors.Is(err, ethereum.NotFound) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	txStatus := models.TX_STATUS_CONFIRMED
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		txStatus = models.TX_STATUS_FAILED
+	}
+
+	fee := new(big.Int).Mul(new(big.Int).SetUint64(receipt.GasUsed), receipt.EffectiveGasPrice)
+	if err := s.txRepo.UpdateTransactionReceipt(
+		ctx,
+		tx.Id,
+		txStatus,
+		receipt.BlockNumber.Uint64(),
+		decimal.NewFromBigInt(fee, 0),
+	); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// applyCosmosResult records the outcome of a Cosmos tx when it was committed.
+func (s *EntityService) applyCosmosResult(ctx context.Context, tx *models.Transaction) (bool, error) {
+	hash, err := hex.DecodeString(tx.CosmosHash)
+	if err != nil {
+		return false, err
+	}
+
+	rs, err := s.rpcClient.Tx(ctx, hash, false)
+	if err != nil {
+		// The node only knows committed transactions.
+		if strings.Contains(err.Error(), "not found") {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	txStatus := models.TX_STATUS_CONFIRMED
+	if rs.TxResult.Code != 0 {
+		txStatus = models.TX_STATUS_FAILED
+	}
+
+	// Cosmos transactions pay the fee of their whole gas limit, the recorded
+	// fee is what was paid.
+	if err := s.txRepo.UpdateTransactionReceipt(ctx, tx.Id, txStatus, uint64(rs.Height), tx.Fee); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// SpeedUpTransaction resends a pending withdrawal with the same nonce and a
+// higher fee.
+func (s *EntityService) SpeedUpTransaction(ctx context.Context, id uuid.UUID) (*models.Transaction, error) {
+	return s.replaceTransaction(ctx, id, false)
+}
+
+// CancelTransaction replaces a pending withdrawal with a zero-value transfer
+// of the wallet to itself.
+func (s *EntityService) CancelTransaction(ctx context.Context, id uuid.UUID) (*models.Transaction, error) {
+	return s.replaceTransaction(ctx, id, true)
+}
+
+// replaceTransaction holds the nonce lease of the wallet of the transaction
+// while replacing it, so that Reconcile does not fill or adopt its nonce in
+// the meantime.
+func (s *EntityService) replaceTransaction(ctx context.Context, id uuid.UUID, cancel bool) (*models.Transaction, error) {
+	original, err := s.getReplaceableTransaction(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	from := common.HexToAddress(original.From)
+	lease, err := s.nonces.Acquire(ctx, from)
+	if err != nil {
+		return nil, status.Newf(codes.Internal, "failed to get nonce").Err()
+	}
+	defer lease.Release(nil)
+
+	// The transaction may have been mined or replaced while waiting.
+	original, err = s.getReplaceableTransaction(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	privateKey, err := s.getSigningKey(ctx, from)
+	if err != nil {
+		return nil, status.Newf(codes.Internal, "failed to get private key").Err()
+	}
+
+	var call *transferCall
+	gasLimit := original.GasLimit
+	if cancel {
+		call, err = newTransferCall(models.AIOZ_CONTRACT_ADDRESS, from, big.NewInt(0))
+		gasLimit = nativeTransferGas
+	} else {
+		call, err = newTransferCall(original.ContractAddress, common.HexToAddress(original.To), original.Amount.BigInt())
+	}
+	if err != nil {
+		return nil, status.Newf(codes.Internal, "failed to encode transfer").Err()
+	}
+
+	pricing, err := gasprice.Replace(ctx, s.ethClient, s.gasCaps, original.GasTipCap.BigInt(), original.GasFeeCap.BigInt())
+	if err != nil {
+		return nil, gasPriceError(err)
+	}
+
+	if limit, requested, max, ok := s.gasCaps.Exceeds(pricing); ok {
+		return nil, models.NewLimitExceededError(
+			limit,
+			decimal.NewFromBigInt(requested, 0),
+			decimal.NewFromBigInt(max, 0),
+		)
+	}
+
+	fee := new(big.Int).Mul(pricing.MaxFeePerGas(), new(big.Int).SetUint64(gasLimit))
+	if !cancel && !call.isToken() {
+		if err := s.checkSpeedUpBalance(ctx, from, call.value, fee); err != nil {
+			return nil, err
+		}
+	}
+
+	tx := pricing.NewTx(s.chainId, original.Nonce, call.to, call.value, gasLimit, call.data)
+	signedTx, err := types.SignTx(tx, types.LatestSignerForChainID(s.chainId), privateKey)
+	if err != nil {
+		return nil, status.Newf(codes.Internal, "failed to sign transaction").Err()
+	}
+
+	replacement := &models.Transaction{
+		Id:              uuid.New(),
+		EntityId:        original.EntityId,
+		EvmHash:         signedTx.Hash().Hex(),
+		ContractAddress: original.ContractAddress,
+		From:            original.From,
+		To:              original.To,
+		Type:            original.Type,
+		Denom:           original.Denom,
+		Amount:          original.Amount,
+		Fee:             decimal.NewFromBigInt(fee, 0),
+		Status:          models.TX_STATUS_PENDING,
+		Nonce:           signedTx.Nonce(),
+		GasLimit:        signedTx.Gas(),
+		GasFeeCap:       decimal.NewFromBigInt(signedTx.GasFeeCap(), 0),
+		GasTipCap:       decimal.NewFromBigInt(signedTx.GasTipCap(), 0),
+		ReplacesId:      original.Id,
+		CreatedAt:       time.Now().UTC().Unix(),
+		UpdatedAt:       time.Now().UTC().Unix(),
+	}
+	if cancel {
+		replacement.ContractAddress = models.AIOZ_CONTRACT_ADDRESS
+		replacement.To = original.From
+		replacement.Denom = aiozcoin.DefaultDenom
+		replacement.Amount = decimal.Zero
+	}
+
+	// A native speed-up is charged its higher fee once it is mined, the
+	// ledger of the entity must cover the difference.
+	if err := s.transact(ctx, func(txService *EntityService) error {
+		if !cancel && !call.isToken() {
+			if err := txService.entityRepo.LockEntity(ctx, original.EntityId); err != nil {
+				return status.Newf(codes.Internal, "failed to lock entity").Err()
+			}
+
+			available, err := txService.getLedgerBalance(ctx, original.EntityId, models.AIOZ_CONTRACT_ADDRESS)
+			if err != nil {
+				return err
+			}
+
+			if available.Add(original.Fee).LessThan(replacement.Fee) {
+				return errSpeedUpUnfunded()
+			}
+		}
+
+		if err := txService.txRepo.Create(ctx, replacement); err != nil {
+			return status.Newf(codes.Internal, "failed to save transaction").Err()
+		}
+
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	if err := s.ethClient.SendTransaction(ctx, signedTx); err != nil {
+		if err := s.txRepo.UpdateTransactionStatus(ctx, replacement.Id, models.TX_STATUS_FAILED); err != nil {
+			log.Println("UpdateTransactionStatus error ", err)
+		}
+
+		return nil, status.Newf(codes.Internal, "failed to send transaction").Err()
+	}
+
+	return replacement, nil
+}
+
+// getReplaceableTransaction returns the pending withdrawal id, failing when it
+// can not be replaced.
+func (s *EntityService) getReplaceableTransaction(ctx context.Context, id uuid.UUID) (*models.Transaction, error) {
+	original, err := s.txRepo.GetTransactionById(ctx, id)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, models.NewNotFoundError(models.REASON_TX_NOT_FOUND, "transaction", id.String())
+		}
+
+		return nil, status.Newf(codes.Internal, "failed to get transaction").Err()
+	}
+
+	if original.Type != models.CONTRACT_OUT_TYPE ||
+		original.Status != models.TX_STATUS_PENDING ||
+		original.EvmHash == "" ||
+		original.GasLimit == 0 {
+		return nil, models.NewFailedPreconditionError(
+			models.REASON_TX_NOT_PENDING,
+			"transaction",
+			"only pending withdrawals can be replaced",
+		)
+	}
+
+	return original, nil
+}
+
+// checkSpeedUpBalance checks that from can send value again with fee. The
+// transaction being replaced is not mined, so value is still in the balance.
+func (s *EntityService) checkSpeedUpBalance(ctx context.Context, from common.Address, value, fee *big.Int) error {
+	balance, err := s.ethClient.BalanceAt(ctx, from, nil)
+	if err != nil {
+		return status.Newf(codes.Internal, "failed to get balance").Err()
+	}
+
+	if balance.Cmp(new(big.Int).Add(value, fee)) < 0 {
+		return errSpeedUpUnfunded()
+	}
+
+	return nil
+}
+
+func errSpeedUpUnfunded() error {
+	return models.NewFailedPreconditionError(
+		models.REASON_SPEED_UP_UNFUNDED,
+		"transaction",
+		"the balance can not pay the amount with the higher fee, cancel the withdrawal instead",
+	)
+}
@@ -0,0 +1,67 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/vangxitrum/payment-host/internal/models"
+)
+
+func TestSpeedUpKeepsWithdrawalType(t *testing.T) {
+	ctx := context.Background()
+	env := newTestEnv(t)
+	entity := env.addEntity(t, "shop")
+	env.deposit(t, entity, 1000000)
+	env.backend.setBalance(env.hotAddr, 1000000000)
+
+	request, err := env.service.Withdraw(ctx, withdrawParams(entity, 100000, "alice"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	replacement, err := env.service.SpeedUpTransaction(ctx, request.Transaction.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if replacement.Type != models.CONTRACT_OUT_TYPE {
+		t.Fatalf("type = %s, want %s", replacement.Type, models.CONTRACT_OUT_TYPE)
+	}
+
+	// Only the replacement is counted once it is mined.
+	env.backend.mine(env.backend.sentTransactions()[1], types.ReceiptStatusSuccessful)
+	for _, tx := range []*models.Transaction{request.Transaction, replacement} {
+		if err := env.service.trackTransaction(ctx, tx); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if got, want := env.ledger(t, entity), int64(1000000-100000)-replacement.Fee.IntPart(); got != want {
+		t.Fatalf("ledger = %d, want %d", got, want)
+	}
+}
+
+func TestFailedWithdrawalPaysItsFee(t *testing.T) {
+	ctx := context.Background()
+	env := newTestEnv(t)
+	entity := env.addEntity(t, "shop")
+	env.deposit(t, entity, 1000000)
+	env.backend.setBalance(env.hotAddr, 1000000000)
+
+	request, err := env.service.Withdraw(ctx, withdrawParams(entity, 100000, "alice"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	env.backend.mine(env.backend.sentTransactions()[0], types.ReceiptStatusFailed)
+	if err := env.service.trackTransaction(ctx, request.Transaction); err != nil {
+		t.Fatal(err)
+	}
+
+	// The amount is back, the gas burnt by the failed transfer is not.
+	if got, want := env.ledger(t, entity), int64(1000000-testTransferFee); got != want {
+		t.Fatalf("ledger = %d, want %d", got, want)
+	}
+}
@@ -0,0 +1,242 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/big"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/shopspring/decimal"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/vangxitrum/payment-host/internal/common/erc20"
+	"github.com/vangxitrum/payment-host/internal/common/gasprice"
+	"github.com/vangxitrum/payment-host/internal/models"
+)
+
+// treasuryRange is the target range of the hot wallet balance of an asset.
+type treasuryRange struct {
+	min decimal.Decimal
+	max decimal.Decimal
+}
+
+// treasuryAlert is a shortfall of the hot wallet balance of an asset, below
+// what pending withdrawals need or below the target minimum.
+type treasuryAlert struct {
+	asset        string
+	belowPending bool
+}
+
+// treasury keeps the hot wallet balances within their target ranges by
+// moving the excess to the cold wallet.
+type treasury struct {
+	mu       sync.Mutex
+	coldAddr common.Address
+	ranges   map[string]treasuryRange
+	// low holds the shortfalls an alert was raised for, each is cleared once
+	// it is over so that it is reported once.
+	low map[treasuryAlert]bool
+}
+
+// newTreasury parses asset:min:max entries, amounts are in base units. The
+// asset is aioz or the address of a tracked token contract. It returns nil
+// when there are no entries.
+func newTreasury(coldAddr string, entries []string, tokenContracts []common.Address) (*treasury, error) {
+	if len(entries) == 0 {
+		return nil, nil
+	}
+
+	cold, _, ok := parseAddress(coldAddr)
+	if !ok {
+		return nil, fmt.Errorf("invalid treasury cold address %q", coldAddr)
+	}
+
+	ranges := make(map[string]treasuryRange, len(entries))
+	for _, entry := range entries {
+		parts := strings.Split(strings.TrimSpace(entry), ":")
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("invalid treasury range %q", entry)
+		}
+
+		min, err := decimal.NewFromString(parts[1])
+		if err != nil || min.IsNegative() {
+			return nil, fmt.Errorf("invalid treasury range %q", entry)
+		}
+
+		max, err := decimal.NewFromString(parts[2])
+		if err != nil || max.LessThan(min) {
+			return nil, fmt.Errorf("invalid treasury range %q", entry)
+		}
+
+		asset := parts[0]
+		if asset != models.AIOZ_CONTRACT_ADDRESS {
+			contract, ok := findTokenContract(asset, tokenContracts)
+			if !ok {
+				return nil, fmt.Errorf("treasury range for untracked token %q", asset)
+			}
+
+			asset = contract.Hex()
+		}
+
+		ranges[asset] = treasuryRange{min: min, max: max}
+	}
+
+	return &treasury{
+		coldAddr: cold,
+		ranges:   ranges,
+		low:      make(map[treasuryAlert]bool),
+	}, nil
+}
+
+// RebalanceTreasury moves what the hot wallet holds above the target range of
+// an asset to the cold wallet, and raises an alert when the hot balance drops
+// below the range or below what pending withdrawals need. Runs never overlap.
+func (s *EntityService) RebalanceTreasury(ctx context.Context) error {
+	if s.treasury == nil || !s.treasury.mu.TryLock() {
+		return nil
+	}
+	defer s.treasury.mu.Unlock()
+
+	totals, err := s.withdrawalRepo.GetPendingWithdrawalTotals(ctx)
+	if err != nil {
+		return status.Newf(codes.Internal, "failed to get pending withdrawals").Err()
+	}
+
+	pending := make(map[string]decimal.Decimal, len(totals))
+	for contractAddr, total := range totals {
+		if contractAddr != models.AIOZ_CONTRACT_ADDRESS {
+			contractAddr = common.HexToAddress(contractAddr).Hex()
+		}
+
+		pending[contractAddr] = pending[contractAddr].Add(total)
+	}
+
+	for asset, target := range s.treasury.ranges {
+		if err := s.rebalance(ctx, asset, target, pending[asset]); err != nil {
+			log.Println("rebalance error ", asset, err)
+		}
+	}
+
+	return nil
+}
+
+// rebalance checks the hot balance of asset against target and the pending
+// withdrawals, which are all paid from the hot wallet, and sends the excess to
+// the cold wallet. What pending withdrawals need is kept even when it is above
+// the range.
+func (s *EntityService) rebalance(
+	ctx context.Context,
+	asset string,
+	target treasuryRange,
+	pending decimal.Decimal,
+) error {
+	hotAddr := s.hotWalletAddr
+	var balance *big.Int
+	var err error
+	if asset == models.AIOZ_CONTRACT_ADDRESS {
+		balance, err = s.ethClient.BalanceAt(ctx, hotAddr, nil)
+	} else {
+		balance, err = erc20.BalanceOf(ctx, s.ethClient, common.HexToAddress(asset), hotAddr)
+	}
+	if err != nil {
+		return err
+	}
+
+	hot := decimal.NewFromBigInt(balance, 0)
+	belowPending := treasuryAlert{asset: asset, belowPending: true}
+	if hot.LessThan(pending) {
+		s.alertTreasury(ctx, belowPending, fmt.Sprintf(
+			"Hot wallet %s holds %s of %s, pending withdrawals need %s",
+			hotAddr.Hex(), hot, asset, pending,
+		))
+	} else {
+		delete(s.treasury.low, belowPending)
+	}
+
+	belowMin := treasuryAlert{asset: asset}
+	if hot.LessThan(target.min) {
+		s.alertTreasury(ctx, belowMin, fmt.Sprintf(
+			"Hot wallet %s holds %s of %s, below its target minimum of %s",
+			hotAddr.Hex(), hot, asset, target.min,
+		))
+	} else {
+		delete(s.treasury.low, belowMin)
+	}
+
+	keep := decimal.Max(target.max, pending)
+	if !hot.GreaterThan(keep) {
+		return nil
+	}
+
+	pricing, err := gasprice.Suggest(ctx, s.ethClient, s.gasCaps)
+	if err != nil {
+		return err
+	}
+
+	amount := hot.Sub(keep)
+	call, err := newTransferCall(asset, s.treasury.coldAddr, amount.BigInt())
+	if err != nil {
+		return err
+	}
+
+	gasLimit := nativeTransferGas
+	if call.isToken() {
+		gasLimit, err = s.ethClient.EstimateGas(ctx, ethereum.CallMsg{
+			From:  hotAddr,
+			To:    &call.to,
+			Value: call.value,
+			Data:  call.data,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	// The fee of a native transfer comes out of the excess.
+	fee := new(big.Int).Mul(pricing.MaxFeePerGas(), new(big.Int).SetUint64(gasLimit))
+	if !call.isToken() {
+		amount = amount.Sub(decimal.NewFromBigInt(fee, 0))
+		if !amount.IsPositive() {
+			return nil
+		}
+
+		call, err = newTransferCall(asset, s.treasury.coldAddr, amount.BigInt())
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = s.sendWithdrawal(ctx, &withdrawal{
+		txType:     models.CONTRACT_REBALANCE_TYPE,
+		privateKey: s.hotWalletKey,
+		from:       hotAddr,
+		receiver:   s.treasury.coldAddr,
+		call:       call,
+		amount:     amount,
+		gasLimit:   gasLimit,
+		pricing:    pricing,
+		fee:        fee,
+	})
+
+	return err
+}
+
+// alertTreasury posts text unless an alert was raised for alert already.
+func (s *EntityService) alertTreasury(ctx context.Context, alert treasuryAlert, text string) {
+	if s.treasury.low[alert] {
+		return
+	}
+
+	log.Println(text)
+	if err := s.alerts.PostMessage(ctx, text); err != nil {
+		log.Println("PostMessage error ", err)
+		return
+	}
+
+	s.treasury.low[alert] = true
+}
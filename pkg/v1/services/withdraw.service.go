@@ -0,0 +1,835 @@
+package services
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/vangxitrum/payment-host/internal/common/aiozcoin"
+	"github.com/vangxitrum/payment-host/internal/common/blockchain"
+	"github.com/vangxitrum/payment-host/internal/common/cosmostx"
+	"github.com/vangxitrum/payment-host/internal/common/erc20"
+	"github.com/vangxitrum/payment-host/internal/common/gasprice"
+	"github.com/vangxitrum/payment-host/internal/models"
+)
+
+const (
+	// nativeTransferGas is the gas used by a plain value transfer to an
+	// account.
+	nativeTransferGas = uint64(21000)
+
+	// Simulated Cosmos gas is raised by 30% to leave room for state changes
+	// between the simulation and the execution.
+	bankGasAdjustmentNum = 13
+	bankGasAdjustmentDen = 10
+)
+
+// transferCall is the EVM call that moves an asset out of a wallet: a plain
+// value transfer for AIOZ, a transfer(address,uint256) call for tokens.
+type transferCall struct {
+	contractAddr string
+	to           common.Address
+	value        *big.Int
+	data         []byte
+}
+
+func newTransferCall(contractAddr string, receiverAddr common.Address, amount *big.Int) (*transferCall, error) {
+	if contractAddr == models.AIOZ_CONTRACT_ADDRESS {
+		return &transferCall{
+			contractAddr: contractAddr,
+			to:           receiverAddr,
+			value:        amount,
+		}, nil
+	}
+
+	data, err := erc20.PackTransfer(receiverAddr, amount)
+	if err != nil {
+		return nil, err
+	}
+
+	return &transferCall{
+		contractAddr: contractAddr,
+		to:           common.HexToAddress(contractAddr),
+		value:        big.NewInt(0),
+		data:         data,
+	}, nil
+}
+
+func (c *transferCall) isToken() bool {
+	return c.contractAddr != models.AIOZ_CONTRACT_ADDRESS
+}
+
+// withdrawal is a validated and priced transfer that is ready to be signed.
+// Withdrawals are sent from the payout wallet of the entity, sweeps from its
+// wallet and rebalances from the business wallet.
+type withdrawal struct {
+	// txType is the type the transfer is recorded with.
+	txType     string
+	entity     *models.Entity
+	privateKey *ecdsa.PrivateKey
+	from       common.Address
+	receiver   common.Address
+	call       *transferCall
+	amount     decimal.Decimal
+	gasLimit   uint64
+	pricing    *gasprice.Pricing
+	// bank is set for native withdrawals to aioz1 addresses, which are sent
+	// as a Cosmos bank transfer rather than an EVM transaction.
+	bank *cosmostx.Send
+	// fee is the most the transaction can be charged, in attoaioz.
+	fee *big.Int
+	// deposits are the deposits a sweep moves, they are linked to it when it
+	// is recorded.
+	deposits []uuid.UUID
+	// quote is the quote a withdrawal executes, it is used when the
+	// withdrawal is recorded.
+	quote *models.WithdrawalQuote
+}
+
+// ledgerDebit is what w takes from the ledger of its entity. The fee of a
+// token transfer is paid in AIOZ by the business.
+func (w *withdrawal) ledgerDebit() decimal.Decimal {
+	if w.call.isToken() {
+		return w.amount
+	}
+
+	return w.amount.Add(decimal.NewFromBigInt(w.fee, 0))
+}
+
+func (w *withdrawal) denom() string {
+	if w.call.isToken() {
+		return w.call.contractAddr
+	}
+
+	return aiozcoin.DefaultDenom
+}
+
+// Withdraw records a withdrawal request. Requests over the approval threshold
+// of the entity wait for approvers, any other request is executed right away
+// and returned along with its transaction. A quoted withdrawal executed right
+// away sends the quoted amount and is priced at most at the quoted fee. The
+// quote is used by the request waiting for approvers, or by the transaction,
+// so a withdrawal failing before either is recorded leaves it usable.
+func (s *EntityService) Withdraw(
+	ctx context.Context,
+	params *models.WithdrawParams,
+) (*models.WithdrawalRequest, error) {
+	caps := s.gasCaps
+	var quote *models.WithdrawalQuote
+	if params.QuoteId != uuid.Nil {
+		var err error
+		quote, err = s.getWithdrawalQuote(ctx, params.EntityName, params.QuoteId)
+		if err != nil {
+			return nil, err
+		}
+
+		params = quote.Params(params.RequestedBy)
+		caps.MaxFeePerGas = quote.MaxFeePerGas.BigInt()
+	}
+
+	w, requiredApprovals, err := s.checkWithdrawal(ctx, params, caps)
+	if err != nil {
+		return nil, err
+	}
+
+	// The price is held to the quoted one, the gas can still have grown.
+	if quote != nil && w.fee.Cmp(quote.Fee.BigInt()) > 0 {
+		return nil, models.NewFailedPreconditionError(
+			models.REASON_QUOTE_FEE_EXCEEDED,
+			"QuoteId",
+			"fee is above the quoted fee, request a new quote",
+		)
+	}
+
+	request := models.NewWithdrawalRequest(w.entity, params, w.call.contractAddr, w.amount, requiredApprovals)
+	if err := s.transact(ctx, func(txService *EntityService) error {
+		if err := txService.withdrawalRepo.CreateWithdrawalRequest(ctx, request); err != nil {
+			return status.Newf(codes.Internal, "failed to save withdrawal request").Err()
+		}
+
+		if quote != nil && request.Status != models.WITHDRAWAL_STATUS_APPROVED {
+			return txService.useWithdrawalQuote(ctx, quote)
+		}
+
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	if request.Status != models.WITHDRAWAL_STATUS_APPROVED {
+		return request, nil
+	}
+
+	w.quote = quote
+	return s.executeWithdrawalRequest(ctx, request, w)
+}
+
+// executeWithdrawalRequest sends the transfer of an approved request, w is
+// the prepared transfer or nil to prepare it again from the request.
+func (s *EntityService) executeWithdrawalRequest(
+	ctx context.Context,
+	request *models.WithdrawalRequest,
+	w *withdrawal,
+) (*models.WithdrawalRequest, error) {
+	var err error
+	if w == nil {
+		w, err = s.prepareApprovedWithdrawal(ctx, request)
+	}
+
+	var transaction *models.Transaction
+	if err == nil {
+		transaction, err = s.sendWithdrawal(ctx, w)
+	}
+
+	if err != nil {
+		if err := s.withdrawalRepo.CompleteWithdrawalRequest(
+			ctx,
+			request.Id,
+			nil,
+			models.WITHDRAWAL_STATUS_FAILED,
+			status.Convert(err).Message(),
+		); err != nil {
+			log.Println("CompleteWithdrawalRequest error ", err)
+		}
+
+		return nil, err
+	}
+
+	if err := s.withdrawalRepo.CompleteWithdrawalRequest(
+		ctx,
+		request.Id,
+		&transaction.Id,
+		models.WITHDRAWAL_STATUS_EXECUTED,
+		"",
+	); err != nil {
+		log.Println("CompleteWithdrawalRequest error ", err)
+	}
+
+	request.Status = models.WITHDRAWAL_STATUS_EXECUTED
+	request.TransactionId = &transaction.Id
+	request.Transaction = transaction
+	return request, nil
+}
+
+// prepareApprovedWithdrawal prepares the transfer of a request that was
+// approved some time after it was made, so the limits are checked again. Only
+// the approved amount is ever sent.
+func (s *EntityService) prepareApprovedWithdrawal(
+	ctx context.Context,
+	request *models.WithdrawalRequest,
+) (*withdrawal, error) {
+	w, _, err := s.checkWithdrawal(ctx, request.Params(), s.gasCaps)
+	if err != nil {
+		return nil, err
+	}
+
+	if !w.amount.Equal(request.Amount) {
+		return nil, models.NewFailedPreconditionError(
+			models.REASON_WITHDRAWAL_CHANGED,
+			"withdrawal request",
+			"withdrawal amount differs from the approved amount",
+		)
+	}
+
+	return w, nil
+}
+
+// checkWithdrawal prepares the transfer of params within caps and checks it
+// against the policy of the entity. It returns the approvals the withdrawal
+// needs.
+func (s *EntityService) checkWithdrawal(
+	ctx context.Context,
+	params *models.WithdrawParams,
+	caps gasprice.Caps,
+) (*withdrawal, int, error) {
+	w, err := s.prepareWithdrawal(ctx, params, caps)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	policy, err := s.getWithdrawalPolicy(ctx, w.entity.Id, w.call.contractAddr)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if err := s.checkWithdrawalLimits(ctx, policy, w); err != nil {
+		return nil, 0, err
+	}
+
+	requiredApprovals := 0
+	if policy != nil && w.amount.GreaterThan(policy.ApprovalThreshold) {
+		requiredApprovals = policy.RequiredApprovals
+	}
+
+	return w, requiredApprovals, nil
+}
+
+// sendWithdrawal signs and broadcasts w with the next nonce of its wallet.
+func (s *EntityService) sendWithdrawal(ctx context.Context, w *withdrawal) (*models.Transaction, error) {
+	// Token transfers are paid for in AIOZ the wallet may not hold. The top-up
+	// is waited for before the lease is taken, so that other transfers of the
+	// wallet are not held up meanwhile. Top-ups only go to entity wallets,
+	// which then only send sweeps, and sweeps never overlap, so nothing else
+	// spends the top-up.
+	if w.call.isToken() {
+		if err := s.topUpGas(ctx, w); err != nil {
+			return nil, err
+		}
+	}
+
+	lease, err := s.nonces.Acquire(ctx, w.from)
+	if err != nil {
+		return nil, status.Newf(codes.Internal, "failed to get nonce").Err()
+	}
+
+	transaction, broadcast, err := s.signWithdrawal(w, lease.Nonce)
+	if err != nil {
+		lease.Release(nil)
+		return nil, status.Newf(codes.Internal, "failed to sign transaction").Err()
+	}
+
+	// The record is stored before broadcasting so that a transfer can never
+	// reach the chain without leaving a trace in the transaction history. A
+	// withdrawal is stored along with the check of the ledger it debits, a
+	// sweep along with the links of its deposits.
+	if err := s.transact(ctx, func(txService *EntityService) error {
+		if w.txType == models.CONTRACT_OUT_TYPE {
+			if err := txService.reserveWithdrawal(ctx, w); err != nil {
+				return err
+			}
+		}
+
+		if err := txService.txRepo.Create(ctx, transaction); err != nil {
+			return status.Newf(codes.Internal, "failed to save transaction").Err()
+		}
+
+		if len(w.deposits) > 0 {
+			if err := txService.txRepo.SetTransactionsSweepId(ctx, w.deposits, transaction.Id); err != nil {
+				return status.Newf(codes.Internal, "failed to link swept deposits").Err()
+			}
+		}
+
+		return nil
+	}); err != nil {
+		lease.Release(nil)
+		return nil, err
+	}
+
+	if err := broadcast(ctx); err != nil {
+		lease.Release(err)
+		if err := s.txRepo.UpdateTransactionStatus(ctx, transaction.Id, models.TX_STATUS_FAILED); err != nil {
+			log.Println("UpdateTransactionStatus error ", err)
+		}
+
+		return nil, status.Newf(codes.Internal, "failed to send transaction").Err()
+	}
+
+	lease.Commit()
+
+	return transaction, nil
+}
+
+// reserveWithdrawal locks the entity of w and checks w against its ledger and
+// its limits again, so that concurrent withdrawals can neither spend the same
+// deposits nor all fit under a limit only one of them fits under, and uses the
+// quote of w. It must run in the database transaction recording w.
+func (s *EntityService) reserveWithdrawal(ctx context.Context, w *withdrawal) error {
+	if err := s.entityRepo.LockEntity(ctx, w.entity.Id); err != nil {
+		return status.Newf(codes.Internal, "failed to lock entity").Err()
+	}
+
+	if err := s.checkLedgerBalance(ctx, w.entity.Id, w.call.contractAddr, w.ledgerDebit()); err != nil {
+		return err
+	}
+
+	policy, err := s.getWithdrawalPolicy(ctx, w.entity.Id, w.call.contractAddr)
+	if err != nil {
+		return err
+	}
+
+	if err := s.checkWithdrawalLimits(ctx, policy, w); err != nil {
+		return err
+	}
+
+	if w.quote != nil {
+		return s.useWithdrawalQuote(ctx, w.quote)
+	}
+
+	return nil
+}
+
+// signWithdrawal signs w with nonce and returns its record along with the
+// function broadcasting it.
+func (s *EntityService) signWithdrawal(
+	w *withdrawal,
+	nonce uint64,
+) (*models.Transaction, func(context.Context) error, error) {
+	// Treasury transfers belong to no entity.
+	var entityId uuid.UUID
+	if w.entity != nil {
+		entityId = w.entity.Id
+	}
+
+	transaction := &models.Transaction{
+		Id:              uuid.New(),
+		EntityId:        entityId,
+		ContractAddress: w.call.contractAddr,
+		From:            w.from.Hex(),
+		To:              w.receiver.Hex(),
+		Type:            w.txType,
+		Denom:           w.denom(),
+		Amount:          w.amount,
+		Fee:             decimal.NewFromBigInt(w.fee, 0),
+		Status:          models.TX_STATUS_PENDING,
+		Nonce:           nonce,
+		GasLimit:        w.gasLimit,
+		CreatedAt:       time.Now().UTC().Unix(),
+		UpdatedAt:       time.Now().UTC().Unix(),
+	}
+
+	if w.bank != nil {
+		// The sequence of the account on the Cosmos side is the nonce of the
+		// wallet on the EVM side, they share the nonce manager.
+		w.bank.Sequence = nonce
+		txBytes, err := w.bank.Sign(w.privateKey)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		transaction.CosmosHash = cosmostx.Hash(txBytes)
+		transaction.To = sdk.AccAddress(w.receiver.Bytes()).String()
+		return transaction, func(ctx context.Context) error {
+			return cosmostx.Broadcast(ctx, s.rpcClient, txBytes)
+		}, nil
+	}
+
+	tx := w.pricing.NewTx(s.chainId, nonce, w.call.to, w.call.value, w.gasLimit, w.call.data)
+	signedTx, err := types.SignTx(tx, types.LatestSignerForChainID(s.chainId), w.privateKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	transaction.EvmHash = signedTx.Hash().Hex()
+	transaction.GasFeeCap = decimal.NewFromBigInt(signedTx.GasFeeCap(), 0)
+	transaction.GasTipCap = decimal.NewFromBigInt(signedTx.GasTipCap(), 0)
+	return transaction, func(ctx context.Context) error {
+		return s.ethClient.SendTransaction(ctx, signedTx)
+	}, nil
+}
+
+// ReconcileNonces resynchronizes the nonces of the wallets withdrawals were
+// signed with and fills the gaps left by transactions the node never saw.
+func (s *EntityService) ReconcileNonces(ctx context.Context) error {
+	if err := s.nonces.Reconcile(ctx, s.fillNonceGap); err != nil {
+		return status.Newf(codes.Internal, "failed to reconcile nonces").Err()
+	}
+
+	return nil
+}
+
+// fillNonceGap occupies nonce with a zero-value transfer of the wallet to
+// itself, so the transactions queued behind it can be mined.
+func (s *EntityService) fillNonceGap(ctx context.Context, account common.Address, nonce uint64) error {
+	privateKey, err := s.getSigningKey(ctx, account)
+	if err != nil {
+		return err
+	}
+
+	pricing, err := gasprice.Suggest(ctx, s.ethClient, s.gasCaps)
+	if err != nil {
+		return err
+	}
+
+	tx := pricing.NewTx(s.chainId, nonce, account, big.NewInt(0), nativeTransferGas, nil)
+	signedTx, err := types.SignTx(tx, types.LatestSignerForChainID(s.chainId), privateKey)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("filling nonce gap %d of %s with %s", nonce, account.Hex(), signedTx.Hash().Hex())
+	return s.ethClient.SendTransaction(ctx, signedTx)
+}
+
+// prepareWithdrawal validates params against the ledger of the entity and
+// prices the transfer within caps. The entity must hold the amount, plus the
+// network fee for AIOZ, and the payout wallet must hold the amount plus the
+// fee, which is always paid in AIOZ. With params.Max the amount is the whole
+// ledger balance, less the fee for native withdrawals, unless
+// params.BaseAmount sets it. Native withdrawals to aioz1 addresses are sent as
+// a Cosmos bank transfer, tokens only exist on the EVM side and go to the 0x
+// form of the address.
+func (s *EntityService) prepareWithdrawal(
+	ctx context.Context,
+	params *models.WithdrawParams,
+	caps gasprice.Caps,
+) (*withdrawal, error) {
+	contractAddr, amount, err := s.resolveAmount(ctx, params.Amount, params.Denom)
+	if err != nil {
+		return nil, err
+	}
+
+	receiverAddr, bech32, ok := parseAddress(params.ReceiverAddress)
+	if !ok {
+		return nil, models.NewInvalidArgumentError(models.REASON_INVALID_ADDRESS, "ReceiverWalletAddress", "invalid wallet address")
+	}
+
+	entity, err := s.getEntityByName(ctx, params.EntityName)
+	if err != nil {
+		return nil, err
+	}
+
+	from, privateKey, err := s.payoutWallet(entity)
+	if err != nil {
+		return nil, err
+	}
+
+	available, err := s.getLedgerBalance(ctx, entity.Id, contractAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	// A quoted or approved amount is sent as is, Max only sizes new
+	// withdrawals.
+	sendAll := params.Max
+	if params.BaseAmount.IsPositive() {
+		amount = params.BaseAmount
+		sendAll = false
+	}
+
+	if sendAll {
+		amount = available
+	}
+
+	balance, err := s.ethClient.BalanceAt(ctx, from, nil)
+	if err != nil {
+		return nil, status.Newf(codes.Internal, "failed to get balance").Err()
+	}
+
+	isToken := contractAddr != models.AIOZ_CONTRACT_ADDRESS
+	if isToken {
+		if !amount.IsPositive() || available.LessThan(amount) {
+			return nil, models.NewInsufficientBalanceError(contractAddr, decimal.Max(amount, decimal.NewFromInt(1)), available)
+		}
+
+		tokenBalance, err := erc20.BalanceOf(ctx, s.ethClient, common.HexToAddress(contractAddr), from)
+		if err != nil {
+			return nil, status.Newf(codes.Internal, "failed to get token balance").Err()
+		}
+
+		if tokenBalance.Cmp(amount.BigInt()) < 0 {
+			return nil, s.insufficientPayoutError(from, contractAddr, amount, decimal.NewFromBigInt(tokenBalance, 0))
+		}
+	}
+
+	call, err := newTransferCall(contractAddr, receiverAddr, amount.BigInt())
+	if err != nil {
+		return nil, status.Newf(codes.Internal, "failed to encode transfer").Err()
+	}
+
+	var (
+		gasLimit uint64
+		pricing  *gasprice.Pricing
+		bank     *cosmostx.Send
+		fee      *big.Int
+	)
+	if bech32 && !isToken {
+		bank, err = s.prepareBankSend(ctx, privateKey, from, receiverAddr, amount, caps)
+		if err != nil {
+			return nil, err
+		}
+
+		gasLimit = bank.GasLimit
+		fee = bank.Fee.AmountOf(aiozcoin.DefaultDenom).BigInt()
+	} else {
+		gasLimit, err = s.ethClient.EstimateGas(ctx, ethereum.CallMsg{
+			From:  from,
+			To:    &call.to,
+			Value: call.value,
+			Data:  call.data,
+		})
+		if err != nil {
+			return nil, status.Newf(codes.Internal, "failed to estimate gas").Err()
+		}
+
+		pricing, err = gasprice.Suggest(ctx, s.ethClient, caps)
+		if err != nil {
+			return nil, gasPriceError(err)
+		}
+
+		// With dynamic fees the actual cost depends on the base fee at
+		// inclusion, so the fee is the most the transaction can be charged.
+		fee = new(big.Int).Mul(pricing.MaxFeePerGas(), new(big.Int).SetUint64(gasLimit))
+	}
+
+	if !isToken && sendAll {
+		amount = amount.Sub(decimal.NewFromBigInt(fee, 0))
+		call.value = amount.BigInt()
+		if bank != nil {
+			bank.Amount = bankCoins(call.value)
+		}
+	}
+
+	required := decimal.NewFromBigInt(fee, 0)
+	if !isToken {
+		required = required.Add(decimal.Max(amount, decimal.NewFromInt(1)))
+		if available.LessThan(required) {
+			return nil, models.NewInsufficientBalanceError(models.AIOZ_CONTRACT_ADDRESS, required, available)
+		}
+	}
+
+	if balance.Cmp(required.BigInt()) < 0 {
+		return nil, s.insufficientPayoutError(from, models.AIOZ_CONTRACT_ADDRESS, required, decimal.NewFromBigInt(balance, 0))
+	}
+
+	return &withdrawal{
+		txType:     models.CONTRACT_OUT_TYPE,
+		entity:     entity,
+		privateKey: privateKey,
+		from:       from,
+		receiver:   receiverAddr,
+		call:       call,
+		amount:     amount,
+		gasLimit:   gasLimit,
+		pricing:    pricing,
+		bank:       bank,
+		fee:        fee,
+	}, nil
+}
+
+// payoutWallet returns the wallet withdrawals of entity are paid from: the
+// business wallet when its key is set, the entity wallet otherwise.
+func (s *EntityService) payoutWallet(entity *models.Entity) (common.Address, *ecdsa.PrivateKey, error) {
+	if s.hasHotWallet() {
+		return s.hotWalletAddr, s.hotWalletKey, nil
+	}
+
+	privateKey, err := s.getWalletPrivateKey(entity.Wallet)
+	if err != nil {
+		return common.Address{}, nil, err
+	}
+
+	return common.HexToAddress(entity.WalletAddress), privateKey, nil
+}
+
+// insufficientPayoutError reports that the payout wallet from holds less than
+// required of asset. The business wallet running short is no fault of the
+// entity, the withdrawal can be retried once the wallet is refilled.
+func (s *EntityService) insufficientPayoutError(
+	from common.Address,
+	asset string,
+	required decimal.Decimal,
+	balance decimal.Decimal,
+) error {
+	if s.hasHotWallet() && from == s.hotWalletAddr {
+		return models.NewFailedPreconditionError(
+			models.REASON_PAYOUT_UNAVAILABLE,
+			"business wallet",
+			"the withdrawal can not be paid right now, retry later",
+		)
+	}
+
+	return models.NewInsufficientBalanceError(asset, required, balance)
+}
+
+// getLedgerBalance returns what entityId holds of contractAddr according to
+// its transaction history.
+func (s *EntityService) getLedgerBalance(ctx context.Context, entityId uuid.UUID, contractAddr string) (decimal.Decimal, error) {
+	balance, err := s.txRepo.GetLedgerBalance(ctx, entityId, contractAddr)
+	if err != nil {
+		return decimal.Zero, status.Newf(codes.Internal, "failed to get ledger balance").Err()
+	}
+
+	return balance, nil
+}
+
+// checkLedgerBalance checks that entityId holds at least required of
+// contractAddr.
+func (s *EntityService) checkLedgerBalance(
+	ctx context.Context,
+	entityId uuid.UUID,
+	contractAddr string,
+	required decimal.Decimal,
+) error {
+	available, err := s.getLedgerBalance(ctx, entityId, contractAddr)
+	if err != nil {
+		return err
+	}
+
+	if available.LessThan(required) {
+		return models.NewInsufficientBalanceError(contractAddr, required, available)
+	}
+
+	return nil
+}
+
+// prepareBankSend builds the Cosmos transfer of amount from the wallet to
+// receiver, its gas is sized by simulating it.
+func (s *EntityService) prepareBankSend(
+	ctx context.Context,
+	privateKey *ecdsa.PrivateKey,
+	from common.Address,
+	receiver common.Address,
+	amount decimal.Decimal,
+	caps gasprice.Caps,
+) (*cosmostx.Send, error) {
+	chainStatus, err := s.rpcClient.Status(ctx)
+	if err != nil {
+		return nil, status.Newf(codes.Internal, "failed to get chain status").Err()
+	}
+
+	accountNumber, err := cosmostx.AccountNumber(ctx, s.rpcClient, sdk.AccAddress(from.Bytes()))
+	if err != nil {
+		return nil, status.Newf(codes.Internal, "failed to get account").Err()
+	}
+
+	sequence, err := s.ethClient.PendingNonceAt(ctx, from)
+	if err != nil {
+		return nil, status.Newf(codes.Internal, "failed to get nonce").Err()
+	}
+
+	gasPrice, err := gasprice.Flat(ctx, s.ethClient, caps)
+	if err != nil {
+		return nil, status.Newf(codes.Internal, "failed to get gas price").Err()
+	}
+
+	send := &cosmostx.Send{
+		ChainId:       chainStatus.NodeInfo.Network,
+		AccountNumber: accountNumber,
+		Sequence:      sequence,
+		To:            sdk.AccAddress(receiver.Bytes()),
+		Amount:        bankCoins(amount.BigInt()),
+	}
+	txBytes, err := send.Sign(privateKey)
+	if err != nil {
+		return nil, status.Newf(codes.Internal, "failed to sign transaction").Err()
+	}
+
+	gasUsed, err := cosmostx.Simulate(ctx, s.rpcClient, txBytes)
+	if err != nil {
+		return nil, status.Newf(codes.Internal, "failed to estimate gas").Err()
+	}
+
+	// Cosmos transactions pay for their whole gas limit up front.
+	send.GasLimit = gasUsed * bankGasAdjustmentNum / bankGasAdjustmentDen
+	send.Fee = bankCoins(new(big.Int).Mul(gasPrice, new(big.Int).SetUint64(send.GasLimit)))
+	return send, nil
+}
+
+// gasPriceError reports a failure to price a transaction, a fee cap the base
+// fee has outgrown is the caller's to retry later.
+func gasPriceError(err error) error {
+	if errors.Is(err, gasprice.ErrFeeCapTooLow) {
+		return models.NewFailedPreconditionError(
+			models.REASON_FEE_CAP_TOO_LOW,
+			"MaxFeePerGas",
+			"the network base fee is above the fee cap, retry later",
+		)
+	}
+
+	return status.Newf(codes.Internal, "failed to get gas price").Err()
+}
+
+func bankCoins(amount *big.Int) sdk.Coins {
+	return sdk.NewCoins(sdk.NewCoin(aiozcoin.DefaultDenom, sdk.NewIntFromBigInt(amount)))
+}
+
+// parseAddress accepts the 0x and the aioz1 form of an account address, both
+// designate the same account. It reports whether addr was in the aioz1 form.
+func parseAddress(addr string) (common.Address, bool, bool) {
+	if common.IsHexAddress(addr) {
+		return common.HexToAddress(addr), false, true
+	}
+
+	accAddr, err := sdk.AccAddressFromBech32(addr)
+	if err != nil || len(accAddr) != common.AddressLength {
+		return common.Address{}, false, false
+	}
+
+	return common.BytesToAddress(accAddr), true, true
+}
+
+// resolveAmount converts an API amount into base units. Native denominations
+// resolve to models.AIOZ_CONTRACT_ADDRESS, any other denom must be the address
+// of a tracked token contract.
+func (s *EntityService) resolveAmount(
+	ctx context.Context,
+	amount decimal.Decimal,
+	denom string,
+) (string, decimal.Decimal, error) {
+	if blockchain.IsNativeDenom(denom) {
+		baseAmount, err := blockchain.ToBaseAmount(amount, denom)
+		if err != nil {
+			return "", decimal.Zero, models.NewInvalidArgumentError(models.REASON_INVALID_AMOUNT, "Amount", err.Error())
+		}
+
+		return models.AIOZ_CONTRACT_ADDRESS, baseAmount, nil
+	}
+
+	contract, ok := s.getTokenContract(denom)
+	if !ok {
+		return "", decimal.Zero, models.NewInvalidArgumentError(models.REASON_UNSUPPORTED_DENOM, "Denom", fmt.Sprintf("unsupported denom %q", denom))
+	}
+
+	decimals, err := erc20.Decimals(ctx, s.ethClient, contract)
+	if err != nil {
+		return "", decimal.Zero, status.Newf(codes.Internal, "failed to get token decimals").Err()
+	}
+
+	baseAmount, err := blockchain.ScaleAmount(amount, int32(decimals))
+	if err != nil {
+		return "", decimal.Zero, models.NewInvalidArgumentError(models.REASON_INVALID_AMOUNT, "Amount", err.Error())
+	}
+
+	return contract.Hex(), baseAmount, nil
+}
+
+func (s *EntityService) getTokenContract(addr string) (common.Address, bool) {
+	return findTokenContract(addr, s.tokenContracts)
+}
+
+func findTokenContract(addr string, tokenContracts []common.Address) (common.Address, bool) {
+	if !common.IsHexAddress(addr) {
+		return common.Address{}, false
+	}
+
+	contract := common.HexToAddress(addr)
+	for _, tokenContract := range tokenContracts {
+		if tokenContract == contract {
+			return contract, true
+		}
+	}
+
+	return common.Address{}, false
+}
+
+func (s *EntityService) getWalletPrivateKey(wallet *models.Wallet) (*ecdsa.PrivateKey, error) {
+	if wallet == nil {
+		return nil, status.Newf(codes.Internal, "entity has no wallet").Err()
+	}
+
+	privateKeyBytes, err := models.Decrypt(wallet.PrivateKey, s.passphrase)
+	if err != nil {
+		return nil, status.Newf(codes.Internal, "failed to decrypt private key").Err()
+	}
+
+	privateKey, err := crypto.ToECDSA(privateKeyBytes)
+	if err != nil {
+		return nil, status.Newf(codes.Internal, "failed to get private key").Err()
+	}
+
+	return privateKey, nil
+}
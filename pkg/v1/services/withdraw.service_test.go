@@ -0,0 +1,297 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/vangxitrum/payment-host/internal/models"
+)
+
+func TestWithdrawPaysFromHotWallet(t *testing.T) {
+	ctx := context.Background()
+	env := newTestEnv(t)
+	entity := env.addEntity(t, "shop")
+	env.deposit(t, entity, 1000000)
+	env.backend.setBalance(env.hotAddr, 1000000000)
+
+	request, err := env.service.Withdraw(ctx, withdrawParams(entity, 100000, "alice"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if request.Status != models.WITHDRAWAL_STATUS_EXECUTED {
+		t.Fatalf("status = %s, want %s", request.Status, models.WITHDRAWAL_STATUS_EXECUTED)
+	}
+
+	if request.Transaction.From != env.hotAddr.Hex() {
+		t.Fatalf("sent from %s, want the hot wallet %s", request.Transaction.From, env.hotAddr.Hex())
+	}
+
+	if sent := env.backend.sentTransactions(); len(sent) != 1 || sent[0].Value().Int64() != 100000 {
+		t.Fatalf("sent %v, want one transfer of 100000", sent)
+	}
+
+	if got, want := env.ledger(t, entity), int64(1000000-100000-testTransferFee); got != want {
+		t.Fatalf("ledger = %d, want %d", got, want)
+	}
+}
+
+func TestWithdrawAboveLedger(t *testing.T) {
+	ctx := context.Background()
+	env := newTestEnv(t)
+	entity := env.addEntity(t, "shop")
+	env.deposit(t, entity, 100000)
+	env.backend.setBalance(env.hotAddr, 1000000000)
+
+	// The fee is taken from the ledger too.
+	_, err := env.service.Withdraw(ctx, withdrawParams(entity, 100000, "alice"))
+	requireReason(t, err, models.REASON_INSUFFICIENT_BALANCE)
+
+	if sent := env.backend.sentTransactions(); len(sent) != 0 {
+		t.Fatalf("sent %d transactions, want none", len(sent))
+	}
+}
+
+func TestConcurrentWithdrawalsShareLedger(t *testing.T) {
+	ctx := context.Background()
+	env := newTestEnv(t)
+	entity := env.addEntity(t, "shop")
+	env.deposit(t, entity, 1000000)
+	env.backend.setBalance(env.hotAddr, 1000000000)
+
+	// Each withdrawal fits the ledger on its own, not both together. Both are
+	// checked before either is recorded.
+	errs := make([]error, 2)
+	var checked sync.WaitGroup
+	checked.Add(len(errs))
+	env.backend.onEstimateGas = func() {
+		checked.Done()
+		checked.Wait()
+	}
+
+	var wg sync.WaitGroup
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, errs[i] = env.service.Withdraw(ctx, withdrawParams(entity, 600000, "alice"))
+		}(i)
+	}
+	wg.Wait()
+
+	failed := 0
+	for _, err := range errs {
+		if err != nil {
+			requireReason(t, err, models.REASON_INSUFFICIENT_BALANCE)
+			failed++
+		}
+	}
+
+	if failed != 1 {
+		t.Fatalf("%d withdrawals failed, want 1", failed)
+	}
+
+	if sent := env.backend.sentTransactions(); len(sent) != 1 {
+		t.Fatalf("sent %d transactions, want 1", len(sent))
+	}
+
+	if got, want := env.ledger(t, entity), int64(1000000-600000-testTransferFee); got != want {
+		t.Fatalf("ledger = %d, want %d", got, want)
+	}
+}
+
+func TestWithdrawHotWalletShort(t *testing.T) {
+	ctx := context.Background()
+	env := newTestEnv(t)
+	entity := env.addEntity(t, "shop")
+	env.deposit(t, entity, 1000000)
+	env.backend.setBalance(env.hotAddr, 1000)
+
+	_, err := env.service.Withdraw(ctx, withdrawParams(entity, 100000, "alice"))
+	requireReason(t, err, models.REASON_PAYOUT_UNAVAILABLE)
+}
+
+// capDailyWithdrawals caps what entity withdraws in AIOZ per day.
+func (e *testEnv) capDailyWithdrawals(t *testing.T, entity *models.Entity, dailyCap int64) {
+	t.Helper()
+
+	policy := models.NewWithdrawalPolicy(entity.Id, models.AIOZ_CONTRACT_ADDRESS)
+	policy.DailyCap = decimal.NewFromInt(dailyCap)
+	if err := e.withdrawalRepo.UpsertWithdrawalPolicy(context.Background(), policy); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWithdrawDailyCap(t *testing.T) {
+	ctx := context.Background()
+	env := newTestEnv(t)
+	entity := env.addEntity(t, "shop")
+	env.deposit(t, entity, 1000000)
+	env.backend.setBalance(env.hotAddr, 1000000000)
+	env.capDailyWithdrawals(t, entity, 150000)
+
+	if _, err := env.service.Withdraw(ctx, withdrawParams(entity, 100000, "alice")); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := env.service.Withdraw(ctx, withdrawParams(entity, 100000, "alice"))
+	requireReason(t, err, models.REASON_LIMIT_EXCEEDED)
+
+	if sent := env.backend.sentTransactions(); len(sent) != 1 {
+		t.Fatalf("sent %d transactions, want 1", len(sent))
+	}
+}
+
+func TestConcurrentWithdrawalsShareDailyCap(t *testing.T) {
+	ctx := context.Background()
+	env := newTestEnv(t)
+	entity := env.addEntity(t, "shop")
+	env.deposit(t, entity, 1000000)
+	env.backend.setBalance(env.hotAddr, 1000000000)
+	env.capDailyWithdrawals(t, entity, 150000)
+
+	// Both withdrawals fit the cap when checked, before either is recorded.
+	errs := make([]error, 2)
+	var checked sync.WaitGroup
+	checked.Add(len(errs))
+	env.store.onWithdrawalTotals = func() {
+		checked.Done()
+		checked.Wait()
+	}
+
+	var wg sync.WaitGroup
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, errs[i] = env.service.Withdraw(ctx, withdrawParams(entity, 100000, "alice"))
+		}(i)
+	}
+	wg.Wait()
+
+	failed := 0
+	for _, err := range errs {
+		if err != nil {
+			requireReason(t, err, models.REASON_LIMIT_EXCEEDED)
+			failed++
+		}
+	}
+
+	if failed != 1 {
+		t.Fatalf("%d withdrawals failed, want 1", failed)
+	}
+
+	if sent := env.backend.sentTransactions(); len(sent) != 1 {
+		t.Fatalf("sent %d transactions, want 1", len(sent))
+	}
+}
+
+func TestQuotedWithdrawalSendsQuotedAmount(t *testing.T) {
+	ctx := context.Background()
+	env := newTestEnv(t)
+	entity := env.addEntity(t, "shop")
+	env.deposit(t, entity, 1000000)
+	env.backend.setBalance(env.hotAddr, 1000000000)
+
+	params := withdrawParams(entity, 0, "alice")
+	params.Max = true
+	quote, err := env.service.QuoteWithdraw(ctx, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := int64(1000000 - testTransferFee); quote.Amount.IntPart() != want {
+		t.Fatalf("quoted %s, want %d", quote.Amount, want)
+	}
+
+	// A deposit made after the quote does not grow a quoted Max withdrawal.
+	env.deposit(t, entity, 500000)
+
+	request, err := env.service.Withdraw(ctx, quotedParams(entity, quote))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !request.Transaction.Amount.Equal(quote.Amount) {
+		t.Fatalf("sent %s, want the quoted %s", request.Transaction.Amount, quote.Amount)
+	}
+
+	if got := env.ledger(t, entity); got != 500000 {
+		t.Fatalf("ledger = %d, want 500000", got)
+	}
+
+	_, err = env.service.Withdraw(ctx, quotedParams(entity, quote))
+	requireReason(t, err, models.REASON_QUOTE_USED)
+}
+
+func TestQuoteKeptWhenWithdrawalFails(t *testing.T) {
+	ctx := context.Background()
+	env := newTestEnv(t)
+	entity := env.addEntity(t, "shop")
+	env.deposit(t, entity, 1000000)
+	env.backend.setBalance(env.hotAddr, 1000000000)
+
+	quote, err := env.service.QuoteWithdraw(ctx, withdrawParams(entity, 100000, "alice"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	env.backend.setBalance(env.hotAddr, 0)
+	_, err = env.service.Withdraw(ctx, quotedParams(entity, quote))
+	requireReason(t, err, models.REASON_PAYOUT_UNAVAILABLE)
+
+	env.backend.setBalance(env.hotAddr, 1000000000)
+	request, err := env.service.Withdraw(ctx, quotedParams(entity, quote))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if request.Status != models.WITHDRAWAL_STATUS_EXECUTED {
+		t.Fatalf("status = %s, want %s", request.Status, models.WITHDRAWAL_STATUS_EXECUTED)
+	}
+}
+
+func TestQuoteKeptWhenTransactionNotSaved(t *testing.T) {
+	ctx := context.Background()
+	env := newTestEnv(t)
+	entity := env.addEntity(t, "shop")
+	env.deposit(t, entity, 1000000)
+	env.backend.setBalance(env.hotAddr, 1000000000)
+
+	quote, err := env.service.QuoteWithdraw(ctx, withdrawParams(entity, 100000, "alice"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The quote is used in the transaction that fails to save the transfer.
+	env.store.createTxErr = errors.New("database is down")
+	if _, err := env.service.Withdraw(ctx, quotedParams(entity, quote)); err == nil {
+		t.Fatal("withdrawal succeeded without saving its transaction")
+	}
+
+	stored, err := env.withdrawalRepo.GetWithdrawalQuoteById(ctx, quote.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if stored.UsedAt != 0 {
+		t.Fatal("quote used by a withdrawal that was rolled back")
+	}
+
+	if sent := env.backend.sentTransactions(); len(sent) != 0 {
+		t.Fatalf("sent %d transactions, want none", len(sent))
+	}
+}
+
+// quotedParams executes quote.
+func quotedParams(entity *models.Entity, quote *models.WithdrawalQuote) *models.WithdrawParams {
+	return &models.WithdrawParams{
+		EntityName:  entity.Name,
+		QuoteId:     quote.Id,
+		RequestedBy: "alice",
+	}
+}